@@ -0,0 +1,92 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// splitDataStream splits a pattern of the form `file:stream` into its file and
+// stream parts. It reports false if the pattern does not name a data stream.
+func splitDataStream(pattern string) (file, stream string, ok bool) {
+	if runtime.GOOS != "windows" {
+		return "", "", false
+	}
+	dir, base := filepath.Split(pattern)
+	i := strings.IndexByte(base, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	return dir + base[:i], base[i+1:], true
+}
+
+// streamDataStreams finds the files matching filePattern and sends the paths of
+// those of their alternate data streams whose names match streamPattern down
+// the results channel.
+func (w *walker) streamDataStreams(filePattern, streamPattern string, results chan<- found) error {
+	if _, err := filepath.Match(streamPattern, ""); err != nil {
+		return badPattern(streamPattern)
+	}
+
+	files := make(chan found)
+	var streamErr error
+	go func() {
+		streamErr = w.stream(filePattern, files, false)
+		close(files)
+	}()
+
+	for f := range files {
+		if err := w.globDataStreams(f.path, streamPattern, results); err != nil {
+			// Drain channel before returning
+			for range files {
+			}
+			return err
+		}
+	}
+
+	return streamErr
+}
+
+// globDataStreams sends the paths of the named data streams of file that match
+// pattern down the results channel. It stops if the cancel channel is closed.
+func (w *walker) globDataStreams(file, pattern string, results chan<- found) error {
+	np, ok := w.fsys.native(file)
+	if !ok {
+		// Only the operating system's filesystem has data streams.
+		return nil
+	}
+	names, err := dataStreams(np)
+	if errors.Is(err, fs.ErrNotExist) {
+		// It was deleted since it was matched.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if w.opts.sorted {
+		sort.Strings(names)
+	}
+	for _, n := range names {
+		matched, err := filepath.Match(pattern, n)
+		if err != nil {
+			return err
+		}
+		if matched {
+			select {
+			case results <- found{path: file + ":" + n}:
+			case <-w.cancel:
+				return nil
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobAlternateDataStreams(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skipf("skipping windows specific test")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestGlobAlternateDataStreams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"a.txt", "a.txt:zone", "a.txt:extra", "b.txt", "c.log:zone"} {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.txt:*", []string{"a.txt:extra", "a.txt:zone"}},
+		{"*:zone", []string{"a.txt:zone", "c.log:zone"}},
+		{"b.txt:*", []string{}},
+		{"*.txt", []string{"a.txt", "b.txt"}},
+	} {
+		pattern := filepath.Join(tmpDir, tt.pattern)
+		matches, err := Glob(context.Background(), pattern, WithAlternateDataStreams())
+		if err != nil {
+			t.Errorf("Glob error for %q: %s", pattern, err)
+			continue
+		}
+		want := make([]string, 0)
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, w))
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+		}
+	}
+
+	if _, err := Glob(context.Background(), filepath.Join(tmpDir, "*.txt:[]"), WithAlternateDataStreams()); err == nil {
+		t.Error("expected error for bad stream pattern; got none")
+	}
+}
+
+func TestNonWindowsGlobColon(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("skipping non-windows specific test")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestNonWindowsGlobColon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "a.txt:zone"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := filepath.Join(tmpDir, "*:zone")
+	matches, err := Glob(context.Background(), pattern, WithAlternateDataStreams())
+	if err != nil {
+		t.Fatalf("Glob error for %q: %s", pattern, err)
+	}
+	want := []string{filepath.Join(tmpDir, "a.txt:zone")}
+	if diff := cmp.Diff(want, matches); diff != "" {
+		t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32          = syscall.NewLazyDLL("kernel32.dll")
+	procFindFirstStreamW = modkernel32.NewProc("FindFirstStreamW")
+	procFindNextStreamW  = modkernel32.NewProc("FindNextStreamW")
+)
+
+// win32FindStreamData mirrors WIN32_FIND_STREAM_DATA.
+type win32FindStreamData struct {
+	StreamSize int64
+	StreamName [syscall.MAX_PATH + 36]uint16
+}
+
+const errorHandleEOF syscall.Errno = 38 // ERROR_HANDLE_EOF
+
+// dataStreams returns the names of the named data streams of file. Files
+// without any (including directories) yield an empty result.
+func dataStreams(file string) ([]string, error) {
+	p, err := syscall.UTF16PtrFromString(extendedLengthPath(file))
+	if err != nil {
+		return nil, err
+	}
+	var data win32FindStreamData
+	h, _, e := procFindFirstStreamW.Call(uintptr(unsafe.Pointer(p)), 0, uintptr(unsafe.Pointer(&data)), 0)
+	if syscall.Handle(h) == syscall.InvalidHandle {
+		if e == errorHandleEOF {
+			return nil, nil
+		}
+		return nil, e
+	}
+	defer syscall.FindClose(syscall.Handle(h))
+
+	var names []string
+	for {
+		// Stream names have the form ":name:$DATA"; the default stream is
+		// "::$DATA".
+		name := syscall.UTF16ToString(data.StreamName[:])
+		name = strings.TrimSuffix(strings.TrimPrefix(name, ":"), ":$DATA")
+		if name != "" {
+			names = append(names, name)
+		}
+		if r, _, e := procFindNextStreamW.Call(h, uintptr(unsafe.Pointer(&data))); r == 0 {
+			if e == errorHandleEOF {
+				return names, nil
+			}
+			return names, e
+		}
+	}
+}
+
+// extendedLengthPath returns the \\?\ form of path if it is too long for the
+// Win32 API to accept as it is. The os package does the same for its own
+// calls, but not for ours.
+func extendedLengthPath(path string) string {
+	const maxShortPath = 248 // MAX_PATH, less room for an 8.3 name
+	if strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\??\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) < maxShortPath {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
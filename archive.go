@@ -0,0 +1,253 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveOptions configures TarMatches and ZipMatches.
+type ArchiveOptions struct {
+	// Root is the directory that the names of archive entries are relative
+	// to. If it is empty, entries are named by their matches, without any
+	// volume name or leading separator.
+	Root string
+	// Concurrency is the number of files opened and read ahead of the one
+	// being written. It defaults to 4.
+	Concurrency int
+}
+
+// readAheadSize is the size up to which files are read in full ahead of being
+// written, bounding the memory used for read-ahead to Concurrency times this.
+const readAheadSize = 256 << 10
+
+// TarMatches writes the remaining matches of r to tw, one entry per match, in
+// the order the matches are produced; to build reproducible archives, use
+// WithSortedOrder. Directories are written as directory entries without their
+// contents, and symbolic links as links. Other special files are skipped, as
+// are matches deleted before they could be read.
+//
+// Files are opened and small ones read in parallel, up to
+// opts.Concurrency ahead of the entry being written. opts may be nil. Closing
+// tw is left to the caller.
+func TarMatches(ctx context.Context, r *Result, tw *tar.Writer, opts *ArchiveOptions) error {
+	return archiveMatches(ctx, r, opts, func(e *archiveEntry) error {
+		hdr, err := tar.FileInfoHeader(e.fi, e.link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = e.name
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		return e.copyTo(tw)
+	})
+}
+
+// ZipMatches is like TarMatches, but writes to a zip archive. Regular files
+// are compressed with Deflate, and symbolic links are stored as files holding
+// their targets, marked as links.
+func ZipMatches(ctx context.Context, r *Result, zw *zip.Writer, opts *ArchiveOptions) error {
+	return archiveMatches(ctx, r, opts, func(e *archiveEntry) error {
+		hdr, err := zip.FileInfoHeader(e.fi)
+		if err != nil {
+			return err
+		}
+		hdr.Name = e.name
+		if e.fi.Mode().IsRegular() {
+			hdr.Method = zip.Deflate
+		}
+		dst, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if e.link != "" {
+			_, err := io.WriteString(dst, e.link)
+			return err
+		}
+		return e.copyTo(dst)
+	})
+}
+
+// archiveEntry is a match prepared for writing to an archive.
+type archiveEntry struct {
+	path string
+	name string
+	fi   fs.FileInfo
+	link string   // target, if a symbolic link
+	data []byte   // contents, if read ahead
+	f    *os.File // open file, if a regular file not read ahead
+	err  error
+	skip bool
+}
+
+// copyTo writes the contents of e, if any, to dst.
+func (e *archiveEntry) copyTo(dst io.Writer) error {
+	if e.f != nil {
+		defer e.f.Close()
+		n, err := io.CopyN(dst, e.f, e.fi.Size())
+		if err == io.EOF {
+			return fmt.Errorf("glob: %s shrank from %d to %d bytes while being archived", e.path, e.fi.Size(), n)
+		}
+		return err
+	}
+	_, err := dst.Write(e.data)
+	return err
+}
+
+// archiveMatches calls write with each remaining match of r, in order, while
+// preparing the following ones in parallel.
+func archiveMatches(ctx context.Context, r *Result, opts *ArchiveOptions, write func(*archiveEntry) error) error {
+	var o ArchiveOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each match gets a channel on which its prepared entry is delivered;
+	// the channels are queued in match order.
+	queue := make(chan chan *archiveEntry, o.Concurrency)
+	sem := make(chan struct{}, o.Concurrency)
+	var matchErr error
+	go func() {
+		defer close(queue)
+		for {
+			m, err := r.NextWithContext(ctx)
+			if err != nil || m == "" {
+				matchErr = err
+				r.Close()
+				return
+			}
+			done := make(chan *archiveEntry, 1)
+			select {
+			case queue <- done:
+			case <-ctx.Done():
+				r.Close()
+				return
+			}
+			sem <- struct{}{}
+			go func() {
+				defer func() { <-sem }()
+				done <- prepareEntry(m, o.Root)
+			}()
+		}
+	}()
+
+	var err error
+	for done := range queue {
+		e := <-done
+		if err != nil || e.skip {
+			e.close()
+			continue
+		}
+		if e.err != nil {
+			err = e.err
+		} else {
+			err = write(e)
+		}
+		e.close()
+		if err != nil {
+			cancel()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return matchErr
+}
+
+// close releases the open file of e, if any.
+func (e *archiveEntry) close() {
+	if e.f != nil {
+		e.f.Close()
+		e.f = nil
+	}
+}
+
+// prepareEntry stats the match p and reads what is needed to archive it.
+func prepareEntry(p, root string) *archiveEntry {
+	e := &archiveEntry{path: p}
+	name, err := archiveName(p, root)
+	if err != nil {
+		e.err = err
+		return e
+	}
+	if name == "" {
+		// The root itself.
+		e.skip = true
+		return e
+	}
+	e.name = name
+
+	e.fi, e.err = os.Lstat(p)
+	switch {
+	case errors.Is(e.err, fs.ErrNotExist):
+		e.err, e.skip = nil, true
+	case e.err != nil:
+	case e.fi.IsDir():
+		e.name += "/"
+	case e.fi.Mode()&fs.ModeSymlink != 0:
+		e.link, e.err = os.Readlink(p)
+	case !e.fi.Mode().IsRegular():
+		e.skip = true
+	case e.fi.Size() <= readAheadSize:
+		var b bytes.Buffer
+		b.Grow(int(e.fi.Size()))
+		f, err := os.Open(p)
+		if err != nil {
+			e.err = err
+			break
+		}
+		_, e.err = io.CopyN(&b, f, e.fi.Size())
+		f.Close()
+		if e.err == io.EOF {
+			e.err = fmt.Errorf("glob: %s shrank from %d to %d bytes while being archived", p, e.fi.Size(), b.Len())
+		}
+		e.data = b.Bytes()
+	default:
+		e.f, e.err = os.Open(p)
+	}
+	if errors.Is(e.err, fs.ErrNotExist) {
+		e.err, e.skip = nil, true
+	}
+	return e
+}
+
+// archiveName returns the slash-separated name of the archive entry for the
+// match p, or "" if p is the root.
+func archiveName(p, root string) (string, error) {
+	if root != "" {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return "", err
+		}
+		if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("glob: %s is outside the archive root %s", p, root)
+		}
+		if rel == "." {
+			return "", nil
+		}
+		p = rel
+	}
+	p = p[len(filepath.VolumeName(p)):]
+	return strings.TrimLeft(filepath.ToSlash(p), "/"), nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// archiveTree creates a tree to archive in a new temporary directory, and
+// returns the directory and the contents of each file in it.
+func archiveTree(t *testing.T) (string, map[string]string) {
+	t.Helper()
+	tmpDir, err := ioutil.TempDir("", "TestArchive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"b.txt":     "bee",
+		"a.txt":     "ay",
+		"dir/c.txt": "sea",
+		"dir/big":   strings.Repeat("x", 3*readAheadSize),
+		"empty":     "",
+	}
+	for name, data := range files {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir, files
+}
+
+func TestTarMatches(t *testing.T) {
+	tmpDir, files := archiveTree(t)
+	defer os.RemoveAll(tmpDir)
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("a.txt", filepath.Join(tmpDir, "link")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var archives [][]byte
+	for _, concurrency := range []int{1, 4} {
+		r := Stream(filepath.Join(Escape(tmpDir), "**"), WithGlobstar(), WithSortedOrder())
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		if err := TarMatches(context.Background(), &r, tw, &ArchiveOptions{Root: tmpDir, Concurrency: concurrency}); err != nil {
+			t.Fatalf("TarMatches returned unexpected error: %v", err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		archives = append(archives, buf.Bytes())
+	}
+	if !bytes.Equal(archives[0], archives[1]) {
+		t.Error("TarMatches wrote different archives with different concurrency")
+	}
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(archives[0]))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != files[hdr.Name] {
+				t.Errorf("tar entry %s has %d bytes, want %d", hdr.Name, len(data), len(files[hdr.Name]))
+			}
+		case tar.TypeSymlink:
+			if hdr.Linkname != "a.txt" {
+				t.Errorf("tar entry %s links to %q, want %q", hdr.Name, hdr.Linkname, "a.txt")
+			}
+		}
+	}
+	want := []string{"a.txt", "b.txt", "dir/", "dir/big", "dir/c.txt", "empty"}
+	if runtime.GOOS != "windows" {
+		want = append(want, "link")
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("Bad tar entries, -want +got: %v", diff)
+	}
+}
+
+func TestZipMatches(t *testing.T) {
+	tmpDir, files := archiveTree(t)
+	defer os.RemoveAll(tmpDir)
+
+	r := Stream(filepath.Join(Escape(tmpDir), "*", "*"), WithSortedOrder())
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := ZipMatches(context.Background(), &r, zw, &ArchiveOptions{Root: tmpDir}); err != nil {
+		t.Fatalf("ZipMatches returned unexpected error: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != files[f.Name] {
+			t.Errorf("zip entry %s has %d bytes, want %d", f.Name, len(data), len(files[f.Name]))
+		}
+	}
+	if diff := cmp.Diff([]string{"dir/big", "dir/c.txt"}, names); diff != "" {
+		t.Errorf("Bad zip entries, -want +got: %v", diff)
+	}
+}
+
+func TestTarMatchesError(t *testing.T) {
+	r := Stream("[")
+	if err := TarMatches(context.Background(), &r, tar.NewWriter(ioutil.Discard), nil); err == nil {
+		t.Error("TarMatches with a malformed pattern returned no error")
+	}
+}
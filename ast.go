@@ -0,0 +1,314 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Pattern is the parsed form of a pattern, as returned by Parse, for tools
+// that analyze or rewrite patterns with the same reading of them as Stream:
+//
+//	p, err := glob.Parse("src/**/*_test.go", glob.WithGlobstar())
+//	...
+//	for _, s := range p.Segments {
+//		if text, ok := s.Literal(); ok {
+//			fmt.Println("literal", text)
+//		}
+//	}
+//
+// String turns a Pattern, parsed or built, back into a pattern.
+type Pattern struct {
+	// Volume is the volume name the pattern starts with, such as `C:` or
+	// `\\server\share` on Windows, which is never matched against. It is
+	// empty elsewhere.
+	Volume string
+	// Rooted is set if the pattern starts with a separator, after its
+	// volume name if any.
+	Rooted bool
+	// Segments are the path elements of the pattern, in order. Empty
+	// elements, as between two consecutive separators, are left out.
+	Segments []Segment
+	// DirOnly is set if the pattern ends in a separator, after at least
+	// one element, so that it only matches directories.
+	DirOnly bool
+}
+
+// A Segment is a path element of a Pattern.
+type Segment struct {
+	// Nodes are the parts of the element, in order.
+	Nodes []Node
+	// CaseInsensitive is set if the element starts with "(?i)" and
+	// WithInlineFlags is given, which makes its Nodes match names
+	// regardless of case.
+	CaseInsensitive bool
+	// Offset is the byte offset of the element in the pattern given to
+	// Parse, after any forward slashes were made separators.
+	Offset int
+}
+
+// A NodeKind is the kind of a Node.
+type NodeKind uint8
+
+const (
+	// LiteralNode matches the Text of the Node exactly.
+	LiteralNode NodeKind = iota + 1
+	// StarNode matches any sequence of characters, as "*" does.
+	StarNode
+	// AnyCharNode matches any one character, as "?" does.
+	AnyCharNode
+	// ClassNode matches any one character in Ranges, or not in them if
+	// Negated is set, as a character class such as "[a-z]" does.
+	ClassNode
+	// GlobstarNode matches any number of path elements, as a "**" element
+	// does with WithGlobstar, or up to Depth of them, as a "**N" element
+	// does. It is only ever the one Node of its Segment.
+	GlobstarNode
+)
+
+// A Node is a part of a Segment.
+type Node struct {
+	Kind NodeKind
+	// Text is the text a LiteralNode matches, with any escapes removed. It
+	// never contains a separator.
+	Text string
+	// Negated and Ranges are the characters a ClassNode matches. With
+	// WithPOSIXClasses, a POSIX character class within it, such as
+	// "[:digit:]", is given as its ranges.
+	Negated bool
+	Ranges  []Range
+	// Depth is the most path elements a GlobstarNode matches, or 0 if it
+	// matches any number.
+	Depth int
+	// Offset is the byte offset of the Node in the pattern given to Parse,
+	// as for Segment.
+	Offset int
+}
+
+// A Range is a range of characters of a character class, from Lo to Hi
+// inclusive. A single character has the same Lo and Hi.
+type Range struct {
+	Lo, Hi rune
+}
+
+// Parse parses pattern as Stream would read it with opts. Of the options, only
+// WithGlobstar, WithInlineFlags and WithPOSIXClasses affect it: WithGlobstar
+// makes a "**" or "**N" element a GlobstarNode rather than StarNodes and a
+// literal, WithInlineFlags makes an element starting with "(?i)"
+// CaseInsensitive, and WithPOSIXClasses gives ClassNodes the ranges of the
+// POSIX character classes they hold. As for Stream, forward slashes are
+// separators on Windows as well as backslashes. Parse returns a *PatternError
+// if pattern is malformed, as Validate does.
+func Parse(pattern string, opts ...Option) (*Pattern, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	pattern = filepath.FromSlash(pattern)
+	if err := validate(pattern, o.posixClasses); err != nil {
+		return nil, err
+	}
+	p := &Pattern{Volume: filepath.VolumeName(pattern)}
+	i := len(p.Volume)
+	p.Rooted = i < len(pattern) && os.IsPathSeparator(pattern[i])
+	_, p.DirOnly = splitDirOnly(pattern)
+	for i < len(pattern) {
+		if os.IsPathSeparator(pattern[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(pattern) && !os.IsPathSeparator(pattern[end]) {
+			end++
+		}
+		p.Segments = append(p.Segments, parseSegment(pattern, i, end, &o))
+		i = end
+	}
+	return p, nil
+}
+
+// parseSegment parses the valid path element pattern[start:end] as Stream
+// would read it with o.
+func parseSegment(pattern string, start, end int, o *options) Segment {
+	s := Segment{Offset: start}
+	if o.inlineFlags && strings.HasPrefix(pattern[start:end], caseInsensitivePrefix) {
+		s.CaseInsensitive = true
+		start += len(caseInsensitivePrefix)
+	}
+	if depth, ok := globstarDepth(pattern[start:end]); ok && o.globstar {
+		s.Nodes = []Node{{Kind: GlobstarNode, Depth: depth, Offset: start}}
+		return s
+	}
+	escapes := runtime.GOOS != "windows"
+	var lit strings.Builder
+	litStart := 0
+	add := func(n Node) {
+		if lit.Len() > 0 {
+			s.Nodes = append(s.Nodes, Node{Kind: LiteralNode, Text: lit.String(), Offset: litStart})
+			lit.Reset()
+		}
+		if n.Kind != 0 {
+			s.Nodes = append(s.Nodes, n)
+		}
+	}
+	for i := start; i < end; {
+		if lit.Len() == 0 {
+			litStart = i
+		}
+		switch c := pattern[i]; {
+		case c == '*':
+			add(Node{Kind: StarNode, Offset: i})
+			i++
+		case c == '?':
+			add(Node{Kind: AnyCharNode, Offset: i})
+			i++
+		case c == '[':
+			var n Node
+			n, i = parseClass(pattern, i, end, escapes, o.posixClasses)
+			add(n)
+		case c == '\\' && escapes:
+			_, n := utf8.DecodeRuneInString(pattern[i+1 : end])
+			lit.WriteString(pattern[i+1 : i+1+n])
+			i += 1 + n
+		default:
+			lit.WriteByte(c)
+			i++
+		}
+	}
+	add(Node{})
+	return s
+}
+
+// parseClass parses the valid character class starting at pattern[start],
+// which is '[', within the element ending at end, and returns the offset just
+// past it. If posix is set, it holds POSIX character classes.
+func parseClass(pattern string, start, end int, escapes, posix bool) (Node, int) {
+	n := Node{Kind: ClassNode, Offset: start}
+	char := func(i int) (rune, int) {
+		if pattern[i] == '\\' && escapes {
+			i++
+		}
+		r, size := utf8.DecodeRuneInString(pattern[i:end])
+		return r, i + size
+	}
+	i := start + 1
+	if pattern[i] == '^' {
+		n.Negated = true
+		i++
+	}
+	for pattern[i] != ']' {
+		if name, next, ok := posixClassAt(pattern, i, end); ok && posix {
+			n.Ranges = append(n.Ranges, posixClasses[name]...)
+			i = next
+			continue
+		}
+		var r Range
+		r.Lo, i = char(i)
+		r.Hi = r.Lo
+		if pattern[i] == '-' {
+			r.Hi, i = char(i + 1)
+		}
+		n.Ranges = append(n.Ranges, r)
+	}
+	return n, i + 1
+}
+
+// String returns the pattern p is the parsed form of, using the operating
+// system's separator, with its literal text escaped as needed. On Windows,
+// where the backslash doesn't escape characters, a class can't hold ']' or
+// '-' as a character, and one that does is written as it is.
+func (p *Pattern) String() string {
+	var b strings.Builder
+	b.WriteString(p.Volume)
+	if p.Rooted {
+		b.WriteByte(filepath.Separator)
+	}
+	for i, s := range p.Segments {
+		if i > 0 {
+			b.WriteByte(filepath.Separator)
+		}
+		s.write(&b)
+	}
+	if p.DirOnly && len(p.Segments) > 0 {
+		b.WriteByte(filepath.Separator)
+	}
+	return b.String()
+}
+
+// String returns the path element of a pattern that s is the parsed form of.
+func (s Segment) String() string {
+	var b strings.Builder
+	s.write(&b)
+	return b.String()
+}
+
+// Literal returns the text that s matches, if it matches only that: if it has
+// no nodes other than LiteralNodes, and isn't case-insensitive unless its text
+// has a single case.
+func (s Segment) Literal() (string, bool) {
+	var b strings.Builder
+	for _, n := range s.Nodes {
+		if n.Kind != LiteralNode {
+			return "", false
+		}
+		b.WriteString(n.Text)
+	}
+	if s.CaseInsensitive {
+		for _, r := range b.String() {
+			if len(foldsOf(r)) > 0 {
+				return "", false
+			}
+		}
+	}
+	return b.String(), true
+}
+
+func (s Segment) write(b *strings.Builder) {
+	if s.CaseInsensitive {
+		b.WriteString(caseInsensitivePrefix)
+	}
+	for _, n := range s.Nodes {
+		n.write(b)
+	}
+}
+
+func (n Node) write(b *strings.Builder) {
+	escapes := runtime.GOOS != "windows"
+	switch n.Kind {
+	case LiteralNode:
+		escapeTo(b, n.Text)
+	case StarNode:
+		b.WriteByte('*')
+	case AnyCharNode:
+		b.WriteByte('?')
+	case GlobstarNode:
+		b.WriteString(globstarElem(n.Depth))
+	case ClassNode:
+		b.WriteByte('[')
+		if n.Negated {
+			b.WriteByte('^')
+		}
+		char := func(r rune) {
+			if escapes && strings.ContainsRune(`\]-^`, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		for _, r := range n.Ranges {
+			char(r.Lo)
+			if r.Hi != r.Lo {
+				b.WriteByte('-')
+				char(r.Hi)
+			}
+		}
+		b.WriteByte(']')
+	}
+}
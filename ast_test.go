@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	lit := func(text string, offset int) Node { return Node{Kind: LiteralNode, Text: text, Offset: offset} }
+	for _, tt := range []struct {
+		pattern  string
+		globstar bool
+		want     *Pattern
+	}{
+		{
+			pattern: "a/*.go",
+			want: &Pattern{Segments: []Segment{
+				{Nodes: []Node{lit("a", 0)}, Offset: 0},
+				{Nodes: []Node{{Kind: StarNode, Offset: 2}, lit(".go", 3)}, Offset: 2},
+			}},
+		},
+		{
+			pattern:  "/src/**/x?[^a-c_]",
+			globstar: true,
+			want: &Pattern{Rooted: true, Segments: []Segment{
+				{Nodes: []Node{lit("src", 1)}, Offset: 1},
+				{Nodes: []Node{{Kind: GlobstarNode, Offset: 5}}, Offset: 5},
+				{Nodes: []Node{
+					lit("x", 8),
+					{Kind: AnyCharNode, Offset: 9},
+					{Kind: ClassNode, Negated: true, Ranges: []Range{{'a', 'c'}, {'_', '_'}}, Offset: 10},
+				}, Offset: 8},
+			}},
+		},
+		{
+			pattern: "a/*//",
+			want: &Pattern{DirOnly: true, Segments: []Segment{
+				{Nodes: []Node{lit("a", 0)}, Offset: 0},
+				{Nodes: []Node{{Kind: StarNode, Offset: 2}}, Offset: 2},
+			}},
+		},
+		{
+			pattern:  "a/**3",
+			globstar: true,
+			want: &Pattern{Segments: []Segment{
+				{Nodes: []Node{lit("a", 0)}, Offset: 0},
+				{Nodes: []Node{{Kind: GlobstarNode, Depth: 3, Offset: 2}}, Offset: 2},
+			}},
+		},
+		{
+			// Without WithGlobstar, "**" is two stars.
+			pattern: "**//b",
+			want: &Pattern{Segments: []Segment{
+				{Nodes: []Node{{Kind: StarNode, Offset: 0}, {Kind: StarNode, Offset: 1}}, Offset: 0},
+				{Nodes: []Node{lit("b", 4)}, Offset: 4},
+			}},
+		},
+	} {
+		var opts []Option
+		if tt.globstar {
+			opts = append(opts, WithGlobstar())
+		}
+		got, err := Parse(tt.pattern, opts...)
+		if err != nil {
+			t.Errorf("Parse(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Bad result from Parse(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	if _, err := Parse("a/[b"); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("Parse(%q) returned error %v, want %v", "a/[b", err, filepath.ErrBadPattern)
+	}
+}
+
+func TestNonWindowsParseEscapes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("backslashes are separators on Windows")
+	}
+	got, err := Parse(`a\*b[\]x-z]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Pattern{Segments: []Segment{{Nodes: []Node{
+		{Kind: LiteralNode, Text: "a*b", Offset: 0},
+		{Kind: ClassNode, Ranges: []Range{{']', ']'}, {'x', 'z'}}, Offset: 4},
+	}}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Bad result from Parse(%q), -want +got: %v", `a\*b[\]x-z]`, diff)
+	}
+}
+
+func TestPatternString(t *testing.T) {
+	patterns := []string{"a/*.go", "/src/**/x?[^a-c_]", "*", "[*]"}
+	if runtime.GOOS != "windows" {
+		patterns = append(patterns, `a\*b[\]x-z]`, `\[^]`)
+	}
+	for _, pattern := range patterns {
+		p, err := Parse(pattern, WithGlobstar())
+		if err != nil {
+			t.Errorf("Parse(%q) returned unexpected error: %v", pattern, err)
+			continue
+		}
+		if got, want := p.String(), filepath.FromSlash(pattern); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", pattern, got, want)
+		}
+	}
+
+	// A Pattern built by hand is escaped as needed.
+	p := &Pattern{Segments: []Segment{
+		{Nodes: []Node{{Kind: LiteralNode, Text: "a*"}, {Kind: StarNode}}},
+		{Nodes: []Node{{Kind: GlobstarNode}}},
+	}}
+	want := filepath.Join(Escape("a*")+"*", "**")
+	if got := p.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if s, ok := p.Segments[0].Literal(); ok {
+		t.Errorf("Literal() of %q = %q, true, want false", p.Segments[0], s)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobBasenameMatch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.log":        {},
+		"var/b.log":    {},
+		"var/x/c.log":  {},
+		"var/x/d.txt":  {},
+		"logs.log/e.c": {},
+	}
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.log", []string{"a.log", "logs.log", "var/b.log", "var/x/c.log"}},
+		{"x", []string{"var/x"}},
+		// Patterns with a separator are matched as usual.
+		{"var/*.log", []string{"var/b.log"}},
+		{"*/", []string{"logs.log/", "var/"}},
+	} {
+		got, err := Glob(context.Background(), tt.pattern, WithFS(fsys), WithBasenameMatch())
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		var want []string
+		for _, p := range tt.want {
+			want = append(want, filepath.FromSlash(p))
+		}
+		if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	var gb Globber
+	if ok, err := gb.Match("*.log", "var/x/c.log", WithBasenameMatch()); err != nil || !ok {
+		t.Errorf("Match(%q, %q) = %v, %v, want true", "*.log", "var/x/c.log", ok, err)
+	}
+}
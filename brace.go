@@ -0,0 +1,220 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// WithBraceExpansion makes a brace expression in the pattern, such as
+// `src/{cmd,internal}/**/*.{go,s}`, stand for each of its comma-separated
+// alternatives in turn, as in bash: the pattern matches what any of the
+// patterns it expands to matches, and each path is yielded once. Braces nest,
+// and an alternative may be empty, as in `a{,.bak}`.
+//
+// A sequence expression stands for a range of numbers or letters, as in
+// bash: `shard-{001..128}` stands for shard-001 up to shard-128, zero-padded
+// to the same width because an end is, `{10..1..3}` for 10, 7, 4 and 1, and
+// `{a..e}` for the letters a to e.
+//
+// A brace with no comma, such as `{a}`, or with no closing brace, is taken
+// literally, as is one escaped with a backslash (outside Windows). The
+// alternatives are globbed one after the other, in the order they are
+// written, so directories they share are read once for each of them.
+func WithBraceExpansion() Option {
+	return func(o *options) {
+		o.braces = true
+	}
+}
+
+// alternatives returns a function like match that matches each of the
+// patterns the brace expressions of its pattern expand to in turn, yielding
+// each path once.
+func (w *walker) alternatives(match func(string, chan<- found) error) func(string, chan<- found) error {
+	return func(pattern string, results chan<- found) error {
+		patterns := expandBraces(pattern)
+		if len(patterns) == 1 {
+			return match(pattern, results)
+		}
+		// Each alternative sets up its own view of the filesystem.
+		fsys := w.fsys
+		seen := make(map[string]bool)
+		keep := w.rewrite(match, func(p string) (string, bool) {
+			if seen[p] {
+				return "", false
+			}
+			seen[p] = true
+			return p, true
+		})
+		for _, p := range patterns {
+			w.fsys = fsys
+			if err := keep(p, results); err != nil {
+				return err
+			}
+			select {
+			case <-w.cancel:
+				return nil
+			default:
+			}
+		}
+		return nil
+	}
+}
+
+// expandBraces returns the patterns the brace expressions of pattern expand
+// to, in order, as described for WithBraceExpansion: "{a,b}{1,2}" expands to
+// a1, a2, b1 and b2.
+func expandBraces(pattern string) []string {
+	open, alts, end, ok := firstBrace(pattern)
+	if !ok {
+		return []string{pattern}
+	}
+	rests := expandBraces(pattern[end:])
+	var patterns []string
+	for _, alt := range alts {
+		for _, a := range expandBraces(alt) {
+			for _, rest := range rests {
+				patterns = append(patterns, pattern[:open]+a+rest)
+			}
+		}
+	}
+	return patterns
+}
+
+// firstBrace finds the first brace expression of pattern that has a comma at
+// its top level or is a sequence expression, returning the offset of its
+// opening brace, its alternatives, and the offset just past its closing
+// brace. Braces and commas within character classes, as in the "[{]" that
+// Escape writes on Windows, are characters of the classes.
+func firstBrace(pattern string) (open int, alts []string, end int, ok bool) {
+	escapes := runtime.GOOS != "windows"
+	for open = 0; open < len(pattern); open++ {
+		switch pattern[open] {
+		case '\\':
+			if escapes {
+				open++
+			}
+			continue
+		case '[':
+			open = classEnd(pattern, open, escapes) - 1
+			continue
+		case '{':
+		default:
+			continue
+		}
+		depth := 0
+		start := open + 1
+		alts = alts[:0]
+		for i := open; i < len(pattern); i++ {
+			switch pattern[i] {
+			case '\\':
+				if escapes {
+					i++
+				}
+			case '[':
+				i = classEnd(pattern, i, escapes) - 1
+			case '{':
+				depth++
+			case ',':
+				if depth == 1 {
+					alts = append(alts, pattern[start:i])
+					start = i + 1
+				}
+			case '}':
+				depth--
+				if depth == 0 {
+					if len(alts) == 0 {
+						if seq, ok := braceSequence(pattern[start:i]); ok {
+							return open, seq, i + 1, true
+						}
+						// "{a}" is literal, but may hold an expression.
+						i = len(pattern)
+						break
+					}
+					return open, append(alts, pattern[start:i]), i + 1, true
+				}
+			}
+		}
+	}
+	return 0, nil, 0, false
+}
+
+// classEnd returns the offset just past the character class of pattern
+// starting at pattern[i], which is '[', or i+1 if the class is unterminated,
+// and so the '[' an ordinary character.
+func classEnd(pattern string, i int, escapes bool) int {
+	for j := i + 1; j < len(pattern) && !os.IsPathSeparator(pattern[j]); j++ {
+		switch {
+		case pattern[j] == '\\' && escapes:
+			j++
+		case pattern[j] == ']':
+			return j + 1
+		}
+	}
+	return i + 1
+}
+
+// braceSequence returns what the body of the sequence expression
+// "{first..last}" or "{first..last..step}" stands for, as described for
+// WithBraceExpansion. ok is false if it is not one.
+func braceSequence(body string) (seq []string, ok bool) {
+	parts := strings.Split(body, "..")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, false
+	}
+	step := 1
+	if len(parts) == 3 {
+		n, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, false
+		}
+		if n < 0 {
+			n = -n
+		}
+		if n != 0 {
+			step = n
+		}
+	}
+	format := func(n int) string { return string(rune(n)) }
+	first, errFirst := strconv.Atoi(parts[0])
+	last, errLast := strconv.Atoi(parts[1])
+	switch {
+	case errFirst == nil && errLast == nil:
+		// An end with a leading zero pads every number to the width of
+		// the wider end.
+		width := 0
+		for _, p := range parts[:2] {
+			if d := strings.TrimPrefix(p, "-"); len(d) > 1 && d[0] == '0' {
+				width = len(parts[0])
+				if len(parts[1]) > width {
+					width = len(parts[1])
+				}
+			}
+		}
+		format = func(n int) string { return fmt.Sprintf("%0*d", width, n) }
+	case isLetter(parts[0]) && isLetter(parts[1]):
+		first, last = int(parts[0][0]), int(parts[1][0])
+	default:
+		return nil, false
+	}
+	if first > last {
+		step = -step
+	}
+	for n := first; step > 0 && n <= last || step < 0 && n >= last; n += step {
+		seq = append(seq, format(n))
+	}
+	return seq, true
+}
+
+// isLetter reports whether s is a single ASCII letter.
+func isLetter(s string) bool {
+	return len(s) == 1 && ('a' <= s[0] && s[0] <= 'z' || 'A' <= s[0] && s[0] <= 'Z')
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandBraces(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"a", []string{"a"}},
+		{"{a,b}", []string{"a", "b"}},
+		{"x{a,b}y", []string{"xay", "xby"}},
+		{"{a,b}{1,2}", []string{"a1", "a2", "b1", "b2"}},
+		{"{a,{b,c}d}", []string{"a", "bd", "cd"}},
+		{"a{,.bak}", []string{"a", "a.bak"}},
+		{"src/{cmd,internal}/*.{go,s}", []string{"src/cmd/*.go", "src/cmd/*.s", "src/internal/*.go", "src/internal/*.s"}},
+		{"shard-{1..3}", []string{"shard-1", "shard-2", "shard-3"}},
+		{"{08..11}", []string{"08", "09", "10", "11"}},
+		{"{1..010..4}", []string{"001", "005", "009"}},
+		{"{3..-3..-2}", []string{"3", "1", "-1", "-3"}},
+		{"{-01..1}", []string{"-01", "000", "001"}},
+		{"{e..a..2}", []string{"e", "c", "a"}},
+		{"{a..c}{1..2}", []string{"a1", "a2", "b1", "b2", "c1", "c2"}},
+		{"{x,{1..2}}", []string{"x", "1", "2"}},
+		// Taken literally.
+		{"{a}", []string{"{a}"}},
+		{"{1..}", []string{"{1..}"}},
+		{"{a..1}", []string{"{a..1}"}},
+		{"{1..2..x}", []string{"{1..2..x}"}},
+		{"{a,b", []string{"{a,b"}},
+		{"a}", []string{"a}"}},
+		{"{{a,b}}", []string{"{a}", "{b}"}},
+		// Braces and commas in character classes are their characters.
+		{"[{]a[,]b[}]", []string{"[{]a[,]b[}]"}},
+		{"{[,],x}", []string{"[,]", "x"}},
+		{"[{a,b}", []string{"[a", "[b"}},
+	} {
+		if got := expandBraces(tt.pattern); !cmp.Equal(tt.want, got) {
+			t.Errorf("expandBraces(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if got, want := expandBraces(`\{a,b}`), []string{`\{a,b}`}; !cmp.Equal(want, got) {
+			t.Errorf("expandBraces(%q) = %q, want %q", `\{a,b}`, got, want)
+		}
+	}
+}
+
+func TestGlobBraceExpansion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/x.go": {},
+		"a/x.s":  {},
+		"b/y.go": {},
+		"c/z.go": {},
+		"{a}":    {},
+	}
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"{a,b}/*.go", []string{"a/x.go", "b/y.go"}},
+		{"a/*.{s,go}", []string{"a/x.s", "a/x.go"}},
+		// Each match is yielded once.
+		{"{a,*}/x.go", []string{"a/x.go"}},
+		{"{a}", []string{"{a}"}},
+		{"{c,d}/*", []string{"c/z.go"}},
+		{"{a..b}/*.go", []string{"a/x.go", "b/y.go"}},
+	} {
+		got, err := Glob(context.Background(), tt.pattern, WithFS(fsys), WithBraceExpansion(), WithSortedOrder())
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		// The alternatives are matched in order, each sorted.
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// WithInlineFlags makes a path element of the pattern that starts with
+// "(?i)", as in `logs/(?i)readme*`, match names regardless of case, while the
+// other elements stay case-sensitive. Cases are those of Unicode simple case
+// folding, as for strings.EqualFold. Without it, "(?i)" is read as
+// filepath.Match reads it, as a "(", any character, and "i)".
+func WithInlineFlags() Option {
+	return func(o *options) {
+		o.inlineFlags = true
+	}
+}
+
+// caseInsensitivePrefix is the prefix of a path element of a pattern that
+// matches names regardless of case, as in `logs/(?i)readme*`.
+const caseInsensitivePrefix = "(?i)"
+
+// maxFoldedRange is the size of the largest range of a character class whose
+// other cases are added to the class of a case-insensitive element. Larger
+// ranges, which hold most of what they could fold to already, are kept as
+// they are.
+const maxFoldedRange = 1 << 10
+
+// expandCaseFolds rewrites the valid path elements of pattern that start with
+// "(?i)" as elements that match the same names in any case, in the syntax of
+// filepath.Match, which has no such flag: "(?i)ab*" becomes "[aA][bB]*".
+// Cases are those of Unicode simple case folding, as for strings.EqualFold.
+func expandCaseFolds(pattern string) string {
+	if !strings.Contains(pattern, caseInsensitivePrefix) {
+		return pattern
+	}
+	var b strings.Builder
+	start := 0
+	for i := 0; i <= len(pattern); i++ {
+		if i < len(pattern) && !os.IsPathSeparator(pattern[i]) {
+			continue
+		}
+		elem := pattern[start:i]
+		if strings.HasPrefix(elem, caseInsensitivePrefix) && validate(elem, false) == nil {
+			parseSegment(elem, 0, len(elem), &options{inlineFlags: true}).folded().write(&b)
+		} else {
+			b.WriteString(elem)
+		}
+		if i < len(pattern) {
+			b.WriteByte(pattern[i])
+		}
+		start = i + 1
+	}
+	return b.String()
+}
+
+// folded returns a Segment that matches what s matches regardless of case,
+// as the case-insensitive s does.
+func (s Segment) folded() Segment {
+	f := Segment{Offset: s.Offset}
+	for _, n := range s.Nodes {
+		switch n.Kind {
+		case LiteralNode:
+			var lit strings.Builder
+			for _, r := range n.Text {
+				others := foldsOf(r)
+				if len(others) == 0 {
+					lit.WriteRune(r)
+					continue
+				}
+				if lit.Len() > 0 {
+					f.Nodes = append(f.Nodes, Node{Kind: LiteralNode, Text: lit.String()})
+					lit.Reset()
+				}
+				class := Node{Kind: ClassNode, Ranges: []Range{{r, r}}}
+				for _, o := range others {
+					class.Ranges = append(class.Ranges, Range{o, o})
+				}
+				f.Nodes = append(f.Nodes, class)
+			}
+			if lit.Len() > 0 {
+				f.Nodes = append(f.Nodes, Node{Kind: LiteralNode, Text: lit.String()})
+			}
+		case ClassNode:
+			ranges := n.Ranges
+			for _, rg := range n.Ranges {
+				if rg.Hi-rg.Lo >= maxFoldedRange {
+					continue
+				}
+				for r := rg.Lo; r <= rg.Hi; r++ {
+					for _, o := range foldsOf(r) {
+						if o < rg.Lo || o > rg.Hi {
+							ranges = append(ranges, Range{o, o})
+						}
+					}
+				}
+			}
+			if merged := mergeRanges(ranges); len(merged) > 0 {
+				ranges = merged
+			}
+			n.Ranges = ranges
+			f.Nodes = append(f.Nodes, n)
+		default:
+			f.Nodes = append(f.Nodes, n)
+		}
+	}
+	return f
+}
+
+// foldsOf returns the other cases of r, if any.
+func foldsOf(r rune) []rune {
+	var others []rune
+	for o := unicode.SimpleFold(r); o != r; o = unicode.SimpleFold(o) {
+		others = append(others, o)
+	}
+	return others
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobCaseInsensitive(t *testing.T) {
+	fsys := fstest.MapFS{
+		"logs/README.md":  {},
+		"logs/readme.txt": {},
+		"logs/ReadMe":     {},
+		"logs/other":      {},
+		"Logs/readme":     {},
+		"src/Straße.go":   {},
+		"src/STRASSE.go":  {},
+		"src/café/x":      {},
+		"src/CAFÉ/y":      {},
+		"src/k.Go":        {},
+		"src/KELVINK":     {},
+	}
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"logs/(?i)readme*", []string{"logs/README.md", "logs/ReadMe", "logs/readme.txt"}},
+		// Other elements are still case-sensitive.
+		{"(?i)logs/readme", []string{"Logs/readme"}},
+		{"logs/(?i)[q-s]*", []string{"logs/README.md", "logs/ReadMe", "logs/readme.txt"}},
+		{"logs/(?i)[^r]*", []string{"logs/other"}},
+		{"src/(?i)café/*", []string{"src/CAFÉ/y", "src/café/x"}},
+		{"src/(?i)*.go", []string{"src/STRASSE.go", "src/Straße.go", "src/k.Go"}},
+		// U+212A KELVIN SIGN folds to k.
+		{"src/(?i)kelvink", []string{"src/KELVINK"}},
+		{"src/(?i)[[:upper:]]*.go", []string{"src/STRASSE.go", "src/Straße.go", "src/k.Go"}},
+	} {
+		got, err := Glob(context.Background(), tt.pattern, WithFS(fsys), WithInlineFlags(), WithPOSIXClasses())
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobInlineFlagsOff(t *testing.T) {
+	// Without WithInlineFlags, "(?i)" means what it does to filepath.Match.
+	fsys := fstest.MapFS{
+		"dir/(Xi)foo": {},
+		"dir/FOO":     {},
+		"dir/foo":     {},
+	}
+	pattern := "dir/(?i)foo"
+	var want []string
+	for name := range fsys {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			want = append(want, name)
+		}
+	}
+	got, err := Glob(context.Background(), pattern, WithFS(fsys))
+	if err != nil {
+		t.Fatalf("Glob(%q) returned unexpected error: %v", pattern, err)
+	}
+	if diff := cmp.Diff([]string{"dir/(Xi)foo"}, want); diff != "" {
+		t.Fatalf("Bad results from filepath.Match(%q), -want +got: %v", pattern, diff)
+	}
+	if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", pattern, diff)
+	}
+
+	p, err := Parse(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Segments[1].CaseInsensitive {
+		t.Errorf("Parse(%q) made %q case-insensitive without WithInlineFlags", pattern, p.Segments[1])
+	}
+}
+
+func TestExpandCaseFolds(t *testing.T) {
+	sep := string(filepath.Separator)
+	for _, tt := range []struct{ pattern, want string }{
+		{"(?i)ab*", "[aA][bB]*"},
+		{"x/(?i)a1?", "x" + sep + "[aA]1?"},
+		{"(?i)[a-c_]", "[A-C_a-c]"},
+		{"a(?i)b", "a(?i)b"},
+		{"(?i)", ""},
+		// Malformed elements are left for the matcher to reject.
+		{"(?i)[a", "(?i)[a"},
+	} {
+		if got := expandCaseFolds(filepath.FromSlash(tt.pattern)); got != tt.want {
+			t.Errorf("expandCaseFolds(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if got, want := expandCaseFolds(`\(?i)a`), `\(?i)a`; got != want {
+			t.Errorf("expandCaseFolds(%q) = %q, want %q", `\(?i)a`, got, want)
+		}
+	}
+}
+
+func TestParseCaseInsensitive(t *testing.T) {
+	p, err := Parse("logs/(?i)read*", WithInlineFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Segment{CaseInsensitive: true, Offset: 5, Nodes: []Node{{Kind: LiteralNode, Text: "read", Offset: 9}, {Kind: StarNode, Offset: 13}}}
+	if diff := cmp.Diff(want, p.Segments[1]); diff != "" {
+		t.Errorf("Bad segment from Parse, -want +got: %v", diff)
+	}
+	if _, ok := p.Segments[1].Literal(); ok {
+		t.Errorf("Segment %q is literal", p.Segments[1])
+	}
+	if got, want := p.String(), filepath.FromSlash("logs/(?i)read*"); got != want {
+		t.Errorf("Parse(%q).String() = %q", want, got)
+	}
+	if got, err := Normalize("a/(?i)./(?i)b[b]", WithInlineFlags()); err != nil || got != filepath.FromSlash("a/(?i)bb") {
+		t.Errorf("Normalize(%q) = %q, %v, want %q", "a/(?i)./(?i)b[b]", got, err, "a/(?i)bb")
+	}
+}
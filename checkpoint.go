@@ -0,0 +1,138 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// A Checkpoint records how far the consumer of a Stream has got through its
+// matches, so that a later Stream, in this process or another, can carry on
+// from there with WithResume rather than start again from scratch:
+//
+//	r := glob.Stream("/mnt/nfs/**", glob.WithGlobstar(), glob.WithSortedOrder())
+//	...
+//	cp, err := r.Checkpoint()
+//	data, err := json.Marshal(cp)
+//	...
+//	var cp glob.Checkpoint
+//	err := json.Unmarshal(data, &cp)
+//	r := glob.Stream("/mnt/nfs/**", glob.WithGlobstar(), glob.WithSortedOrder(), glob.WithResume(cp))
+//
+// It is taken from a Stream with WithSortedOrder, whose matches come in the
+// same order from one run to the next, and can be encoded as JSON.
+type Checkpoint struct {
+	// Pattern is the pattern of the Stream, as given to Stream.
+	Pattern string `json:"pattern"`
+	// After is the last match received from the Stream, if any.
+	After string `json:"after,omitempty"`
+	// Matches is the number of matches received from the Stream and those
+	// it resumed, if any.
+	Matches int64 `json:"matches"`
+}
+
+// Checkpoint returns a Checkpoint of the matches received from the Stream so
+// far. It may be called at any time, including concurrently with Next, and
+// fails unless the Stream was created with WithSortedOrder.
+func (g *Result) Checkpoint() (Checkpoint, error) {
+	if !g.w.opts.sorted {
+		return Checkpoint{}, errors.New("glob: Checkpoint needs a Stream with WithSortedOrder")
+	}
+	g.w.lastMu.Lock()
+	last := g.w.last
+	g.w.lastMu.Unlock()
+	cp := Checkpoint{Pattern: g.w.pattern, After: last, Matches: atomic.LoadInt64(&g.w.matched)}
+	if r := g.w.opts.resume; r != nil {
+		cp.Matches += r.Matches
+		if cp.After == "" {
+			cp.After = r.After
+		}
+	}
+	return cp, nil
+}
+
+// WithResume makes the Stream carry on from cp, taken from an earlier Stream
+// for the same pattern with the same options, yielding only the matches that
+// came after those it had received. It needs WithSortedOrder; the Stream fails
+// without it, or if cp is of another pattern.
+//
+// If the pattern is in path order (see MergeStreams), the directories whose
+// matches all came before cp aren't read again, so an interrupted crawl of a
+// huge tree doesn't go back over what it has done. Otherwise every directory
+// is read again, and the number of matches cp counts are left out. Either way,
+// files created or deleted in the meantime may shift what is left out; a
+// match is never yielded twice in path order.
+func WithResume(cp Checkpoint) Option {
+	return func(o *options) {
+		o.resume = &cp
+	}
+}
+
+// resume returns a function like start that leaves out the matches before the
+// Checkpoint of WithResume. It is given the pattern as given to Stream.
+func (w *walker) resume(start func(string, chan<- found) error) func(string, chan<- found) error {
+	cp := *w.opts.resume
+	return func(pattern string, results chan<- found) error {
+		if !w.opts.sorted {
+			return errors.New("glob: WithResume needs WithSortedOrder")
+		}
+		if cp.Pattern != pattern {
+			return fmt.Errorf("glob: can't resume pattern %q from a checkpoint of %q", pattern, cp.Pattern)
+		}
+		skip := cp.Matches
+		return w.rewrite(start, func(p string) (string, bool) {
+			if w.pathOrder {
+				return p, comparePaths(p, cp.After) > 0
+			}
+			if skip > 0 {
+				skip--
+				return p, false
+			}
+			return p, true
+		})(pattern, results)
+	}
+}
+
+// resumeFrom sets the path of the Checkpoint of WithResume as it is read
+// from the filesystem, before matches are made relative to base if rel is set
+// (see anchor), for passed. It does nothing unless the Stream is in path
+// order.
+func (w *walker) resumeFrom(base string, rel bool) error {
+	cp := w.opts.resume
+	if cp == nil || cp.After == "" || !w.pathOrder {
+		return nil
+	}
+	after := filepath.FromSlash(cp.After)
+	switch {
+	case w.opts.confined && w.opts.absolute:
+		abs, err := filepath.Abs(w.opts.root)
+		if err != nil {
+			return err
+		}
+		if after, err = filepath.Rel(abs, after); err != nil {
+			// Nothing can be passed.
+			return nil
+		}
+	case rel && base != ".":
+		after = filepath.Join(base, after)
+	}
+	w.resumeAfter = after
+	return nil
+}
+
+// passed reports whether every path within dir came before the Checkpoint
+// of WithResume, so that dir needn't be read.
+func (w *walker) passed(dir string) bool {
+	if w.resumeAfter == "" {
+		return false
+	}
+	return comparePaths(filepath.Clean(dir), w.resumeAfter) < 0 && !strings.HasPrefix(w.resumeAfter, joinPrefix(dir))
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// checkpointFS is a tree of several directories, for TestGlobResume.
+func checkpointFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a/x.go":     {},
+		"a/y/z.go":   {},
+		"b.go":       {},
+		"c/d/e/f.go": {},
+		"c/w.go":     {},
+		"g/h.go":     {},
+	}
+}
+
+func TestGlobResume(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		pattern string
+		// n is the number of matches received before the Stream is
+		// interrupted, and dirs the number of directories the resumed
+		// Stream reads.
+		n    int
+		dirs int64
+	}{
+		// a and a/y are passed.
+		{name: "PathOrder", pattern: "**", n: 5, dirs: 5},
+		{name: "Wildcards", pattern: "*/*.go", n: 2, dirs: 3},
+		// Every directory is read again, twice.
+		{name: "NotInPathOrder", pattern: "**/*.go", n: 2, dirs: 14},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := checkpointFS()
+			opts := []Option{WithFS(fsys), WithGlobstar(), WithSortedOrder()}
+			want, err := Glob(context.Background(), tt.pattern, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := Stream(tt.pattern, opts...)
+			var got []string
+			for len(got) < tt.n {
+				m, err := r.Next()
+				if err != nil || m == "" {
+					t.Fatalf("Next() = %q, %v, want a match", m, err)
+				}
+				got = append(got, m)
+			}
+			cp, err := r.Checkpoint()
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cp.Pattern != tt.pattern || cp.After != got[tt.n-1] || cp.Matches != int64(tt.n) {
+				t.Errorf("Checkpoint() = %+v, want pattern %q after %q with %d matches", cp, tt.pattern, got[tt.n-1], tt.n)
+			}
+
+			// Resume it from the encoded Checkpoint.
+			data, err := json.Marshal(cp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var resumed Checkpoint
+			if err := json.Unmarshal(data, &resumed); err != nil {
+				t.Fatal(err)
+			}
+			r = Stream(tt.pattern, append(opts, WithResume(resumed))...)
+			got = append(got, readAll(t, &r)...)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+			}
+			if st := r.Stats(); st.Dirs != tt.dirs {
+				t.Errorf("Stats().Dirs = %d, want %d", st.Dirs, tt.dirs)
+			}
+			cp, err = r.Checkpoint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cp.After != want[len(want)-1] || cp.Matches != int64(len(want)) {
+				t.Errorf("Checkpoint() = %+v, want after %q with %d matches", cp, want[len(want)-1], len(want))
+			}
+		})
+	}
+}
+
+func TestGlobResumeFromRoot(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "globtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, name := range []string{"a/x", "b/y", "c/z"} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The Checkpoint is of matches relative to the root.
+	cp := Checkpoint{Pattern: "*/*", After: filepath.Join("b", "y"), Matches: 2}
+	r := Stream("*/*", WithRoot(tmpDir), WithSortedOrder(), WithResume(cp))
+	got := readAll(t, &r)
+	if diff := cmp.Diff([]string{filepath.Join("c", "z")}, got); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", "*/*", diff)
+	}
+	// a is passed, but b may have more matches after b/y.
+	if st := r.Stats(); st.Dirs != 3 {
+		t.Errorf("Stats().Dirs = %d, want 3", st.Dirs)
+	}
+}
+
+func TestGlobResumeErrors(t *testing.T) {
+	fsys := checkpointFS()
+	r := Stream("*", WithFS(fsys))
+	defer r.Close()
+	if _, err := r.Checkpoint(); err == nil {
+		t.Error("Checkpoint() of an unsorted Stream succeeded")
+	}
+
+	cp := Checkpoint{Pattern: "*", After: "b.go", Matches: 2}
+	for _, opts := range [][]Option{
+		{WithFS(fsys), WithResume(cp)},
+		{WithFS(fsys), WithSortedOrder(), WithResume(Checkpoint{Pattern: "*/*"})},
+	} {
+		if _, err := Glob(context.Background(), "*", opts...); err == nil {
+			t.Error("Glob() resumed from a bad checkpoint succeeded")
+		}
+	}
+}
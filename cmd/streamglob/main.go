@@ -0,0 +1,271 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+// Streamglob prints the files that match glob patterns, as they are found.
+//
+// Usage:
+//
+//	streamglob [flags] pattern...
+//
+// Each argument is a pattern, in the syntax of filepath.Match, with "**"
+// matching any number of directories. Patterns should be quoted to keep the
+// shell from expanding them. Matches of each pattern are printed one per line,
+// in no particular order unless -sort is given, before the next pattern is
+// searched.
+//
+// The flags are:
+//
+//	-exclude pattern
+//		Don't print matches whose base name or path matches pattern. May be
+//		repeated.
+//	-type f|d|l
+//		Only print regular files, directories or symbolic links.
+//	-max-depth n
+//		Only print matches at most n directories below the part of the
+//		pattern before its first wildcard, and don't read directories any
+//		deeper for "**".
+//	-0
+//		End each match with a NUL byte instead of a newline, for xargs -0.
+//	-sort
+//		Print matches in the order filepath.Glob would.
+//	-gitignore
+//		Skip files ignored by .gitignore files.
+//	-follow
+//		Descend into symbolic links to directories for "**".
+//	-j n
+//		Read up to n directories at once.
+//
+// Streamglob exits with status 1 if a pattern is malformed or can't be
+// searched, and 2 for bad usage. An interrupt stops it cleanly, after the
+// matches printed so far, with the status of the patterns searched until
+// then.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// stringsFlag is a flag that may be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// run runs streamglob with args, returning its exit status.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("streamglob", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: streamglob [flags] pattern...\n")
+		flags.PrintDefaults()
+	}
+	var excludes stringsFlag
+	flags.Var(&excludes, "exclude", "don't print matches whose base name or path matches `pattern`")
+	fileType := flags.String("type", "", "only print regular files (f), directories (d) or symbolic links (l)")
+	maxDepth := flags.Int("max-depth", -1, "only print matches at most `n` directories below the pattern's literal prefix")
+	nul := flags.Bool("0", false, "end matches with a NUL byte instead of a newline")
+	sorted := flags.Bool("sort", false, "print matches in sorted order")
+	gitignore := flags.Bool("gitignore", false, "skip files ignored by .gitignore files")
+	follow := flags.Bool("follow", false, "follow symbolic links to directories for **")
+	concurrency := flags.Int("j", 1, "read up to `n` directories at once")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	for _, ex := range excludes {
+		if _, err := filepath.Match(ex, ""); err != nil {
+			fmt.Fprintf(stderr, "streamglob: bad -exclude pattern %q: %v\n", ex, err)
+			return 2
+		}
+	}
+	var typ fs.FileMode
+	switch *fileType {
+	case "", "f":
+	case "d":
+		typ = fs.ModeDir
+	case "l":
+		typ = fs.ModeSymlink
+	default:
+		fmt.Fprintf(stderr, "streamglob: bad -type %q: want f, d or l\n", *fileType)
+		return 2
+	}
+
+	opts := []glob.Option{glob.WithGlobstar(), glob.WithConcurrency(*concurrency)}
+	if *sorted {
+		opts = append(opts, glob.WithSortedOrder())
+	}
+	if *gitignore {
+		opts = append(opts, glob.WithGitignore())
+	}
+	if *follow {
+		opts = append(opts, glob.WithFollowSymlinks())
+	}
+	if len(excludes) > 0 {
+		opts = append(opts, glob.WithFilter(func(p string, d fs.DirEntry) bool {
+			for _, ex := range excludes {
+				if m, _ := filepath.Match(ex, d.Name()); m {
+					return false
+				}
+				if m, _ := filepath.Match(ex, p); m {
+					return false
+				}
+			}
+			return true
+		}))
+	}
+	if *fileType != "" {
+		opts = append(opts, glob.WithFilter(func(p string, d fs.DirEntry) bool {
+			if typ == 0 {
+				return d.Type().IsRegular()
+			}
+			return d.Type()&typ != 0
+		}))
+	}
+
+	if *nul {
+		opts = append(opts, glob.WithSeparator(0))
+	}
+	status := 0
+	for _, pattern := range flags.Args() {
+		depthOpts := opts
+		if *maxDepth >= 0 {
+			base := depth(literalDir(pattern))
+			pattern = limitGlobstars(pattern, *maxDepth)
+			depthOpts = append(opts[:len(opts):len(opts)], glob.WithFilter(func(p string, d fs.DirEntry) bool {
+				return depth(p)-base <= *maxDepth+1
+			}))
+		}
+		if err := stream(ctx, pattern, stdout, depthOpts); err != nil {
+			fmt.Fprintf(stderr, "streamglob: %v\n", err)
+			status = 1
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return status
+}
+
+// stream writes the matches of pattern to w as they are found, until ctx is
+// done, which isn't an error.
+func stream(ctx context.Context, pattern string, w io.Writer, opts []glob.Option) error {
+	r := glob.Stream(pattern, opts...)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.Close()
+		case <-done:
+		}
+	}()
+	if _, err := r.WriteTo(w); err != nil && !(ctx.Err() != nil && errors.Is(err, glob.ErrClosed)) {
+		return err
+	}
+	return nil
+}
+
+// literalDir returns the leading directories of pattern that have no
+// wildcards.
+func literalDir(pattern string) string {
+	dir := ""
+	for {
+		i := strings.IndexAny(pattern, `/`+string(filepath.Separator))
+		if i < 0 || strings.ContainsAny(pattern[:i], `*?[\`) {
+			return dir
+		}
+		dir += pattern[:i+1]
+		pattern = pattern[i+1:]
+	}
+}
+
+// limitGlobstars rewrites the "**" elements of pattern as "**N" elements
+// that match at most maxDepth levels of directories, so that -max-depth
+// limits the directories read and not just the matches printed. A "**"
+// element that may match no directories at all is removed.
+func limitGlobstars(pattern string, maxDepth int) string {
+	var b strings.Builder
+	for pattern != "" {
+		elem, sep := pattern, ""
+		if i := strings.IndexAny(pattern, `/`+string(filepath.Separator)); i >= 0 {
+			elem, sep = pattern[:i], pattern[i:i+1]
+		}
+		pattern = pattern[len(elem)+len(sep):]
+
+		n, ok := globstarDepth(elem)
+		if !ok {
+			b.WriteString(elem + sep)
+			continue
+		}
+		// A trailing "**" matches the files in the deepest directories
+		// too.
+		limit := maxDepth
+		if sep == "" {
+			limit++
+		}
+		switch {
+		case limit == 0:
+		case n != 0 && n <= limit, limit > 9999:
+			b.WriteString(elem + sep)
+		default:
+			b.WriteString("**" + strconv.Itoa(limit) + sep)
+		}
+	}
+	return b.String()
+}
+
+// globstarDepth reports whether elem is a "**" or "**N" element, with the
+// most levels it matches, N, or 0 for any number.
+func globstarDepth(elem string) (n int, ok bool) {
+	if !strings.HasPrefix(elem, "**") {
+		return 0, false
+	}
+	digits := elem[2:]
+	if digits == "" {
+		return 0, true
+	}
+	if digits[0] == '0' || strings.Trim(digits, "0123456789") != "" || len(digits) > 4 {
+		return 0, false
+	}
+	n, _ = strconv.Atoi(digits)
+	return n, true
+}
+
+// depth returns the number of elements of the path p.
+func depth(p string) int {
+	p = filepath.Clean(p)
+	p = p[len(filepath.VolumeName(p)):]
+	p = strings.Trim(filepath.ToSlash(p), "/")
+	if p == "" || p == "." {
+		return 0
+	}
+	return strings.Count(p, "/") + 1
+}
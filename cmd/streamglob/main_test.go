@@ -0,0 +1,131 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRun(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestRun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, name := range []string{"src/a.go", "src/a_test.go", "src/b/b.go", "src/b/c/c.go", "vendor/v.go", "README"} {
+		p := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		args       []string
+		want       []string
+		wantStatus int
+	}{
+		{args: []string{"*"}, want: []string{"README", "src", "vendor"}},
+		{args: []string{"-type", "f", "*"}, want: []string{"README"}},
+		{args: []string{"-type", "d", "**"}, want: []string{"src", "src/b", "src/b/c", "vendor"}},
+		{args: []string{"**/*.go"}, want: []string{"src/a.go", "src/a_test.go", "src/b/b.go", "src/b/c/c.go", "vendor/v.go"}},
+		{args: []string{"-exclude", "*_test.go", "-exclude", "vendor/*", "**/*.go"}, want: []string{"src/a.go", "src/b/b.go", "src/b/c/c.go"}},
+		{args: []string{"-max-depth", "1", "src/**/*.go"}, want: []string{"src/a.go", "src/a_test.go", "src/b/b.go"}},
+		{args: []string{"-max-depth", "0", "-j", "4", "**/*.go", "src/*.go"}, want: []string{"src/a.go", "src/a_test.go"}},
+		{args: []string{"-max-depth", "0", "src/**"}, want: []string{"src", "src/a.go", "src/a_test.go", "src/b"}},
+		{args: []string{"-max-depth", "1", "-type", "d", "**"}, want: []string{"src", "src/b", "vendor"}},
+		{args: []string{"README", "src/[", "vendor/*"}, want: []string{"README", "vendor/v.go"}, wantStatus: 1},
+		{args: []string{}, wantStatus: 2},
+		{args: []string{"-type", "x", "*"}, wantStatus: 2},
+		{args: []string{"-exclude", "[", "*"}, wantStatus: 2},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(context.Background(), tt.args, &stdout, &stderr)
+		if status != tt.wantStatus {
+			t.Errorf("run(%q) = %d, want %d; stderr:\n%s", tt.args, status, tt.wantStatus, stderr.String())
+		}
+		got := strings.Fields(filepath.ToSlash(stdout.String()))
+		sort.Strings(got)
+		if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Bad output from run(%q), -want +got: %v", tt.args, diff)
+		}
+	}
+
+	var stdout bytes.Buffer
+	if status := run(context.Background(), []string{"-0", "-sort", "src/*.go"}, &stdout, ioutil.Discard); status != 0 {
+		t.Fatalf("run with -0 = %d, want 0", status)
+	}
+	if got, want := filepath.ToSlash(stdout.String()), "src/a.go\x00src/a_test.go\x00"; got != want {
+		t.Errorf("run with -0 printed %q, want %q", got, want)
+	}
+
+	// An interrupt, before or while the matches are printed, is a clean stop.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var stderr bytes.Buffer
+	if status := run(ctx, []string{"**", "*"}, ioutil.Discard, &stderr); status != 0 || stderr.Len() > 0 {
+		t.Errorf("run after an interrupt = %d, want 0; stderr:\n%s", status, stderr.String())
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := writerFunc(func(b []byte) (int, error) {
+		cancel()
+		return len(b), nil
+	})
+	if status := run(ctx, []string{"**", "*"}, interrupt, &stderr); status != 0 || stderr.Len() > 0 {
+		t.Errorf("run interrupted while printing = %d, want 0; stderr:\n%s", status, stderr.String())
+	}
+}
+
+// writerFunc is an io.Writer that calls itself.
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) { return f(b) }
+
+func TestLimitGlobstars(t *testing.T) {
+	for _, tt := range []struct {
+		pattern  string
+		maxDepth int
+		want     string
+	}{
+		{pattern: "src/**/*.go", maxDepth: 0, want: "src/*.go"},
+		{pattern: "src/**/*.go", maxDepth: 2, want: "src/**2/*.go"},
+		{pattern: "src/**", maxDepth: 0, want: "src/**1"},
+		{pattern: "**", maxDepth: 3, want: "**4"},
+		{pattern: "**/x/**/*.go", maxDepth: 1, want: "**1/x/**1/*.go"},
+		{pattern: "src/**5/*.go", maxDepth: 2, want: "src/**2/*.go"},
+		{pattern: "src/**1/*.go", maxDepth: 2, want: "src/**1/*.go"},
+		{pattern: "src/**/*.go", maxDepth: 10000, want: "src/**/*.go"},
+		{pattern: "src/**x/*.go", maxDepth: 0, want: "src/**x/*.go"},
+		{pattern: "src/*.go", maxDepth: 0, want: "src/*.go"},
+	} {
+		if got := limitGlobstars(tt.pattern, tt.maxDepth); got != tt.want {
+			t.Errorf("limitGlobstars(%q, %d) = %q, want %q", tt.pattern, tt.maxDepth, got, tt.want)
+		}
+	}
+}
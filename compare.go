@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// ComparePatterns returns a Watcher that reports the paths matched by exactly
+// one of oldPattern and newPattern, which are relative to the directory root:
+// a Remove event for each path that only oldPattern matches and a Create event
+// for each path that only newPattern matches. Paths are relative to root as
+// well. Once every difference has been reported, Next returns an Event with a
+// zero Op.
+//
+// This is meant for checking that a rewritten pattern, for example in a
+// configuration file, still selects the same files. The matches of oldPattern
+// are held in memory while those of newPattern are streamed.
+func ComparePatterns(ctx context.Context, oldPattern, newPattern, root string, opts ...Option) Watcher {
+	ctx, cancel := context.WithCancel(ctx)
+	wt := Watcher{
+		errors: make(chan error),
+		events: make(chan Event),
+		cancel: cancel,
+	}
+	go func() {
+		defer close(wt.events)
+		defer close(wt.errors)
+		if err := compare(ctx, oldPattern, newPattern, root, opts, wt.events); err != nil {
+			select {
+			case wt.errors <- err:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return wt
+}
+
+// compare sends the differences between the matches of oldPattern and
+// newPattern in root down the events channel.
+func compare(ctx context.Context, oldPattern, newPattern, root string, opts []Option, events chan<- Event) error {
+	rootPattern := Escape(root)
+	rel := func(m string) string {
+		if r, err := filepath.Rel(root, m); err == nil {
+			return r
+		}
+		return m
+	}
+
+	matches, err := Glob(ctx, filepath.Join(rootPattern, oldPattern), opts...)
+	if err != nil {
+		return err
+	}
+	old := make(map[string]bool, len(matches))
+	for _, m := range matches {
+		old[rel(m)] = true
+	}
+
+	r := Stream(filepath.Join(rootPattern, newPattern), opts...)
+	defer r.Close()
+	for {
+		m, err := r.NextWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		if m == "" {
+			break
+		}
+		m = rel(m)
+		if old[m] {
+			delete(old, m)
+			continue
+		}
+		if send(ctx, events, Event{Op: Create, Path: m}) != nil {
+			return nil
+		}
+	}
+	for m := range old {
+		if send(ctx, events, Event{Op: Remove, Path: m}) != nil {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestComparePatterns(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestComparePatterns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, f := range []string{"src/a.go", "src/a_test.go", "src/b.go", "src/doc.txt"} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		oldPattern, newPattern string
+		want                   []Event
+	}{
+		{"src/*.go", "src/[ab]*.go", nil},
+		{"src/*.go", "src/?.go", []Event{{Remove, filepath.FromSlash("src/a_test.go")}}},
+		{"src/*.go", "src/*", []Event{{Create, filepath.FromSlash("src/doc.txt")}}},
+		{"src/a*", "src/*.go", []Event{{Create, filepath.FromSlash("src/b.go")}}},
+		{"src/*.txt", "nonexistent/*", []Event{{Remove, filepath.FromSlash("src/doc.txt")}}},
+	}
+	for _, tt := range tests {
+		wt := ComparePatterns(context.Background(), tt.oldPattern, tt.newPattern, tmpDir)
+		var got []Event
+		for {
+			e, err := wt.Next()
+			if err != nil {
+				t.Fatalf("ComparePatterns(%q, %q): Next() returned unexpected error: %v", tt.oldPattern, tt.newPattern, err)
+			}
+			if e.Op == 0 {
+				break
+			}
+			got = append(got, e)
+		}
+		wt.Close()
+		sortEvents := cmpopts.SortSlices(func(x, y Event) bool { return x.Path < y.Path })
+		if diff := cmp.Diff(tt.want, got, sortEvents); diff != "" {
+			t.Errorf("ComparePatterns(%q, %q) returned diff (-want +got):\n%s", tt.oldPattern, tt.newPattern, diff)
+		}
+	}
+}
+
+func TestComparePatternsError(t *testing.T) {
+	wt := ComparePatterns(context.Background(), "*", "[", ".")
+	defer wt.Close()
+	for {
+		e, err := wt.Next()
+		if err != nil {
+			return
+		}
+		if e.Op == 0 {
+			t.Fatal("ComparePatterns with a malformed pattern returned no error")
+		}
+	}
+}
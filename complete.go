@@ -0,0 +1,86 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Complete returns up to n completions of partial, a pattern that is still
+// being typed, as an interactive prompt offers them: the paths whose last
+// element begins with what partial's last element matches, so that "src/ma"
+// completes to "src/main.go" and "src/math/". Completions that are
+// directories, or symbolic links to them, end in a separator, ready for the
+// next element to be typed after them; the others are the paths of files.
+//
+// The path elements before the last may hold wildcards, as in "*/ma", which
+// are matched as Stream matches them with opts, and the completions are paths
+// rather than patterns: they need quoting with Escape to be used as patterns
+// themselves. As in shells, names beginning with a dot are only completed if
+// the last element of partial does too.
+//
+// Completions come in the order of WithSortedOrder, and are the first n in it
+// if there are more. A partial whose last element is not yet a valid pattern,
+// such as "src/[a", is reported as an error, as Stream reports it. The search
+// is bounded in time by ctx and by WithTimeout: if either runs out first,
+// Complete returns the completions found so far along with the error, for
+// the prompt to show what it can.
+func Complete(ctx context.Context, partial string, n int, opts ...Option) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	last := partial
+	if i := strings.LastIndexFunc(partial, isSeparatorRune); i >= 0 {
+		last = partial[i+1:]
+	}
+	hidden := strings.HasPrefix(last, ".")
+	opts = append(opts[:len(opts):len(opts)], WithSortedOrder())
+	r := Stream(partial+"*", opts...)
+	defer r.Close()
+	sep := string(filepath.Separator)
+	if r.w.opts.forwardSlashes {
+		sep = "/"
+	}
+	completions := make([]string, 0)
+	for len(completions) < n {
+		m, err := r.next(ctx)
+		if err != nil {
+			return completions, err
+		}
+		if m.path == "" {
+			break
+		}
+		name := filepath.Base(m.path)
+		if !hidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if completesDir(ctx, m, sep, opts) {
+			m.path += sep
+		}
+		completions = append(completions, m.path)
+	}
+	return completions, nil
+}
+
+// completesDir reports whether the match m of Complete is a directory, or a
+// symbolic link to one, when looked up as Stream looks it up with opts.
+func completesDir(ctx context.Context, m found, sep string, opts []Option) bool {
+	if m.d != nil && m.d.Type()&fs.ModeSymlink == 0 {
+		return m.d.IsDir()
+	}
+	matches, err := Glob(ctx, Escape(m.path)+sep, opts...)
+	return err == nil && len(matches) > 0
+}
+
+// isSeparatorRune is os.IsPathSeparator for runes.
+func isSeparatorRune(r rune) bool {
+	return r < 0x80 && os.IsPathSeparator(uint8(r))
+}
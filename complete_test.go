@@ -0,0 +1,105 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestComplete(t *testing.T) {
+	fsys := fstest.MapFS{
+		".profile":         {},
+		"Makefile":         {},
+		"src/main.go":      {},
+		"src/math/add.go":  {},
+		"src/.hidden":      {},
+		"src/util.go":      {},
+		"docs/manual.md":   {},
+		"vendor/x/main.go": {},
+	}
+	for _, tt := range []struct {
+		partial string
+		n       int
+		want    []string
+	}{
+		{partial: "", n: 10, want: []string{"Makefile", "docs/", "src/", "vendor/"}},
+		{partial: ".", n: 10, want: []string{".profile"}},
+		{partial: "src/ma", n: 10, want: []string{"src/main.go", "src/math/"}},
+		{partial: "src/", n: 10, want: []string{"src/main.go", "src/math/", "src/util.go"}},
+		{partial: "src/.", n: 10, want: []string{"src/.hidden"}},
+		{partial: "*/ma", n: 10, want: []string{"docs/manual.md", "src/main.go", "src/math/"}},
+		{partial: "src/[mu]", n: 10, want: []string{"src/main.go", "src/math/", "src/util.go"}},
+		// Completions are the first n in sorted order.
+		{partial: "src/", n: 2, want: []string{"src/main.go", "src/math/"}},
+		{partial: "nothing/", n: 10, want: []string{}},
+		{partial: "src/", n: 0, want: []string{}},
+	} {
+		got, err := Complete(context.Background(), tt.partial, tt.n, WithFS(fsys))
+		if err != nil {
+			t.Errorf("Complete(%q) returned unexpected error: %v", tt.partial, err)
+			continue
+		}
+		var want []string
+		for _, p := range tt.want {
+			want = append(want, filepath.FromSlash(p))
+		}
+		if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Bad results from Complete(%q), -want +got: %v", tt.partial, diff)
+		}
+	}
+
+	if _, err := Complete(context.Background(), "src/[a", 10, WithFS(fsys)); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("Complete(%q) returned error %v, want %v", "src/[a", err, filepath.ErrBadPattern)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Complete(ctx, "src/", 10, WithFS(fsys)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Complete with a canceled context returned error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCompleteSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+	dir, err := ioutil.TempDir("", "glob-complete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "real"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"dirlink": "real", "filelink": "file", "broken": "missing"} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Complete(context.Background(), "", 10, WithRoot(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"broken", "dirlink/", "file", "filelink", "real/"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Bad results from Complete(%q), -want +got: %v", "", diff)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import "sync"
+
+// group collects the outcome of tasks started with spawn.
+type group struct {
+	wg sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+// failed reports whether a task in the group has returned an error.
+func (g *group) failed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err != nil
+}
+
+// wait waits for the tasks in the group and returns the first error any of
+// them returned.
+func (g *group) wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+// spawn runs f as part of g. It runs f in a new goroutine if fewer than
+// WithConcurrency-1 additional goroutines are already busy on behalf of the
+// Stream, and in the calling goroutine otherwise. Because spawn never waits for
+// a goroutine to become free, tasks can spawn further tasks without risk of
+// deadlock.
+//
+// If f returns an error, the rest of the Stream is told to stop.
+func (w *walker) spawn(g *group, f func() error) {
+	run := func() {
+		if err := f(); err != nil {
+			g.mu.Lock()
+			if g.err == nil {
+				g.err = err
+			}
+			g.mu.Unlock()
+			w.abort()
+		}
+	}
+
+	select {
+	case w.sem <- struct{}{}:
+		g.wg.Add(1)
+		go func() {
+			defer func() { <-w.sem }()
+			defer g.wg.Done()
+			run()
+		}()
+	default:
+		run()
+	}
+}
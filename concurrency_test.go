@@ -0,0 +1,76 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobConcurrency(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobConcurrency")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			dir := filepath.Join(tmpDir, fmt.Sprintf("d%d", i), fmt.Sprintf("e%d", j))
+			if err := os.MkdirAll(dir, 0777); err != nil {
+				t.Fatal(err)
+			}
+			for k := 0; k < 4; k++ {
+				if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", k)), nil, 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	for _, pattern := range []string{"*/*/*.txt", "d?/e1/*", "**/f3.txt", "d2/**", "*/**/*"} {
+		pattern = filepath.Join(tmpDir, filepath.FromSlash(pattern))
+		want, err := Glob(context.Background(), pattern, WithGlobstar())
+		if err != nil {
+			t.Fatalf("Glob error for %q: %s", pattern, err)
+		}
+		for _, n := range []int{2, 16} {
+			matches, err := Glob(context.Background(), pattern, WithGlobstar(), WithConcurrency(n))
+			if err != nil {
+				t.Errorf("Glob error for %q with WithConcurrency(%d): %s", pattern, n, err)
+				continue
+			}
+			if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+				t.Errorf("Bad results from Glob(%#q) with WithConcurrency(%d), -want +got: %v", pattern, n, diff)
+			}
+		}
+	}
+
+	pattern := filepath.Join(tmpDir, "*", "*", "[]")
+	if _, err := Glob(context.Background(), pattern, WithConcurrency(4)); err == nil {
+		t.Errorf("expected error for bad pattern with WithConcurrency(4); got none")
+	}
+}
+
+func TestPartialGlobConcurrency(t *testing.T) {
+	gr := Stream("testdata/**", WithGlobstar(), WithConcurrency(4))
+	if _, err := gr.Next(); err != nil {
+		t.Fatalf("Next() returned unexpected error: %v", err)
+	}
+	if err := gr.Close(); err != nil {
+		t.Fatalf("Close() returned unexpected error: %v", err)
+	}
+	if match, err := gr.Next(); match != "" || err != ErrClosed {
+		t.Errorf("After Close(), Next() = %q, %v, want %v", match, err, ErrClosed)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build go1.24
+
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// rootFS is the tree beneath a directory, opened with os.OpenRoot so that no
+// name, however many symbolic links or ".." elements it goes through, can
+// resolve to a file outside of it.
+type rootFS struct {
+	root *os.Root
+}
+
+// openConfined returns the tree beneath dir, for WithConfinedRoot.
+func openConfined(dir string) (*rootFS, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &rootFS{root: root}, nil
+}
+
+// openWithin returns the tree beneath the directory dir of r, which is
+// confined to r as well.
+func (r *rootFS) openWithin(dir string) (*rootFS, error) {
+	root, err := r.root.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &rootFS{root: root}, nil
+}
+
+func (r *rootFS) Open(name string) (fs.File, error)      { return r.root.Open(name) }
+func (r *rootFS) Stat(name string) (fs.FileInfo, error)  { return r.root.Stat(name) }
+func (r *rootFS) Lstat(name string) (fs.FileInfo, error) { return r.root.Lstat(name) }
+
+func (r *rootFS) native(name string) (string, bool) {
+	return filepath.Join(r.root.Name(), name), true
+}
+
+func (r *rootFS) Close() error {
+	return r.root.Close()
+}
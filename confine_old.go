@@ -0,0 +1,29 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build !go1.24
+
+package glob
+
+import "errors"
+
+// rootFS would confine traversal to a directory, but needs os.Root, which
+// was added in Go 1.24.
+type rootFS struct {
+	filesystem
+}
+
+func openConfined(dir string) (*rootFS, error) {
+	return nil, errors.New("glob: WithConfinedRoot requires Go 1.24 or later")
+}
+
+func (r *rootFS) openWithin(dir string) (*rootFS, error) {
+	return nil, errors.New("glob: WithConfinedRoot requires Go 1.24 or later")
+}
+
+func (r *rootFS) Close() error {
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build go1.24
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobConfinedRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("skipping symlink test on Windows")
+	}
+	tmpDir, err := ioutil.TempDir("", "TestGlobConfinedRoot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// root/out and root/dir/up point outside of root, and root/in points
+	// within it.
+	for _, dir := range []string{"root/dir", "outside"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"root/file", "root/dir/x", "outside/secret"} {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, f), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"root/out":    filepath.Join(tmpDir, "outside"),
+		"root/dir/up": "../../outside",
+		"root/in":     "dir",
+	} {
+		if err := os.Symlink(target, filepath.Join(tmpDir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := filepath.Join(tmpDir, "root")
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "*", want: []string{"dir", "file", "in", "out"}},
+		{pattern: "*/*", want: []string{"dir/up", "dir/x", "in/up", "in/x"}},
+		{pattern: "out/*", want: []string{}},
+		{pattern: "dir/up/secret", want: []string{}},
+		{pattern: "../*", want: []string{}},
+		{pattern: "dir/../../outside/*", want: []string{}},
+		{pattern: "*", opts: []Option{WithSkipBrokenSymlinks()}, want: []string{"dir", "file", "in"}},
+		{
+			pattern: "**",
+			opts:    []Option{WithGlobstar(), WithFollowSymlinks()},
+			want:    []string{"dir", "dir/up", "dir/x", "file", "in", "in/up", "in/x", "out"},
+		},
+		{pattern: "f*", opts: []Option{WithAbsolutePaths()}, want: []string{filepath.Join(root, "file")}},
+	}
+	for _, tt := range tests {
+		want := []string{}
+		for _, w := range tt.want {
+			want = append(want, filepath.FromSlash(w))
+		}
+		matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithConfinedRoot(root))...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithConfinedRoot, -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	pattern := filepath.Join(Escape(tmpDir), "outside", "*")
+	if _, err := Glob(context.Background(), pattern, WithConfinedRoot(root)); err == nil {
+		t.Errorf("Glob(%q) with WithConfinedRoot succeeded, want an error for an absolute pattern", pattern)
+	}
+
+	// A later WithRoot is within the confined root.
+	for _, dir := range []string{"dir", "in"} {
+		matches, err := Glob(context.Background(), "*", WithConfinedRoot(root), WithRoot(dir))
+		if err != nil {
+			t.Errorf("Glob(%q) with WithRoot(%q) within WithConfinedRoot error: %s", "*", dir, err)
+			continue
+		}
+		if diff := cmp.Diff([]string{"up", "x"}, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithRoot(%q) within WithConfinedRoot, -want +got: %v", "*", dir, diff)
+		}
+	}
+	for _, dir := range []string{"..", "dir/../..", "out", filepath.Join(tmpDir, "outside")} {
+		if matches, err := Glob(context.Background(), "*", WithConfinedRoot(root), WithRoot(dir)); err == nil {
+			t.Errorf("Glob(%q) with WithRoot(%q) within WithConfinedRoot = %q, want an error for a root outside it", "*", dir, matches)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// deduper remembers the matches produced so far, for WithDeduplication.
+type deduper struct {
+	fsys filesystem
+	seen map[string]bool
+	// caseInsensitive caches, by directory, whether the filesystem holding
+	// it ignores case, once a name in it has shown which.
+	caseInsensitive map[string]bool
+}
+
+func newDeduper(fsys filesystem) *deduper {
+	return &deduper{fsys: fsys, seen: make(map[string]bool), caseInsensitive: make(map[string]bool)}
+}
+
+// keep reports whether the match p refers to a directory entry not matched
+// before.
+func (d *deduper) keep(p string) (string, bool) {
+	k, err := d.key(p)
+	if err != nil {
+		k = p
+	}
+	if d.seen[k] {
+		return p, false
+	}
+	d.seen[k] = true
+	return p, true
+}
+
+// key returns the canonical form of the path p: absolute, with symbolic links
+// resolved, and folded to lower case if the filesystem holding it ignores
+// case. Outside the operating system's filesystem, it is p, cleaned.
+func (d *deduper) key(p string) (string, error) {
+	np, ok := d.fsys.native(p)
+	if !ok {
+		return filepath.Clean(p), nil
+	}
+	abs, err := filepath.Abs(np)
+	if err != nil {
+		return "", err
+	}
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	dir, base := filepath.Split(real)
+	insensitive, known := d.caseInsensitive[dir]
+	if !known {
+		if swapped := swapCase(base); swapped != base {
+			insensitive = sameFile(real, dir+swapped)
+			d.caseInsensitive[dir] = insensitive
+		}
+	}
+	if insensitive {
+		return strings.ToLower(real), nil
+	}
+	return real, nil
+}
+
+// swapCase returns s with the case of each letter inverted.
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if u := unicode.ToUpper(r); u != r {
+			return u
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// sameFile reports whether the paths a and b both exist and name the same
+// file.
+func sameFile(a, b string) bool {
+	fa, err := os.Lstat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Lstat(b)
+	return err == nil && os.SameFile(fa, fb)
+}
+
+// linkDeduper remembers the files matched so far, for
+// WithHardlinkDeduplication.
+type linkDeduper struct {
+	w    *walker
+	seen map[linkKey]bool
+}
+
+// linkKey identifies a match by the file it refers to and, for a match made
+// through WithAlternateDataStreams, the name of the data stream.
+type linkKey struct {
+	id     fileID
+	stream string
+}
+
+func newLinkDeduper(w *walker) *linkDeduper {
+	return &linkDeduper{w: w, seen: make(map[linkKey]bool)}
+}
+
+// keep reports whether the match p refers to a file not matched before. A
+// match whose identity can't be determined is always kept.
+func (d *linkDeduper) keep(p string) (string, bool) {
+	var k linkKey
+	file := p
+	if d.w.opts.dataStreams {
+		if f, stream, ok := splitDataStream(p); ok {
+			file, k.stream = f, stream
+		}
+	}
+	id, ok := d.w.identity(file, nil)
+	if !ok {
+		return p, true
+	}
+	k.id = id
+	if d.seen[k] {
+		return p, false
+	}
+	d.seen[k] = true
+	return p, true
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobDeduplication(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping test that needs symbolic links")
+	}
+	tmpDir, err := ioutil.TempDir("", "TestGlobDeduplication")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "real"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "real", "file"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(tmpDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := filepath.Join(Escape(tmpDir), "*", "file")
+	matches, err := Glob(context.Background(), pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("Glob(%q) = %q, want a match through the link and one through the real path", pattern, matches)
+	}
+
+	matches, err = Glob(context.Background(), pattern, WithDeduplication(), WithSortedOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{filepath.Join(tmpDir, "link", "file")}, matches); diff != "" {
+		t.Errorf("Bad results from Glob(%q) with WithDeduplication, -want +got: %v", pattern, diff)
+	}
+}
+
+func TestDeduperCaseInsensitive(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestDeduperCaseInsensitive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := os.Mkdir(filepath.Join(tmpDir, "Foo"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "Foo", "Bar"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Lstat(filepath.Join(tmpDir, "foo", "bar"))
+	insensitive := err == nil
+
+	d := newDeduper(osFS{})
+	if _, ok := d.keep(filepath.Join(tmpDir, "Foo", "Bar")); !ok {
+		t.Fatal("keep dropped the first match")
+	}
+	if _, ok := d.keep(filepath.Join(tmpDir, "foo", "bar")); ok != !insensitive {
+		t.Errorf("keep of a differently cased match = %v on a filesystem that is case-insensitive: %v", ok, insensitive)
+	}
+}
+
+func TestGlobHardlinkDeduplication(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobHardlinkDeduplication")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"a", "c"} {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Link(filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "b")); err != nil {
+		t.Skipf("can't create hard links: %v", err)
+	}
+
+	pattern := filepath.Join(Escape(tmpDir), "*")
+	matches, err := Glob(context.Background(), pattern, WithSortedOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("Glob(%q) = %q, want a match for each name", pattern, matches)
+	}
+
+	matches, err = Glob(context.Background(), pattern, WithHardlinkDeduplication(), WithSortedOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "c")}
+	if diff := cmp.Diff(want, matches); diff != "" {
+		t.Errorf("Bad results from Glob(%q) with WithHardlinkDeduplication, -want +got: %v", pattern, diff)
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// dirReader reads the entries of a directory.
+//
+// The entries' types come from the directory listing itself wherever the
+// platform provides them (d_type on Unix, the attributes returned alongside
+// each name by the bulk directory queries os uses on Windows), so reading an
+// entry's type does not cost a system call per entry. Other metadata, such as
+// sizes and times, is fetched per entry through fs.DirEntry.Info, and only
+// when needed. There is no special-purpose bulk backend for it, such as
+// getattrlistbulk on macOS: Go programs can only make system calls there
+// through libSystem, whose wrappers the standard library doesn't expose.
+//
+// Nor is there an io_uring backend on Linux. io_uring has no getdents
+// operation, so while it could issue the openat and statx calls of a
+// traversal asynchronously, each directory would still be read by a blocking
+// getdents64, and that is where a latency-bound traversal waits. Reading
+// directories from goroutines, as WithConcurrency does, overlaps all three.
+type dirReader interface {
+	// next returns the next entry, or io.EOF if there are no more.
+	next() (fs.DirEntry, error)
+	Close() error
+}
+
+// openDir opens dir for reading its entries, in the order requested by the
+// options. A directory deleted before or while it is read yields whatever
+// could be read of it, without an error.
+//
+// Only the entries whose names begin with prefix are needed, which
+// filesystems that list directories remotely use to narrow their queries;
+// others return every entry.
+func (w *walker) openDir(dir, prefix string) (dirReader, error) {
+	if w.span == nil && w.opts.progress == nil {
+		return w.readAhead(dir, prefix)
+	}
+	start := time.Now()
+	d, err := w.readAhead(dir, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if w.opts.progress != nil {
+		atomic.AddInt64(&w.pending, 1)
+		d = &pendingDirReader{dirReader: d, w: w}
+	}
+	if w.span != nil {
+		d = &timedDirReader{dirReader: d, w: w, dir: dir, start: start, elapsed: time.Since(start)}
+	}
+	return d, nil
+}
+
+// readAhead is openDir, without timing for WithTracer or counting for
+// WithProgress. The directory is opened and read by a goroutine of its own, a
+// chunk ahead of its entries being returned, so that closing the Stream
+// interrupts a read blocked on a dead network mount: the walker gives up on
+// the read, rather than waiting for a system call that may never return, and
+// the goroutine closes the directory once it does.
+func (w *walker) readAhead(dir, prefix string) (dirReader, error) {
+	r := &aheadDirReader{chunks: make(chan dirChunkResult), stop: make(chan struct{}), cancel: w.cancel}
+	go r.read(w, dir, prefix)
+	select {
+	case c := <-r.chunks:
+		if c.open {
+			return nil, c.err
+		}
+		r.entries, r.err = c.entries, c.err
+		return r, nil
+	case <-w.cancel:
+		close(r.stop)
+		return &sliceDirReader{}, nil
+	}
+}
+
+// readDir is readAhead, reading the directory as it goes.
+func (w *walker) readDir(dir, prefix string) (dirReader, error) {
+	var file fs.File
+	var err error
+	if po, ok := w.fsys.(prefixOpener); ok && prefix != "" {
+		file, err = po.openPrefix(dir, prefix)
+	} else {
+		file, err = w.fsys.Open(dir)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		// It was deleted since it was matched.
+		return &sliceDirReader{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, ok := file.(fs.ReadDirFile)
+	if !ok {
+		file.Close()
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: errors.New("not implemented")}
+	}
+	if w.opts.sorted {
+		entries, err := f.ReadDir(-1)
+		f.Close()
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		return &sliceDirReader{entries: entries}, nil
+	}
+	// entriesByInode stats entries by their paths, which only name them in
+	// the operating system's own view of the filesystem.
+	_, native := w.fsys.(osFS)
+	if osf, ok := f.(*os.File); ok && native && w.opts.inodeOrder && inodeOrderSupported {
+		entries, err := entriesByInode(dir, osf)
+		f.Close()
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return &sliceDirReader{entries: entries}, nil
+	}
+	if osf, ok := f.(*os.File); ok && native && getdentsSupported {
+		// On Linux, huge directories are read faster in bulk, a buffer of
+		// raw entries at a time.
+		r, err := newGetdentsReader(dir, osf)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return r, nil
+	}
+	return &fileDirReader{f: f}, nil
+}
+
+// dirChunk is the number of entries a fileDirReader reads at a time.
+const dirChunk = 256
+
+// fileDirReader reads entries from a directory a chunk at a time, so that
+// reading huge directories takes constant memory, and their matches are found
+// while they're still being read.
+type fileDirReader struct {
+	f       fs.ReadDirFile
+	entries []fs.DirEntry // read but not yet returned
+	err     error         // returned once entries are
+}
+
+func (r *fileDirReader) next() (fs.DirEntry, error) {
+	if len(r.entries) == 0 && r.err == nil {
+		r.entries, r.err = r.f.ReadDir(dirChunk)
+		if r.err == nil && len(r.entries) == 0 {
+			r.err = io.EOF
+		}
+	}
+	if len(r.entries) > 0 {
+		e := r.entries[0]
+		r.entries = r.entries[1:]
+		return e, nil
+	}
+	if errors.Is(r.err, fs.ErrNotExist) {
+		// The directory was deleted while being read.
+		return nil, io.EOF
+	}
+	return nil, r.err
+}
+
+func (r *fileDirReader) Close() error {
+	return r.f.Close()
+}
+
+// An aheadDirReader returns the entries read ahead by its read method.
+type aheadDirReader struct {
+	chunks chan dirChunkResult
+	stop   chan struct{} // closed by Close
+	cancel <-chan struct{}
+
+	entries []fs.DirEntry // received but not yet returned
+	err     error         // returned once entries are
+}
+
+// dirChunkResult is a chunk of entries sent by aheadDirReader.read, with the
+// error that ended the directory, if any, or the error of opening it if open
+// is set.
+type dirChunkResult struct {
+	entries []fs.DirEntry
+	err     error
+	open    bool
+}
+
+// read opens dir and sends its entries down the chunks channel, a chunk at a
+// time, until they are exhausted or the reader is closed.
+func (r *aheadDirReader) read(w *walker, dir, prefix string) {
+	d, err := w.readDir(dir, prefix)
+	if err != nil {
+		select {
+		case r.chunks <- dirChunkResult{err: err, open: true}:
+		case <-r.stop:
+		}
+		return
+	}
+	defer d.Close()
+	for {
+		var c dirChunkResult
+		if s, ok := d.(*sliceDirReader); ok {
+			// The directory has been read in full already.
+			c.entries, c.err = s.entries, io.EOF
+		}
+		for len(c.entries) < dirChunk && c.err == nil {
+			e, err := d.next()
+			if err != nil {
+				c.err = err
+				break
+			}
+			c.entries = append(c.entries, e)
+		}
+		select {
+		case r.chunks <- c:
+		case <-r.stop:
+			return
+		}
+		if c.err != nil {
+			return
+		}
+	}
+}
+
+func (r *aheadDirReader) next() (fs.DirEntry, error) {
+	if len(r.entries) == 0 && r.err == nil {
+		select {
+		case c := <-r.chunks:
+			r.entries, r.err = c.entries, c.err
+		case <-r.cancel:
+			// The Stream is closed; give up on the read.
+			return nil, io.EOF
+		}
+	}
+	if len(r.entries) > 0 {
+		e := r.entries[0]
+		r.entries = r.entries[1:]
+		return e, nil
+	}
+	return nil, r.err
+}
+
+func (r *aheadDirReader) Close() error {
+	close(r.stop)
+	return nil
+}
+
+// sliceDirReader returns entries that have already been read.
+type sliceDirReader struct {
+	entries []fs.DirEntry
+}
+
+func (r *sliceDirReader) next() (fs.DirEntry, error) {
+	if len(r.entries) == 0 {
+		return nil, io.EOF
+	}
+	e := r.entries[0]
+	r.entries = r.entries[1:]
+	return e, nil
+}
+
+func (r *sliceDirReader) Close() error {
+	return nil
+}
+
+// timedDirReader measures the time spent reading a directory, excluding the
+// time between reads, and reports the directory to the span of WithTracer if
+// it was slow.
+type timedDirReader struct {
+	dirReader
+	w       *walker
+	dir     string
+	start   time.Time
+	elapsed time.Duration
+}
+
+func (r *timedDirReader) next() (fs.DirEntry, error) {
+	start := time.Now()
+	e, err := r.dirReader.next()
+	r.elapsed += time.Since(start)
+	return e, err
+}
+
+func (r *timedDirReader) Close() error {
+	if r.elapsed >= r.w.opts.slowDir {
+		r.w.span.SlowDir(r.dir, r.start, r.elapsed)
+	}
+	return r.dirReader.Close()
+}
@@ -0,0 +1,216 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestOpenDirDeleted(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestOpenDirDeleted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	deleted := filepath.Join(tmpDir, "deleted")
+
+	for _, opts := range []options{{}, {sorted: true}, {inodeOrder: true}} {
+		w := &walker{opts: opts, fsys: osFS{}}
+		d, err := w.openDir(deleted, "")
+		if err != nil {
+			t.Fatalf("openDir of a deleted directory with %+v returned unexpected error: %v", opts, err)
+		}
+		if e, err := d.next(); err != io.EOF {
+			t.Errorf("openDir of a deleted directory with %+v: next() = %v, %v; want io.EOF", opts, e, err)
+		}
+		d.Close()
+	}
+}
+
+// TestGlobConcurrentDeletion globs a tree while files and directories in it
+// are created and deleted, which must never produce an error.
+func TestGlobConcurrentDeletion(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobConcurrentDeletion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; ctx.Err() == nil; n++ {
+				dir := filepath.Join(tmpDir, fmt.Sprintf("d%d-%d", i, n%8))
+				os.MkdirAll(filepath.Join(dir, "sub"), 0777)
+				ioutil.WriteFile(filepath.Join(dir, "sub", "f.log"), []byte("x"), 0666)
+				os.RemoveAll(dir)
+			}
+		}(i)
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	root := Escape(tmpDir)
+	for i := 0; i < 200; i++ {
+		for _, tt := range []struct {
+			pattern string
+			opts    []Option
+		}{
+			{pattern: filepath.Join(root, "*", "*", "*.log")},
+			{pattern: filepath.Join(root, "*", "*", "*.log"), opts: []Option{WithNonEmptyFiles(), WithSortedOrder()}},
+			{pattern: filepath.Join(root, "*", "*", "*"), opts: []Option{WithInodeOrder()}},
+			{pattern: filepath.Join(root, "**", "*.log"), opts: []Option{WithGlobstar(), WithConcurrency(4)}},
+		} {
+			if _, err := Glob(context.Background(), tt.pattern, tt.opts...); err != nil {
+				t.Fatalf("Glob(%q) returned error while entries were being deleted: %v", tt.pattern, err)
+			}
+		}
+	}
+}
+
+// gatedFS is a filesystem of a single huge directory, "big", whose listing
+// stops after its first chunk of entries until release is closed.
+type gatedFS struct {
+	fstest.MapFS
+	release chan struct{}
+}
+
+func (g gatedFS) Open(name string) (fs.File, error) {
+	f, err := g.MapFS.Open(name)
+	if err != nil || name != "big" {
+		return f, err
+	}
+	return &gatedDir{ReadDirFile: f.(fs.ReadDirFile), release: g.release}, nil
+}
+
+type gatedDir struct {
+	fs.ReadDirFile
+	release chan struct{}
+	read    int
+}
+
+func (d *gatedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.read > 0 {
+		<-d.release
+	}
+	entries, err := d.ReadDirFile.ReadDir(n)
+	d.read += len(entries)
+	return entries, err
+}
+
+func TestGlobHugeDirectoryStreams(t *testing.T) {
+	g := gatedFS{MapFS: fstest.MapFS{}, release: make(chan struct{})}
+	for i := 0; i < 10*dirChunk; i++ {
+		g.MapFS[fmt.Sprintf("big/%05d", i)] = &fstest.MapFile{}
+	}
+	r := Stream("big/*", WithFS(g))
+	defer r.Close()
+	// Matches from the first chunk arrive while the rest of the directory
+	// is still to be read.
+	for i := 0; i < dirChunk; i++ {
+		m, err := r.Next()
+		if err != nil || m == "" {
+			t.Fatalf("Next() = %q, %v before the directory was read in full, want a match", m, err)
+		}
+	}
+	close(g.release)
+	n := dirChunk
+	for {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == "" {
+			break
+		}
+		n++
+	}
+	if n != 10*dirChunk {
+		t.Errorf("Stream matched %d entries, want %d", n, 10*dirChunk)
+	}
+}
+
+// stuckFS is a filesystem whose directory "stuck" can't be read until release
+// is closed, like one on a dead network mount.
+type stuckFS struct {
+	fstest.MapFS
+	reading chan struct{} // closed once the read has begun
+	release chan struct{}
+	closed  chan struct{} // closed once the directory is
+}
+
+func (s stuckFS) Open(name string) (fs.File, error) {
+	f, err := s.MapFS.Open(name)
+	if err != nil || name != "stuck" {
+		return f, err
+	}
+	return &stuckDir{ReadDirFile: f.(fs.ReadDirFile), fsys: s}, nil
+}
+
+type stuckDir struct {
+	fs.ReadDirFile
+	fsys stuckFS
+}
+
+func (d *stuckDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	select {
+	case <-d.fsys.reading:
+	default:
+		close(d.fsys.reading)
+	}
+	<-d.fsys.release
+	return d.ReadDirFile.ReadDir(n)
+}
+
+func (d *stuckDir) Close() error {
+	close(d.fsys.closed)
+	return d.ReadDirFile.Close()
+}
+
+func TestCloseInterruptsDirectoryRead(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithSortedOrder()}} {
+		s := stuckFS{
+			MapFS:   fstest.MapFS{"stuck/a": {}},
+			reading: make(chan struct{}),
+			release: make(chan struct{}),
+			closed:  make(chan struct{}),
+		}
+		r := Stream("stuck/*", append(opts, WithFS(s))...)
+		<-s.reading
+		r.Close()
+		// The Stream ends without waiting for the read.
+		deadline := time.Now().Add(10 * time.Second)
+		for atomic.LoadInt32(&r.w.ended) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("Stream didn't end after Close while reading a directory")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if m, err := r.Next(); m != "" || err != ErrClosed {
+			t.Errorf("After Close(), Next() = %q, %v, want %v", m, err, ErrClosed)
+		}
+		// The directory is closed once the read returns.
+		close(s.release)
+		<-s.closed
+	}
+}
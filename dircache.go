@@ -0,0 +1,219 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// A DirCache remembers the directory listings and file metadata read by the
+// Streams it is given to with WithDirCache, so that patterns evaluated one
+// after another against the same tree read each directory once between them:
+//
+//	gb := glob.NewGlobber(glob.WithDirCache(glob.NewDirCache()))
+//	for _, pattern := range patterns {
+//		matches, err := gb.Glob(ctx, pattern)
+//		...
+//	}
+//
+// Nothing in a DirCache expires, so a Stream using one doesn't see changes to
+// the tree made after the directories were first read; call Reset to forget
+// them. Listings are held in memory in full. Paths found not to exist or not
+// to be accessible are remembered as well, so that they aren't tried again
+// and, where a Stream logs them (see WithLogger), they are logged once. A
+// DirCache must only be used by Streams that read the same filesystem, with
+// the same root given by WithConfinedRoot, if any, since it knows directories
+// by their paths. It is safe for concurrent use.
+type DirCache struct {
+	mu       sync.Mutex
+	entries  map[string][]fs.DirEntry
+	openErrs map[string]error
+	stats    map[string]statResult
+	lstats   map[string]statResult
+}
+
+// statResult is the cached result of a stat, which failed if err is set.
+type statResult struct {
+	fi  fs.FileInfo
+	err error
+}
+
+// NewDirCache returns an empty DirCache.
+func NewDirCache() *DirCache {
+	c := &DirCache{}
+	c.Reset()
+	return c
+}
+
+// Reset empties the cache.
+func (c *DirCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string][]fs.DirEntry)
+	c.openErrs = make(map[string]error)
+	c.stats = make(map[string]statResult)
+	c.lstats = make(map[string]statResult)
+}
+
+// WithDirCache makes the Stream read directories and stat files through c,
+// which it shares with every other Stream given c. Successful reads are
+// cached, and failures for not existing or for lacking permission; a nil c
+// turns caching off.
+func WithDirCache(c *DirCache) Option {
+	return func(o *options) {
+		o.dirCache = c
+	}
+}
+
+// useDirCache makes the walker read its filesystem through the DirCache of
+// WithDirCache, if any. It is called once the filesystem is otherwise set up,
+// since the walker tells the operating system's filesystem by its type.
+func (w *walker) useDirCache() {
+	if w.opts.dirCache != nil {
+		w.fsys = cachedFS{w.fsys, w.opts.dirCache}
+	}
+}
+
+// cachedFS is a filesystem whose directory listings and metadata are cached in
+// a DirCache.
+type cachedFS struct {
+	filesystem
+	c *DirCache
+}
+
+// Open opens name, which is only read from the filesystem if it is a file or
+// a directory not read before.
+func (f cachedFS) Open(name string) (fs.File, error) {
+	f.c.mu.Lock()
+	entries, ok := f.c.entries[name]
+	openErr := f.c.openErrs[name]
+	f.c.mu.Unlock()
+	if ok {
+		return &cachedDir{fsys: f, name: name, entries: entries, read: true}, nil
+	}
+	if openErr != nil {
+		return nil, openErr
+	}
+	file, err := f.filesystem.Open(name)
+	if err != nil {
+		if cacheable(err) {
+			f.c.mu.Lock()
+			f.c.openErrs[name] = cachedError{err}
+			f.c.mu.Unlock()
+		}
+		return nil, err
+	}
+	return &cachedDir{File: file, fsys: f, name: name}, nil
+}
+
+func (f cachedFS) Stat(name string) (fs.FileInfo, error) {
+	return f.stat(f.c.stats, f.filesystem.Stat, name)
+}
+
+func (f cachedFS) Lstat(name string) (fs.FileInfo, error) {
+	return f.stat(f.c.lstats, f.filesystem.Lstat, name)
+}
+
+func (f cachedFS) stat(cache map[string]statResult, stat func(string) (fs.FileInfo, error), name string) (fs.FileInfo, error) {
+	f.c.mu.Lock()
+	r, ok := cache[name]
+	f.c.mu.Unlock()
+	if ok {
+		return r.fi, r.err
+	}
+	fi, err := stat(name)
+	if err != nil && !cacheable(err) {
+		return nil, err
+	}
+	r = statResult{fi: fi}
+	if err != nil {
+		r.err = cachedError{err}
+	}
+	f.c.mu.Lock()
+	cache[name] = r
+	f.c.mu.Unlock()
+	return fi, err
+}
+
+// cacheable reports whether a DirCache remembers the error err of reading a
+// path, because trying again would fail the same way.
+func cacheable(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission)
+}
+
+// cachedError is an error remembered by a DirCache, returned in place of
+// trying again.
+type cachedError struct {
+	err error
+}
+
+func (e cachedError) Error() string { return e.err.Error() }
+func (e cachedError) Unwrap() error { return e.err }
+
+// cachedDir is a file opened by a cachedFS. If it is a directory, it is read
+// in full by the first call to ReadDir, and its entries are cached. A
+// directory read from the cache has no underlying file.
+type cachedDir struct {
+	fs.File
+	fsys    cachedFS
+	name    string
+	entries []fs.DirEntry // read but not yet returned
+	read    bool
+}
+
+func (d *cachedDir) Stat() (fs.FileInfo, error) {
+	if d.File == nil {
+		return d.fsys.Stat(d.name)
+	}
+	return d.File.Stat()
+}
+
+func (d *cachedDir) Read(b []byte) (int, error) {
+	if d.File == nil {
+		return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+	}
+	return d.File.Read(b)
+}
+
+func (d *cachedDir) Close() error {
+	if d.File == nil {
+		return nil
+	}
+	return d.File.Close()
+}
+
+func (d *cachedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		f, ok := d.File.(fs.ReadDirFile)
+		if !ok {
+			return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: fs.ErrInvalid}
+		}
+		entries, err := f.ReadDir(-1)
+		if err != nil {
+			return nil, err
+		}
+		d.read = true
+		d.entries = entries
+		d.fsys.c.mu.Lock()
+		d.fsys.c.entries[d.name] = entries
+		d.fsys.c.mu.Unlock()
+	}
+	if n > 0 && len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n <= 0 || n > len(d.entries) {
+		n = len(d.entries)
+	}
+	// The listing is shared with every other reader of the cache, and the
+	// caller may reorder what it is given, as WithSortedOrder does.
+	entries := append([]fs.DirEntry(nil), d.entries[:n]...)
+	d.entries = d.entries[n:]
+	return entries, nil
+}
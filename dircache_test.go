@@ -0,0 +1,208 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobDirCache(t *testing.T) {
+	r := &slowRemote{fsys: fstest.MapFS{
+		"src/a/a.go":      {},
+		"src/a/a_test.go": {},
+		"src/b/b.go":      {},
+		"docs/README":     {},
+	}}
+	gb := NewGlobber(WithRemoteFS(r), WithGlobstar(), WithDirCache(NewDirCache()))
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "src/**/*.go", want: []string{"src/a/a.go", "src/a/a_test.go", "src/b/b.go"}},
+		{pattern: "src/**/*_test.go", want: []string{"src/a/a_test.go"}},
+		{pattern: "src/*/*.go", want: []string{"src/a/a.go", "src/a/a_test.go", "src/b/b.go"}},
+		{pattern: "*/a", want: []string{"src/a"}},
+	}
+	for i, tt := range tests {
+		matches, err := gb.Glob(context.Background(), tt.pattern)
+		if err != nil {
+			t.Fatalf("Glob(%q) error: %s", tt.pattern, err)
+		}
+		if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+		// The first pattern reads src and its subdirectories; the others
+		// read them from the cache, and the last adds the root and docs.
+		want := 3
+		if i == len(tests)-1 {
+			want = 5
+		}
+		if r.all != want {
+			t.Errorf("After Glob(%q), %d directories read, want %d", tt.pattern, r.all, want)
+		}
+	}
+
+	// The same Globber without a cache reads the tree again, as does one
+	// whose cache is reset.
+	gb.With(WithDirCache(nil)).Glob(context.Background(), "src/*/*.go")
+	if r.all != 8 {
+		t.Errorf("After Glob without a cache, %d directories read, want 8", r.all)
+	}
+	c := NewDirCache()
+	gb = gb.With(WithDirCache(c))
+	gb.Glob(context.Background(), "src/*/*.go")
+	c.Reset()
+	gb.Glob(context.Background(), "src/*/*.go")
+	if r.all != 14 {
+		t.Errorf("After Reset, %d directories read, want 14", r.all)
+	}
+}
+
+// countingDenied is a deniedRemote that counts the stats of each path.
+type countingDenied struct {
+	deniedRemote
+	mu    sync.Mutex
+	stats map[string]int
+}
+
+func (r *countingDenied) Stat(name string) (fs.FileInfo, error) {
+	r.mu.Lock()
+	r.stats[name]++
+	r.mu.Unlock()
+	return r.deniedRemote.Stat(name)
+}
+
+func TestGlobDirCacheErrors(t *testing.T) {
+	r := &countingDenied{
+		deniedRemote: deniedRemote{
+			slowRemote: slowRemote{fsys: fstest.MapFS{
+				"public/x": {},
+				"secret/x": {},
+			}},
+			denied: "secret",
+		},
+		stats: make(map[string]int),
+	}
+	var logged []string
+	debug := func(o *options) {
+		o.debug = func(msg string, args ...any) {
+			if msg == "glob: skipping path that can't be stat'ed" {
+				logged = append(logged, fmt.Sprint(args...))
+			}
+		}
+	}
+	gb := NewGlobber(WithRemoteFS(r), WithDirCache(NewDirCache()), debug)
+	for i := 0; i < 3; i++ {
+		for _, pattern := range []string{"*/x", "missing/*"} {
+			s := gb.Stream(pattern)
+			var matches []string
+			for {
+				m, err := s.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m == "" {
+					break
+				}
+				matches = append(matches, m)
+			}
+			if pattern != "*/x" {
+				continue
+			}
+			if diff := cmp.Diff([]string{"public/x"}, matches); diff != "" {
+				t.Errorf("Bad results from Stream(%q), -want +got: %v", pattern, diff)
+			}
+			// Every Stream counts the directory it skips.
+			if got := s.Stats().Errors; got != 1 {
+				t.Errorf("Stream(%q) counted %d errors, want 1", pattern, got)
+			}
+		}
+	}
+	// The denied and missing directories are only tried once, and the
+	// failure logged once.
+	if r.stats["secret"] != 1 || r.stats["missing"] != 1 {
+		t.Errorf("Stats of the failing paths: %v, want one each of secret and missing", r.stats)
+	}
+	if len(logged) != 1 {
+		t.Errorf("Logged %q, want one skipped path", logged)
+	}
+}
+
+// reversedFS is a MapFS that lists directories in reverse order, as a
+// filesystem that doesn't sort its listings might.
+type reversedFS struct {
+	fstest.MapFS
+}
+
+func (r reversedFS) Open(name string) (fs.File, error) {
+	f, err := r.MapFS.Open(name)
+	if d, ok := f.(fs.ReadDirFile); ok && err == nil {
+		return reversedDir{d}, nil
+	}
+	return f, err
+}
+
+type reversedDir struct {
+	fs.ReadDirFile
+}
+
+func (d reversedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := d.ReadDirFile.ReadDir(n)
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, err
+}
+
+func TestGlobDirCacheConcurrentSorted(t *testing.T) {
+	fsys := fstest.MapFS{}
+	var want []string
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("d/f%02d", i)
+		fsys[name] = &fstest.MapFile{}
+		want = append(want, filepath.FromSlash(name))
+	}
+	gb := NewGlobber(WithFS(reversedFS{fsys}), WithDirCache(NewDirCache()))
+	if _, err := gb.Glob(context.Background(), "d/*"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Streams sorting the cached listing don't reorder it under others.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		sorted := i%2 == 0
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts := []Option{}
+			if sorted {
+				opts = append(opts, WithSortedOrder())
+			}
+			matches, err := gb.Glob(context.Background(), "d/*", opts...)
+			if err != nil {
+				t.Errorf("Glob(%q) error: %s", "d/*", err)
+				return
+			}
+			if !sorted {
+				sort.Strings(matches)
+			}
+			if diff := cmp.Diff(want, matches); diff != "" {
+				t.Errorf("Bad results from Glob(%q), sorted %v, -want +got: %v", "d/*", sorted, diff)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// splitDirOnly strips the trailing separators of pattern, reporting whether
+// it had any: a pattern such as `build/*/` that ends in a separator matches
+// only directories, and symbolic links to them, as it does in shells. A
+// pattern that is only a root, such as "/", is left as it is.
+func splitDirOnly(pattern string) (string, bool) {
+	i := len(pattern)
+	for i > 0 && os.IsPathSeparator(pattern[i-1]) {
+		i--
+	}
+	if i == len(pattern) || i <= len(filepath.VolumeName(pattern)) {
+		return pattern, false
+	}
+	return pattern[:i], true
+}
+
+// keepDir reports whether the match m of a pattern ending in a separator is a
+// directory, or a symbolic link to one.
+func (w *walker) keepDir(m found) (found, bool) {
+	if m.d != nil && m.d.IsDir() {
+		return m, true
+	}
+	if m.d != nil && m.d.Type()&os.ModeSymlink == 0 {
+		return m, false
+	}
+	fi, err := w.fsys.Stat(m.path)
+	return m, err == nil && fi.IsDir()
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestGlobDirOnly(t *testing.T) {
+	fsys := fstest.MapFS{
+		"build/a/x.o": {},
+		"build/b/y.o": {},
+		"build/c.o":   {},
+		"src/d/e.go":  {},
+	}
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"build/*/", []string{"build/a/", "build/b/"}},
+		{"build/*//", []string{"build/a/", "build/b/"}},
+		{"build/c.o/", []string{}},
+		{"build/a/", []string{"build/a/"}},
+		{"*/", []string{"build/", "src/"}},
+		{"**/", []string{"build/", "build/a/", "build/b/", "src/", "src/d/"}},
+		{"{build,src}/*/", []string{"build/a/", "build/b/", "src/d/"}},
+	} {
+		got, err := Glob(context.Background(), tt.pattern, WithFS(fsys), WithGlobstar(), WithBraceExpansion())
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		var want []string
+		for _, p := range tt.want {
+			want = append(want, filepath.FromSlash(p))
+		}
+		if diff := cmp.Diff(want, got, sortStringSlices, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobDirOnlySymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+	dir, err := ioutil.TempDir("", "glob-dironly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, d := range []string{"real", "other"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"dirlink": "real", "filelink": "file", "broken": "missing"} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Glob(context.Background(), "*/", WithRoot(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"dirlink/", "other/", "real/"}
+	if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", "*/", diff)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// ErrClosed is returned by Next for a Stream that has been closed, so that
+// matches cut short by Close aren't mistaken for all of them.
+var ErrClosed = errors.New("glob: Stream closed")
+
+// LimitError is returned when a Stream stops because traversal reached a limit
+// set by an Option.
+type LimitError struct {
+	// Limit is the name of the Option that set the limit, for example
+	// "WithMaxVisitedDirs".
+	Limit string
+	// Max is the value of the limit.
+	Max int
+	// Path is the directory at which the limit was reached.
+	Path string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("glob: %s(%d) exceeded at %s", e.Limit, e.Max, e.Path)
+}
+
+// PatternError describes a malformed pattern. It wraps filepath.ErrBadPattern,
+// so errors.Is(err, filepath.ErrBadPattern) holds for it.
+type PatternError struct {
+	// Pattern is the pattern as given.
+	Pattern string
+	// Offset is the byte offset in Pattern of the offending construct.
+	Offset int
+	// Construct is the offending part of Pattern, starting at Offset.
+	Construct string
+	// Msg says what is wrong with Construct.
+	Msg string
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("glob: bad pattern %q: %s at offset %d: %q", e.Pattern, e.Msg, e.Offset, e.Construct)
+}
+
+func (e *PatternError) Unwrap() error {
+	return filepath.ErrBadPattern
+}
+
+// badPattern returns the *PatternError for the pattern, or path element of
+// one, that filepath.Match rejected. Stream validates its pattern before
+// matching anything, so this is only needed for what an expansion, such as
+// that of an environment variable, put into it.
+func badPattern(pattern string) error {
+	if err := validate(pattern, false); err != nil {
+		return err
+	}
+	return &PatternError{Pattern: pattern, Construct: pattern, Msg: "syntax error"}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Escape returns a pattern that matches exactly the path s, by quoting the
+// characters of s that have a special meaning in patterns, including the
+// braces and commas of WithBraceExpansion. It allows arbitrary paths, such as
+// a root directory provided by a user, to be embedded in a larger pattern:
+//
+//	pattern := filepath.Join(glob.Escape(root), "**", "*.go")
+//
+// On Windows, where the backslash separates path elements rather than quoting
+// the next character, metacharacters are quoted by enclosing them in a
+// character class: Escape(`C:\a*b`) is `C:\a[*]b`. Elsewhere they are preceded
+// by a backslash: Escape("/a*b") is `/a\*b`.
+//
+// A volume name, such as the `\\?\C:` of an extended-length path, is never
+// matched against and is left as it is.
+func Escape(s string) string {
+	vol := filepath.VolumeName(s)
+	if !strings.ContainsAny(s[len(vol):], escapedChars) {
+		return s
+	}
+	var b strings.Builder
+	b.WriteString(vol)
+	escapeTo(&b, s[len(vol):])
+	return b.String()
+}
+
+// escapedChars are the characters that Escape quotes.
+const escapedChars = `*?[\{},`
+
+// escapeTo writes s to b, quoting its metacharacters as Escape does, without
+// regard for any volume name.
+func escapeTo(b *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case strings.IndexByte(escapedChars, c) < 0:
+			b.WriteByte(c)
+		case runtime.GOOS != "windows":
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\\':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('[')
+			b.WriteByte(c)
+			b.WriteByte(']')
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"plain/path.txt", "plain/path.txt"},
+		{"a*b", `a\*b`},
+		{"what?", `what\?`},
+		{"[draft].txt", `\[draft].txt`},
+		{`back\slash`, `back\\slash`},
+		{"/tmp/{a,b}", `/tmp/\{a\,b\}`},
+	}
+	if runtime.GOOS == "windows" {
+		tests = []struct{ path, want string }{
+			{`C:\plain\path.txt`, `C:\plain\path.txt`},
+			{`C:\a*b`, `C:\a[*]b`},
+			{`what?`, `what[?]`},
+			{`[draft].txt`, `[[]draft].txt`},
+			{`\\?\C:\a*b`, `\\?\C:\a[*]b`},
+			{`\\?\C:\plain`, `\\?\C:\plain`},
+			{`C:\{a,b}`, `C:\[{]a[,]b[}]`},
+		}
+	}
+	for _, tt := range tests {
+		if got := Escape(tt.path); got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGlobEscaped(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobEscaped[*]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	names := []string{"a*b", "aXb", "q?", "qq", "[x]", "x"}
+	if runtime.GOOS == "windows" {
+		// '*' and '?' are not allowed in file names on Windows.
+		names = []string{"[x]", "x"}
+	}
+	for _, name := range names {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range names {
+		path := filepath.Join(tmpDir, name)
+		pattern := Escape(path)
+		matches, err := Glob(context.Background(), pattern)
+		if err != nil {
+			t.Errorf("Glob error for %q: %s", pattern, err)
+			continue
+		}
+		if diff := cmp.Diff([]string{path}, matches); diff != "" {
+			t.Errorf("Bad results from Glob(Escape(%q)), -want +got: %v", path, diff)
+		}
+	}
+
+	pattern := filepath.Join(Escape(tmpDir), "*")
+	matches, err := Glob(context.Background(), pattern)
+	if err != nil {
+		t.Fatalf("Glob error for %q: %s", pattern, err)
+	}
+	if len(matches) != len(names) {
+		t.Errorf("Glob(%#q) returned %d matches, want %d", pattern, len(matches), len(names))
+	}
+}
+
+func TestGlobEscapedBraces(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobEscapedBraces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	root := filepath.Join(tmpDir, "{a,b}")
+	for _, dir := range []string{root, filepath.Join(tmpDir, "a"), filepath.Join(root, "c")} {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{filepath.Join(root, "c", "x.go"), filepath.Join(tmpDir, "a", "y.go")} {
+		if err := ioutil.WriteFile(f, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The braces of an escaped root are not expanded.
+	pattern := filepath.Join(Escape(root), "**", "*.go")
+	for _, opts := range [][]Option{
+		{WithGlobstar(), WithBraceExpansion()},
+		{WithMinimatch()},
+	} {
+		matches, err := Glob(context.Background(), pattern, opts...)
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", pattern, err)
+			continue
+		}
+		if diff := cmp.Diff([]string{filepath.Join(root, "c", "x.go")}, matches); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", pattern, diff)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// expandTilde replaces a leading "~" or "~user" path element of pattern with
+// the home directory of the current or named user.
+func expandTilde(pattern string) (string, error) {
+	if !strings.HasPrefix(pattern, "~") {
+		return pattern, nil
+	}
+	end := 1
+	for end < len(pattern) && !os.IsPathSeparator(pattern[end]) {
+		end++
+	}
+
+	var home string
+	if name := pattern[1:end]; name == "" {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("glob: cannot expand ~: %w", err)
+		}
+		home = h
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("glob: cannot expand ~%s: %w", name, err)
+		}
+		home = u.HomeDir
+	}
+	return Escape(home) + pattern[end:], nil
+}
+
+// expandEnv replaces references to environment variables in pattern, written
+// $NAME or ${NAME}, with their values. "$$" stands for a literal dollar sign,
+// as does a dollar sign that does not start a reference.
+func expandEnv(pattern string) (string, error) {
+	if !strings.Contains(pattern, "$") {
+		return pattern, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '$' || i+1 == len(pattern) {
+			b.WriteByte(pattern[i])
+			continue
+		}
+
+		var name string
+		switch c := pattern[i+1]; {
+		case c == '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case c == '{':
+			end := strings.IndexByte(pattern[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("glob: unterminated ${ in pattern %q", pattern)
+			}
+			name = pattern[i+2 : i+2+end]
+			if !isEnvName(name) {
+				return "", fmt.Errorf("glob: bad environment variable name %q in pattern %q", name, pattern)
+			}
+			i += 2 + end
+		case isEnvNameStart(c):
+			end := i + 2
+			for end < len(pattern) && isEnvNameChar(pattern[end]) {
+				end++
+			}
+			name = pattern[i+1 : end]
+			i = end - 1
+		default:
+			b.WriteByte('$')
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("glob: environment variable %s in pattern %q is not set", name, pattern)
+		}
+		b.WriteString(Escape(value))
+	}
+	return b.String(), nil
+}
+
+func isEnvName(s string) bool {
+	if s == "" || !isEnvNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isEnvNameChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isEnvNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isEnvNameChar(c byte) bool {
+	return isEnvNameStart(c) || '0' <= c && c <= '9'
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandTilde(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+	for _, tt := range []struct{ pattern, want string }{
+		{"~", Escape(home)},
+		{"~/*.txt", Escape(home) + "/*.txt"},
+		{"a/~/b", "a/~/b"},
+		{"~~", ""},
+	} {
+		got, err := expandTilde(filepath.FromSlash(tt.pattern))
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("expandTilde(%q) = %q, want error", tt.pattern, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandTilde(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if want := filepath.FromSlash(tt.want); got != want {
+			t.Errorf("expandTilde(%q) = %q, want %q", tt.pattern, got, want)
+		}
+	}
+
+	u, err := user.Current()
+	if err != nil {
+		t.Skipf("no current user: %v", err)
+	}
+	got, err := expandTilde("~" + u.Username)
+	if err != nil {
+		t.Fatalf("expandTilde(%q) returned unexpected error: %v", "~"+u.Username, err)
+	}
+	if want := Escape(u.HomeDir); got != want {
+		t.Errorf("expandTilde(%q) = %q, want %q", "~"+u.Username, got, want)
+	}
+}
+
+func TestGlobTildeExpansion(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobTildeExpansion[home]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "notes.txt"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("USERPROFILE", tmpDir)
+
+	pattern := filepath.FromSlash("~/*.txt")
+	matches, err := Glob(context.Background(), pattern, WithTildeExpansion())
+	if err != nil {
+		t.Fatalf("Glob error for %q: %s", pattern, err)
+	}
+	want := []string{filepath.Join(tmpDir, "notes.txt")}
+	if diff := cmp.Diff(want, matches); diff != "" {
+		t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+	}
+
+	// Without the option, "~" is an ordinary name.
+	matches, err = Glob(context.Background(), pattern)
+	if err != nil {
+		t.Fatalf("Glob error for %q: %s", pattern, err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("Glob(%#q) without WithTildeExpansion() = %v, want none", pattern, matches)
+	}
+
+	if runtime.GOOS != "windows" {
+		_, err = Glob(context.Background(), "~no-such-user-for-glob-tests/*", WithTildeExpansion())
+		var unknown user.UnknownUserError
+		if !errors.As(err, &unknown) {
+			t.Errorf("Glob for an unknown user returned error %v, want a user.UnknownUserError", err)
+		}
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("GLOB_TEST_DIR", "/srv/release-[1]")
+	t.Setenv("GLOB_TEST_EMPTY", "")
+	os.Unsetenv("GLOB_TEST_UNSET")
+
+	for _, tt := range []struct {
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{"plain/*.so", "plain/*.so", false},
+		{"$GLOB_TEST_DIR/**/*.so", Escape("/srv/release-[1]") + "/**/*.so", false},
+		{"${GLOB_TEST_DIR}lib", Escape("/srv/release-[1]") + "lib", false},
+		{"a${GLOB_TEST_EMPTY}b", "ab", false},
+		{"cost$$5", "cost$5", false},
+		{"trailing$", "trailing$", false},
+		{"$1 and $-", "$1 and $-", false},
+		{"$GLOB_TEST_UNSET/x", "", true},
+		{"${GLOB_TEST_DIR", "", true},
+		{"${}", "", true},
+		{"${NOT-A-NAME}", "", true},
+	} {
+		got, err := expandEnv(tt.pattern)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expandEnv(%q) = %q, want error", tt.pattern, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandEnv(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestGlobEnvExpansion(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobEnvExpansion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "lib.so"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GLOB_TEST_RELEASE_DIR", tmpDir)
+
+	pattern := filepath.FromSlash("$GLOB_TEST_RELEASE_DIR/*.so")
+	matches, err := Glob(context.Background(), pattern, WithEnvExpansion())
+	if err != nil {
+		t.Fatalf("Glob error for %q: %s", pattern, err)
+	}
+	want := []string{filepath.Join(tmpDir, "lib.so")}
+	if diff := cmp.Diff(want, matches); diff != "" {
+		t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+	}
+
+	if _, err := Glob(context.Background(), "$GLOB_TEST_UNSET/*", WithEnvExpansion()); err == nil {
+		t.Error("expected error for unset variable; got none")
+	}
+}
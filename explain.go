@@ -0,0 +1,138 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Explanation says how a pattern matches a path, or where it fails to, as
+// returned by Explain.
+type Explanation struct {
+	// Matched reports whether the pattern matches the path.
+	Matched bool
+	// Steps pair elements of the pattern with the elements of the path they
+	// match, in order. If the pattern doesn't match, the last step is the
+	// one that fails, along the attempt that got furthest.
+	Steps []Step
+}
+
+// Step is a step of an Explanation.
+type Step struct {
+	// Pattern is an element of the pattern, or empty if the path goes on
+	// past the end of the pattern.
+	Pattern string
+	// Path is the element of the path that Pattern is matched against, or
+	// the elements, joined by separators, that a "**" matches (see
+	// WithGlobstar). It is empty if a "**" matches no elements or if the
+	// path ends before the pattern does.
+	Path string
+	// Matched reports whether Pattern matches Path.
+	Matched bool
+}
+
+// String returns the explanation as text, one step per line, ending with
+// whether the pattern matches.
+func (e Explanation) String() string {
+	var b strings.Builder
+	for _, s := range e.Steps {
+		switch {
+		case s.Matched && s.Path == "":
+			fmt.Fprintf(&b, "%q matches no elements\n", s.Pattern)
+		case s.Matched:
+			fmt.Fprintf(&b, "%q matches %q\n", s.Pattern, s.Path)
+		case s.Pattern == "":
+			fmt.Fprintf(&b, "the pattern ends before %q\n", s.Path)
+		case s.Path == "":
+			fmt.Fprintf(&b, "the path ends before %q\n", s.Pattern)
+		default:
+			fmt.Fprintf(&b, "%q does not match %q\n", s.Pattern, s.Path)
+		}
+	}
+	if e.Matched {
+		b.WriteString("the pattern matches\n")
+	} else {
+		b.WriteString("the pattern does not match\n")
+	}
+	return b.String()
+}
+
+// Explain reports how pattern matches the path name, element by element, as
+// Stream would match it if name existed, or at which element it fails to. It
+// doesn't touch the filesystem, so it doesn't account for options that skip
+// files, such as WithGitignore or WithFilter; of the options, only
+// WithGlobstar, WithInlineFlags and WithPOSIXClasses affect it. name must be
+// relative if pattern is, and absolute if pattern is. Explain returns a
+// *PatternError if pattern is malformed.
+func Explain(pattern, name string, opts ...Option) (Explanation, error) {
+	pattern = filepath.FromSlash(pattern)
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := validate(pattern, o.posixClasses); err != nil {
+		return Explanation{}, err
+	}
+	pattern, err := expandSyntax(pattern, &o)
+	if err != nil {
+		return Explanation{}, err
+	}
+	if o.globstar {
+		pattern = collapseGlobstars(pattern)
+	}
+	sep := string(filepath.Separator)
+	x := explainer{globstar: o.globstar}
+	ok := x.match(strings.Split(pattern, sep), strings.Split(filepath.Clean(filepath.FromSlash(name)), sep), nil)
+	return Explanation{Matched: ok, Steps: x.best}, nil
+}
+
+// explainer is matchPathElems, keeping track of the steps taken.
+type explainer struct {
+	globstar bool
+	// best is the successful steps, once there are some, or the failed
+	// steps that got furthest so far.
+	best []Step
+}
+
+func (x *explainer) match(pattern, elems []string, steps []Step) bool {
+	for len(pattern) > 0 {
+		if depth, ok := globstarDepth(pattern[0]); ok && x.globstar {
+			for i := 0; i <= len(elems) && (depth == 0 || i <= depth); i++ {
+				step := Step{Pattern: pattern[0], Path: strings.Join(elems[:i], string(filepath.Separator)), Matched: true}
+				if x.match(pattern[1:], elems[i:], append(steps[:len(steps):len(steps)], step)) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			x.fail(append(steps, Step{Pattern: pattern[0]}))
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], elems[0]); !ok {
+			x.fail(append(steps, Step{Pattern: pattern[0], Path: elems[0]}))
+			return false
+		}
+		steps = append(steps, Step{Pattern: pattern[0], Path: elems[0], Matched: true})
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	if len(elems) > 0 {
+		x.fail(append(steps, Step{Path: strings.Join(elems, string(filepath.Separator))}))
+		return false
+	}
+	x.best = append([]Step(nil), steps...)
+	return true
+}
+
+// fail records steps that failed, if they got further than any before.
+func (x *explainer) fail(steps []Step) {
+	if x.best == nil || len(steps) > len(x.best) {
+		x.best = append([]Step(nil), steps...)
+	}
+}
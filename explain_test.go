@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExplain(t *testing.T) {
+	sep := string(filepath.Separator)
+	tests := []struct {
+		pattern, name string
+		opts          []Option
+		want          Explanation
+	}{
+		{
+			pattern: "src/*/*.go", name: "src/app/main.go",
+			want: Explanation{Matched: true, Steps: []Step{
+				{Pattern: "src", Path: "src", Matched: true},
+				{Pattern: "*", Path: "app", Matched: true},
+				{Pattern: "*.go", Path: "main.go", Matched: true},
+			}},
+		},
+		{
+			pattern: "src/*/*.go", name: "src/app/main.c",
+			want: Explanation{Steps: []Step{
+				{Pattern: "src", Path: "src", Matched: true},
+				{Pattern: "*", Path: "app", Matched: true},
+				{Pattern: "*.go", Path: "main.c"},
+			}},
+		},
+		{
+			pattern: "src/*.go", name: "src/app/main.go",
+			want: Explanation{Steps: []Step{
+				{Pattern: "src", Path: "src", Matched: true},
+				{Pattern: "*.go", Path: "app"},
+			}},
+		},
+		{
+			pattern: "src/*", name: "src/app/main.go",
+			want: Explanation{Steps: []Step{
+				{Pattern: "src", Path: "src", Matched: true},
+				{Pattern: "*", Path: "app", Matched: true},
+				{Path: "main.go"},
+			}},
+		},
+		{
+			pattern: "src/*/*", name: "src/app",
+			want: Explanation{Steps: []Step{
+				{Pattern: "src", Path: "src", Matched: true},
+				{Pattern: "*", Path: "app", Matched: true},
+				{Pattern: "*"},
+			}},
+		},
+		{
+			pattern: "**/*_test.go", name: "a/b/c_test.go", opts: []Option{WithGlobstar()},
+			want: Explanation{Matched: true, Steps: []Step{
+				{Pattern: "**", Path: "a" + sep + "b", Matched: true},
+				{Pattern: "*_test.go", Path: "c_test.go", Matched: true},
+			}},
+		},
+		{
+			pattern: "**/pkg/*.go", name: "a/pkg/b/c.go", opts: []Option{WithGlobstar()},
+			want: Explanation{Steps: []Step{
+				{Pattern: "**", Path: "a", Matched: true},
+				{Pattern: "pkg", Path: "pkg", Matched: true},
+				{Pattern: "*.go", Path: "b"},
+			}},
+		},
+		{
+			pattern: "**1/*.go", name: "a/b/c.go", opts: []Option{WithGlobstar()},
+			want: Explanation{Steps: []Step{
+				{Pattern: "**1", Matched: true},
+				{Pattern: "*.go", Path: "a"},
+			}},
+		},
+		{
+			// Without WithGlobstar, "**" is "*".
+			pattern: "**/*.go", name: "a/b/c.go",
+			want: Explanation{Steps: []Step{
+				{Pattern: "**", Path: "a", Matched: true},
+				{Pattern: "*.go", Path: "b"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Explain(tt.pattern, tt.name, tt.opts...)
+		if err != nil {
+			t.Errorf("Explain(%q, %q) error: %s", tt.pattern, tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Bad explanation from Explain(%q, %q), -want +got: %v", tt.pattern, tt.name, diff)
+		}
+		if got.Matched != matchPath(collapseGlobstars(filepath.FromSlash(tt.pattern)), filepath.FromSlash(tt.name), &options{globstar: len(tt.opts) > 0}) {
+			t.Errorf("Explain(%q, %q).Matched = %v, unlike matchPath", tt.pattern, tt.name, got.Matched)
+		}
+	}
+
+	e, err := Explain("src/*.go", "src/app/main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\"src\" matches \"src\"\n\"*.go\" does not match \"app\"\nthe pattern does not match\n"
+	if got := e.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	var pe *PatternError
+	if _, err := Explain("src/[a", "src/a"); !errors.As(err, &pe) {
+		t.Errorf("Explain of a malformed pattern returned %v, want a *PatternError", err)
+	}
+}
@@ -0,0 +1,13 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+// fileID identifies a file: the device, or volume, it is on and its inode, or
+// file index, on that device.
+type fileID struct {
+	dev, ino uint64
+}
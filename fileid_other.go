@@ -0,0 +1,16 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build !unix && !windows
+
+package glob
+
+import "io/fs"
+
+// fileIdentity is not supported on this platform.
+func fileIdentity(p string, fi fs.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build unix
+
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// fileIdentity returns the identity of the file p, using fi, the result of
+// stat'ing p, if it is not nil.
+func fileIdentity(p string, fi fs.FileInfo) (fileID, bool) {
+	if fi == nil {
+		var err error
+		if fi, err = os.Stat(p); err != nil {
+			return fileID{}, false
+		}
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
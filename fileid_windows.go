@@ -0,0 +1,37 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentity returns the identity of the file p. The FileInfo from a Stat on
+// Windows doesn't carry the volume serial number and file index, so fi is not
+// used and p is opened to query them.
+func fileIdentity(p string, fi fs.FileInfo) (fileID, bool) {
+	name, err := syscall.UTF16PtrFromString(extendedLengthPath(p))
+	if err != nil {
+		return fileID{}, false
+	}
+	// FILE_FLAG_BACKUP_SEMANTICS is needed to open directories; no access
+	// rights are needed to query the file's information.
+	h, err := syscall.CreateFile(name, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+	defer syscall.CloseHandle(h)
+	var info syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &info); err != nil {
+		return fileID{}, false
+	}
+	return fileID{
+		dev: uint64(info.VolumeSerialNumber),
+		ino: uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow),
+	}, true
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io/fs"
+	"sync/atomic"
+)
+
+// filtered reports whether the match p is to be dropped by the options that
+// restrict matches by their type or metadata, or by WithFilter. d is the
+// directory entry of p, or nil if it was not read from a directory.
+func (w *walker) filtered(p string, d fs.DirEntry) bool {
+	if w.opts.skipBroken && w.brokenLink(p, d) {
+		return true
+	}
+	if w.opts.filtersByInfo() {
+		if w.opts.filesOnly() && d != nil && d.IsDir() {
+			return true
+		}
+		fi, ok := w.stat(p, d)
+		if !ok || !w.opts.keepInfo(fi) {
+			return true
+		}
+	}
+	if len(w.opts.filters) == 0 {
+		return false
+	}
+	if d == nil {
+		fi, err := w.fsys.Lstat(p)
+		if err != nil {
+			return true
+		}
+		d = fs.FileInfoToDirEntry(fi)
+	}
+	return !w.opts.accepted(p, d)
+}
+
+// filesOnly reports whether the options only let regular files match.
+func (o *options) filesOnly() bool {
+	return o.nonEmptyFiles || o.sizeRange
+}
+
+// filtersByInfo reports whether the options filter matches by their
+// metadata, which keepInfo checks.
+func (o *options) filtersByInfo() bool {
+	return o.filesOnly() || !o.modifiedAfter.IsZero()
+}
+
+// keepInfo reports whether a match with the metadata fi, of its target if it
+// is a symbolic link, passes the options that filter by metadata.
+func (o *options) keepInfo(fi fs.FileInfo) bool {
+	switch {
+	case o.filesOnly() && !fi.Mode().IsRegular():
+		return false
+	case o.nonEmptyFiles && fi.Size() == 0:
+		return false
+	case o.sizeRange && (fi.Size() < o.minSize || fi.Size() > o.maxSize):
+		return false
+	case !o.modifiedAfter.IsZero() && !fi.ModTime().After(o.modifiedAfter):
+		return false
+	}
+	return true
+}
+
+// accepted reports whether the match p, whose directory entry is d, satisfies
+// the filters of WithFilter.
+func (o *options) accepted(p string, d fs.DirEntry) bool {
+	for _, f := range o.filters {
+		if !f(p, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// stat returns the metadata of the match p, following symbolic links, for the
+// options that filter by it. It reports false if p can't be stat'ed, or if d
+// shows that p is a FIFO, socket or device: some FUSE and network filesystems
+// hang when these are stat'ed, so they are only stat'ed with
+// WithStatSpecialFiles.
+//
+// Unless p is a symbolic link, the metadata comes from d where it has it: on
+// Windows, directory listings include sizes and times, so no further system
+// call is needed.
+func (w *walker) stat(p string, d fs.DirEntry) (fs.FileInfo, bool) {
+	if d != nil && d.Type()&(fs.ModeNamedPipe|fs.ModeSocket|fs.ModeDevice|fs.ModeIrregular) != 0 && !w.opts.statSpecial {
+		atomic.AddInt64(&w.special, 1)
+		w.debug("glob: skipping special file", "path", p)
+		return nil, false
+	}
+	if d != nil && d.Type()&fs.ModeSymlink == 0 {
+		fi, err := d.Info()
+		return fi, err == nil
+	}
+	fi, err := w.fsys.Stat(p)
+	return fi, err == nil
+}
+
+// brokenLink reports whether p, whose directory entry is d if known, is a
+// symbolic link whose target can't be reached.
+func (w *walker) brokenLink(p string, d fs.DirEntry) bool {
+	if d == nil {
+		fi, err := w.fsys.Lstat(p)
+		if err != nil {
+			return false
+		}
+		d = fs.FileInfoToDirEntry(fi)
+	}
+	if d.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+	_, err := w.fsys.Stat(p)
+	return err != nil
+}
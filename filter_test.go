@@ -0,0 +1,302 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobNonEmptyFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobNonEmptyFiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "dir", "sub"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"full":          "data",
+		"empty":         "",
+		"dir/full":      "data",
+		"dir/empty":     "",
+		"dir/sub/full":  "data",
+		"dir/sub/empty": "",
+	}
+	for f, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, filepath.FromSlash(f)), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantLink := runtime.GOOS != "windows"
+	if wantLink {
+		if err := os.Symlink("full", filepath.Join(tmpDir, "link")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("empty", filepath.Join(tmpDir, "emptylink")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "*", want: []string{"full", "link"}},
+		{pattern: "full", want: []string{"full"}},
+		{pattern: "empty", want: nil},
+		{pattern: "dir", want: nil},
+		{pattern: "*/*", want: []string{"dir/full"}},
+		{pattern: "**", opts: []Option{WithGlobstar()}, want: []string{"full", "link", "dir/full", "dir/sub/full"}},
+		{pattern: "dir/**", opts: []Option{WithGlobstar()}, want: []string{"dir/full", "dir/sub/full"}},
+		{pattern: "**/full", opts: []Option{WithGlobstar()}, want: []string{"full", "dir/full", "dir/sub/full"}},
+	}
+	for _, tt := range tests {
+		want := []string{}
+		for _, w := range tt.want {
+			if w == "link" && !wantLink {
+				continue
+			}
+			want = append(want, filepath.Join(tmpDir, filepath.FromSlash(w)))
+		}
+		pattern := filepath.Join(Escape(tmpDir), tt.pattern)
+		matches, err := Glob(context.Background(), pattern, append(tt.opts, WithNonEmptyFiles())...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithNonEmptyFiles, -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobSkipBrokenSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("skipping symlink test on Windows")
+	}
+	tmpDir, err := ioutil.TempDir("", "TestGlobSkipBrokenSymlinks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "file"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"good": "file", "broken": "missing", "loop": "loop"} {
+		if err := os.Symlink(target, filepath.Join(tmpDir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		// Broken links are matched by default.
+		{pattern: "*", want: []string{"broken", "file", "good", "loop"}},
+		{pattern: "broken", want: []string{"broken"}},
+		{pattern: "*", opts: []Option{WithSkipBrokenSymlinks()}, want: []string{"file", "good"}},
+		{pattern: "broken", opts: []Option{WithSkipBrokenSymlinks()}, want: []string{}},
+		{pattern: "**", opts: []Option{WithGlobstar(), WithSkipBrokenSymlinks()}, want: []string{"", "file", "good"}},
+	}
+	for _, tt := range tests {
+		want := []string{}
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, w))
+		}
+		pattern := filepath.Join(Escape(tmpDir), tt.pattern)
+		matches, err := Glob(context.Background(), pattern, tt.opts...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobModifiedAfter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobModifiedAfter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	since := time.Now().Add(-time.Hour)
+	if err := os.MkdirAll(filepath.Join(tmpDir, "dir"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	for f, mtime := range map[string]time.Time{
+		"old":     since.Add(-time.Hour),
+		"new":     since.Add(time.Minute),
+		"dir/old": since,
+		"dir/new": since.Add(time.Minute),
+	} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(f))
+		if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Creating the files above updated the directory's own time.
+	if err := os.Chtimes(filepath.Join(tmpDir, "dir"), since, since); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "*", opts: []Option{WithModifiedAfter(since)}, want: []string{"new"}},
+		{pattern: "old", opts: []Option{WithModifiedAfter(since)}, want: []string{}},
+		{pattern: "*/*", opts: []Option{WithModifiedAfter(since)}, want: []string{"dir/new"}},
+		{pattern: "**", opts: []Option{WithGlobstar(), WithModifiedAfter(since)}, want: []string{"", "new", "dir/new"}},
+		{pattern: "*", opts: []Option{WithModifiedAfter(time.Time{})}, want: []string{"dir", "new", "old"}},
+	}
+	for _, tt := range tests {
+		want := []string{}
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, filepath.FromSlash(w)))
+		}
+		pattern := filepath.Join(Escape(tmpDir), tt.pattern)
+		matches, err := Glob(context.Background(), pattern, tt.opts...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobSize(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobSize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "logs", "old"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	for f, size := range map[string]int{
+		"logs/empty.log":   0,
+		"logs/small.log":   10,
+		"logs/medium.log":  100,
+		"logs/old/big.log": 1000,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, filepath.FromSlash(f)), make([]byte, size), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		opts []Option
+		want []string
+	}{
+		{opts: []Option{WithMinSize(1)}, want: []string{"logs/small.log", "logs/medium.log", "logs/old/big.log"}},
+		{opts: []Option{WithMinSize(100)}, want: []string{"logs/medium.log", "logs/old/big.log"}},
+		{opts: []Option{WithMaxSize(0)}, want: []string{"logs/empty.log"}},
+		{opts: []Option{WithMaxSize(100)}, want: []string{"logs/empty.log", "logs/small.log", "logs/medium.log"}},
+		{opts: []Option{WithMinSize(10), WithMaxSize(100)}, want: []string{"logs/small.log", "logs/medium.log"}},
+		{opts: []Option{WithMaxSize(100), WithMinSize(10)}, want: []string{"logs/small.log", "logs/medium.log"}},
+		{opts: []Option{WithMinSize(11), WithMaxSize(99)}, want: []string{}},
+	}
+	for _, tt := range tests {
+		want := []string{}
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, filepath.FromSlash(w)))
+		}
+		// The directories are dropped too, although "**" matches them.
+		pattern := filepath.Join(Escape(tmpDir), "logs", "**")
+		matches, err := Glob(context.Background(), pattern, append(tt.opts, WithGlobstar())...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with %d size options, -want +got: %v", pattern, len(tt.opts), diff)
+		}
+	}
+}
+
+func TestGlobFilter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobFilter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "src.go"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	for f, data := range map[string]string{
+		"a.go":        "package a",
+		"b.go":        "",
+		"c.txt":       "text",
+		"src.go/d.go": "package d",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, filepath.FromSlash(f)), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goFiles := WithFilter(func(p string, d fs.DirEntry) bool {
+		return !d.IsDir() && strings.HasSuffix(d.Name(), ".go") && filepath.Base(p) == d.Name()
+	})
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "*", opts: []Option{goFiles}, want: []string{"a.go", "b.go"}},
+		{pattern: "a.go", opts: []Option{goFiles}, want: []string{"a.go"}},
+		{pattern: "c.txt", opts: []Option{goFiles}, want: []string{}},
+		{pattern: "**", opts: []Option{WithGlobstar(), goFiles}, want: []string{"a.go", "b.go", "src.go/d.go"}},
+		{pattern: "*", opts: []Option{goFiles, WithNonEmptyFiles()}, want: []string{"a.go"}},
+		{
+			pattern: "*",
+			opts:    []Option{goFiles, WithFilter(func(p string, d fs.DirEntry) bool { return d.Name() != "a.go" })},
+			want:    []string{"b.go"},
+		},
+	}
+	for _, tt := range tests {
+		want := []string{}
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, filepath.FromSlash(w)))
+		}
+		pattern := filepath.Join(Escape(tmpDir), tt.pattern)
+		matches, err := Glob(context.Background(), pattern, tt.opts...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build unix
+
+package glob
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobNonEmptyFilesSkipsFIFOs(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobNonEmptyFilesSkipsFIFOs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := syscall.Mkfifo(filepath.Join(tmpDir, "fifo"), 0666); err != nil {
+		t.Skipf("cannot create FIFO: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "file"), []byte("data"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		opts             []Option
+		wantSpecialFiles int64
+	}{
+		{opts: []Option{WithNonEmptyFiles()}, wantSpecialFiles: 1},
+		{opts: []Option{WithNonEmptyFiles(), WithStatSpecialFiles()}, wantSpecialFiles: 0},
+	} {
+		r := Stream(filepath.Join(Escape(tmpDir), "*"), tt.opts...)
+		var matches []string
+		for {
+			m, err := r.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m == "" {
+				break
+			}
+			matches = append(matches, m)
+		}
+		if diff := cmp.Diff([]string{filepath.Join(tmpDir, "file")}, matches); diff != "" {
+			t.Errorf("Bad results, -want +got: %v", diff)
+		}
+		if got := r.Stats().SpecialFiles; got != tt.wantSpecialFiles {
+			t.Errorf("Stats().SpecialFiles = %d, want %d", got, tt.wantSpecialFiles)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// filesystem is the tree a Stream reads. Names use the operating system's
+// separator, as matches do.
+type filesystem interface {
+	Open(name string) (fs.File, error)
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	// native returns the path by which os finds the file name, or false if
+	// the filesystem isn't the operating system's. It is only used to read
+	// metadata that the methods above can't provide, such as file
+	// identities, never contents.
+	native(name string) (string, bool)
+}
+
+// prefixOpener is implemented by filesystems that can list the entries of a
+// directory whose names begin with prefix more cheaply than all of them.
+type prefixOpener interface {
+	openPrefix(dir, prefix string) (fs.File, error)
+}
+
+// osFS is the operating system's filesystem, with names resolved as os
+// resolves them.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)      { return os.Open(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)  { return os.Stat(name) }
+func (osFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+func (osFS) native(name string) (string, bool)      { return name, true }
+
+// ioFS is an fs.FS, for WithFS.
+type ioFS struct {
+	fsys fs.FS
+}
+
+func (f ioFS) Open(name string) (fs.File, error) {
+	return f.fsys.Open(filepath.ToSlash(name))
+}
+
+func (f ioFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, filepath.ToSlash(name))
+}
+
+// Lstat uses the Lstat method of the fs.FS if it has one. Otherwise the
+// fs.FS is assumed not to have symbolic links, as is the case for embed.FS.
+func (f ioFS) Lstat(name string) (fs.FileInfo, error) {
+	if l, ok := f.fsys.(interface {
+		Lstat(name string) (fs.FileInfo, error)
+	}); ok {
+		return l.Lstat(filepath.ToSlash(name))
+	}
+	return f.Stat(name)
+}
+
+func (f ioFS) native(name string) (string, bool) {
+	return "", false
+}
+
+// readFile returns the contents of the file name of fsys.
+func readFile(fsys filesystem, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// identity returns the identity of the file p, using fi, the result of
+// stat'ing p, if it is not nil.
+func (w *walker) identity(p string, fi fs.FileInfo) (fileID, bool) {
+	np, ok := w.fsys.native(p)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileIdentity(np, fi)
+}
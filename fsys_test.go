@@ -0,0 +1,106 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"embed"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+//go:embed testdata
+var testdataFS embed.FS
+
+func TestGlobFS(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"templates/index.tmpl":         {},
+		"templates/admin/users.tmpl":   {},
+		"templates/admin/users.css":    {},
+		"templates/admin/.gitignore":   {Data: []byte("*.css\n")},
+		"templates/partials/nav.tmpl":  {},
+		"templates/partials/nav.tmpl~": {},
+		"static/app.js":                {},
+	}
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "testdata/*", want: []string{"testdata/a", "testdata/b", "testdata/match", "testdata/other"}},
+		{pattern: "testdata/a/c/**/?", opts: []Option{WithGlobstar()}, want: []string{"testdata/a/c/d", "testdata/a/c/d/e", "testdata/a/c/d/e/f", "testdata/a/c/d/e/f/a", "testdata/a/c/d/e/f/b", "testdata/a/c/d/e/f/c"}},
+		{pattern: "**/match", opts: []Option{WithGlobstar()}, want: []string{"testdata/match"}},
+		{pattern: "testdata/match", want: []string{"testdata/match"}},
+		{pattern: "*/a", opts: []Option{WithRoot("testdata")}, want: []string{"a/a", "b/a"}},
+		{pattern: "./testdata/match", want: []string{}},
+		{pattern: "/testdata", want: []string{}},
+		{pattern: "testdata/nonexistent/*", want: []string{}},
+	}
+	for _, tt := range tests {
+		matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithFS(testdataFS))...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithFS(embed.FS), -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	tests = []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "templates/**/*.tmpl", want: []string{"templates/index.tmpl", "templates/admin/users.tmpl", "templates/partials/nav.tmpl"}},
+		{pattern: "templates/admin/*", opts: []Option{WithGitignore()}, want: []string{"templates/admin/.gitignore", "templates/admin/users.tmpl"}},
+		{pattern: "**/*.js", opts: []Option{WithSortedOrder(), WithConcurrency(4)}, want: []string{"static/app.js"}},
+	}
+	for _, tt := range tests {
+		matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithGlobstar(), WithFS(mapFS))...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithFS(fstest.MapFS), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// The archive has no entries for directories, which zip.Reader infers
+	// from the names of the files.
+	for _, name := range []string{"assets/logo.png", "assets/icons/a.png", "assets/icons/b.svg", "assets/icons/16/c.png", "README"} {
+		if _, err := zw.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := "assets/**/*.png"
+	matches, err := Glob(context.Background(), pattern, WithFS(zr), WithGlobstar(), WithSortedOrder())
+	if err != nil {
+		t.Fatalf("Glob(%q) error: %s", pattern, err)
+	}
+	want := []string{"assets/logo.png", "assets/icons/a.png", "assets/icons/16/c.png"}
+	if diff := cmp.Diff(want, matches); diff != "" {
+		t.Errorf("Bad results from Glob(%q) with WithFS(*zip.Reader), -want +got: %v", pattern, diff)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build ignore
+
+// gen_norm generates norm_tables.go from the Unicode Character Database.
+//
+//	go run gen_norm.go [-ucd DIR]
+//
+// reads UnicodeData.txt and CompositionExclusions.txt from DIR, or downloads
+// them from unicode.org if DIR is not given.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const version = "14.0.0"
+
+var ucd = flag.String("ucd", "", "directory holding the Unicode Character Database files")
+
+func main() {
+	flag.Parse()
+
+	type decomposition struct {
+		r     rune
+		parts []rune
+	}
+	var decomps []decomposition
+	ccc := make(map[rune]int)
+	err := readLines("UnicodeData.txt", func(fields []string) {
+		r := parseRune(fields[0])
+		if c, _ := strconv.Atoi(fields[3]); c != 0 {
+			ccc[r] = c
+		}
+		d := fields[5]
+		if d == "" || strings.HasPrefix(d, "<") {
+			return // No decomposition, or a compatibility one.
+		}
+		var parts []rune
+		for _, p := range strings.Fields(d) {
+			parts = append(parts, parseRune(p))
+		}
+		decomps = append(decomps, decomposition{r, parts})
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var exclusions []rune
+	if err := readLines("CompositionExclusions.txt", func(fields []string) {
+		exclusions = append(exclusions, parseRune(fields[0]))
+	}); err != nil {
+		log.Fatal(err)
+	}
+	sort.Slice(exclusions, func(i, j int) bool { return exclusions[i] < exclusions[j] })
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen_norm.go from Unicode %s; DO NOT EDIT.\n\n", version)
+	fmt.Fprintf(&b, "package glob\n\n")
+	fmt.Fprintf(&b, "// canonicalDecompositions maps code points to their canonical decompositions,\n")
+	fmt.Fprintf(&b, "// of one or two code points (a zero second code point means one).\n")
+	fmt.Fprintf(&b, "// Hangul syllables are decomposed algorithmically instead.\n")
+	fmt.Fprintf(&b, "var canonicalDecompositions = map[rune][2]rune{\n")
+	for _, d := range decomps {
+		second := rune(0)
+		if len(d.parts) > 1 {
+			second = d.parts[1]
+		}
+		fmt.Fprintf(&b, "\t%#04x: {%#04x, %#04x},\n", d.r, d.parts[0], second)
+	}
+	fmt.Fprintf(&b, "}\n\n")
+
+	var runes []rune
+	for r := range ccc {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	fmt.Fprintf(&b, "// combiningClasses lists, in order, the ranges of code points that have the\n")
+	fmt.Fprintf(&b, "// same nonzero canonical combining class.\n")
+	fmt.Fprintf(&b, "var combiningClasses = []combiningClassRange{\n")
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j] == runes[j-1]+1 && ccc[runes[j]] == ccc[runes[i]] {
+			j++
+		}
+		fmt.Fprintf(&b, "\t{%#04x, %#04x, %d},\n", runes[i], runes[j-1], ccc[runes[i]])
+		i = j
+	}
+	fmt.Fprintf(&b, "}\n\n")
+
+	fmt.Fprintf(&b, "// compositionExclusions lists the code points that CompositionExclusions.txt\n")
+	fmt.Fprintf(&b, "// excludes from composition. Singletons and non-starter decompositions, which\n")
+	fmt.Fprintf(&b, "// are excluded too, are recognized from canonicalDecompositions instead.\n")
+	fmt.Fprintf(&b, "var compositionExclusions = map[rune]bool{\n")
+	for _, r := range exclusions {
+		fmt.Fprintf(&b, "\t%#04x: true,\n", r)
+	}
+	fmt.Fprintf(&b, "}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("norm_tables.go", src, 0666); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readLines calls f with the semicolon-separated fields of each line of the
+// named UCD file that isn't blank or a comment.
+func readLines(name string, f func(fields []string)) error {
+	var r io.Reader
+	if *ucd != "" {
+		file, err := os.Open(filepath.Join(*ucd, name))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	} else {
+		resp, err := http.Get("https://www.unicode.org/Public/" + version + "/ucd/" + name)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("fetching %s: %s", name, resp.Status)
+		}
+		r = resp.Body
+	}
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		f(fields)
+	}
+	return s.Err()
+}
+
+func parseRune(s string) rune {
+	r, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rune(r)
+}
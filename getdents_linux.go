@@ -0,0 +1,144 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const getdentsSupported = true
+
+// direntBufs holds the buffers that getdentsReaders read into, which are
+// large enough that a huge directory takes few system calls to read.
+var direntBufs = sync.Pool{New: func() any { b := make([]byte, 128<<10); return &b }}
+
+// The offsets of the fields of a struct linux_dirent64.
+const (
+	direntInoOff    = unsafe.Offsetof(syscall.Dirent{}.Ino)
+	direntReclenOff = unsafe.Offsetof(syscall.Dirent{}.Reclen)
+	direntTypeOff   = unsafe.Offsetof(syscall.Dirent{}.Type)
+	direntNameOff   = int(unsafe.Offsetof(syscall.Dirent{}.Name))
+)
+
+// direntSlab is the number of entries a getdentsReader allocates at a time.
+const direntSlab = 64
+
+// getdentsReader reads the entries of a directory of the operating system's
+// filesystem with getdents64, a buffer at a time, without the per-entry
+// bookkeeping of os.File.ReadDir.
+type getdentsReader struct {
+	dir string
+	f   *os.File
+	rc  syscall.RawConn
+	buf *[]byte
+	b   []byte // records read but not yet returned
+	// slab holds the entries yet to be returned, allocated together so
+	// that returning an entry doesn't take an allocation of its own.
+	slab []dirent
+}
+
+func newGetdentsReader(dir string, f *os.File) (*getdentsReader, error) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	return &getdentsReader{dir: dir, f: f, rc: rc, buf: direntBufs.Get().(*[]byte)}, nil
+}
+
+func (r *getdentsReader) next() (fs.DirEntry, error) {
+	for {
+		name, typ, _, ok, err := r.raw()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, io.EOF
+		}
+		if len(r.slab) == 0 {
+			r.slab = make([]dirent, direntSlab)
+		}
+		d := &r.slab[0]
+		if err := d.set(r.dir, name, typ); err != nil {
+			// The entry was removed since the directory was read.
+			continue
+		}
+		r.slab = r.slab[1:]
+		return d, nil
+	}
+}
+
+// raw returns the name, d_type and inode number of the next entry, other
+// than "." and "..". ok is false at the end of the directory.
+func (r *getdentsReader) raw() (name string, typ uint8, ino uint64, ok bool, err error) {
+	for {
+		if len(r.b) == 0 {
+			buf := *r.buf
+			var n int
+			var readErr error
+			if err := r.rc.Read(func(fd uintptr) bool {
+				n, readErr = syscall.ReadDirent(int(fd), buf)
+				return readErr != syscall.EAGAIN
+			}); err != nil {
+				return "", 0, 0, false, err
+			}
+			if readErr == syscall.EINTR {
+				continue
+			}
+			if readErr == syscall.ENOENT {
+				// The directory was deleted while being read.
+				return "", 0, 0, false, nil
+			}
+			if readErr != nil {
+				return "", 0, 0, false, &os.PathError{Op: "getdents", Path: r.f.Name(), Err: readErr}
+			}
+			if n <= 0 {
+				return "", 0, 0, false, nil
+			}
+			r.b = buf[:n]
+		}
+
+		// Each record is a struct linux_dirent64 (syscall.Dirent), whose
+		// fields are read in place: a record is shorter than the struct,
+		// whose name has room for the longest there is, so it can't be
+		// cast to one at the end of the buffer.
+		if len(r.b) < direntNameOff {
+			r.b = nil
+			continue
+		}
+		reclen := int(*(*uint16)(unsafe.Pointer(&r.b[direntReclenOff])))
+		if reclen < direntNameOff || reclen > len(r.b) {
+			r.b = nil
+			continue
+		}
+		rec := r.b[:reclen]
+		r.b = r.b[reclen:]
+		ino := *(*uint64)(unsafe.Pointer(&rec[direntInoOff]))
+		typ := rec[direntTypeOff]
+		raw := rec[direntNameOff:]
+		if i := bytes.IndexByte(raw, 0); i >= 0 {
+			raw = raw[:i]
+		}
+		if ino == 0 || string(raw) == "." || string(raw) == ".." {
+			continue
+		}
+		return string(raw), typ, ino, true, nil
+	}
+}
+
+func (r *getdentsReader) Close() error {
+	if r.buf != nil {
+		direntBufs.Put(r.buf)
+		r.buf, r.b = nil, nil
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetdentsReader(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGetdentsReader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Enough entries to take several buffers.
+	want := map[string]os.FileMode{"sub": os.ModeDir, "link": os.ModeSymlink}
+	for i := 0; i < 6000; i++ {
+		name := fmt.Sprintf("file-with-a-longish-name-%04d", i)
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+		want[name] = 0
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "sub"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub", filepath.Join(tmpDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &walker{fsys: osFS{}}
+	d, err := w.readDir(tmpDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if _, ok := d.(*getdentsReader); !ok {
+		t.Fatalf("readDir returned a %T, want a *getdentsReader", d)
+	}
+	got := make(map[string]os.FileMode)
+	for {
+		e, err := d.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[e.Name()] = e.Type()
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("getdentsReader returned wrong entries, -want +got: %v", diff)
+	}
+}
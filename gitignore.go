@@ -0,0 +1,190 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// gitignore decides which directory entries are excluded by the .gitignore
+// files of the directories containing them.
+//
+// It remembers the rules of every directory it has been asked about, so that
+// each .gitignore file is read at most once per Stream.
+type gitignore struct {
+	fsys filesystem
+	// cwd is the directory relative paths are resolved against, or empty
+	// if they are not to be resolved, in which case the .gitignore files of
+	// directories above "." do not apply.
+	cwd string
+
+	mu   sync.Mutex
+	dirs map[string]*ignoreFile
+}
+
+// ignoreFile holds the rules read from the .gitignore file of one directory.
+type ignoreFile struct {
+	rules []ignoreRule
+	// repo is set if the directory is the root of a git repository, in which
+	// case the .gitignore files of its parents do not apply.
+	repo bool
+}
+
+// ignoreRule is a single line of a .gitignore file.
+type ignoreRule struct {
+	// pattern holds the slash-separated elements of the rule.
+	pattern  []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func newGitignore(fsys filesystem, cwd string) *gitignore {
+	return &gitignore{
+		fsys: fsys,
+		cwd:  cwd,
+		dirs: make(map[string]*ignoreFile),
+	}
+}
+
+// ignored reports whether the entry name of directory dir, which is a
+// directory if isDir is set, is excluded.
+//
+// Following git, the deepest .gitignore file with a rule matching the entry
+// decides, and within a file the last matching rule wins.
+func (g *gitignore) ignored(dir, name string, isDir bool) bool {
+	if !filepath.IsAbs(dir) && g.cwd != "" {
+		dir = filepath.Join(g.cwd, dir)
+	}
+	p := filepath.Join(dir, name)
+
+	for d := dir; ; {
+		f := g.load(d)
+		rel := p
+		if d != "." {
+			rel = strings.TrimPrefix(p[len(d):], string(filepath.Separator))
+		}
+		elems := strings.Split(filepath.ToSlash(rel), "/")
+		for i := len(f.rules) - 1; i >= 0; i-- {
+			if r := f.rules[i]; r.matches(elems, isDir) {
+				return !r.negate
+			}
+		}
+
+		parent := filepath.Dir(d)
+		if f.repo || parent == d {
+			return false
+		}
+		d = parent
+	}
+}
+
+// load returns the rules of directory dir, reading them if necessary.
+func (g *gitignore) load(dir string) *ignoreFile {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if f, ok := g.dirs[dir]; ok {
+		return f
+	}
+	f := &ignoreFile{}
+	if _, err := g.fsys.Lstat(filepath.Join(dir, ".git")); err == nil {
+		f.repo = true
+	}
+	name := filepath.Join(dir, ".gitignore")
+	// Only read regular files: opening a named pipe would block.
+	if fi, err := g.fsys.Lstat(name); err == nil && fi.Mode().IsRegular() {
+		if b, err := readFile(g.fsys, name); err == nil {
+			f.rules = parseGitignore(string(b))
+		}
+	}
+	g.dirs[dir] = f
+	return f
+}
+
+// parseGitignore parses the contents of a .gitignore file.
+func parseGitignore(content string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		// Trailing spaces are ignored unless escaped.
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+			line = line[:len(line)-1]
+		}
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		var r ignoreRule
+		if line[0] == '!' {
+			r.negate = true
+			line = line[1:]
+		} else if line[0] == '\\' && len(line) > 1 && (line[1] == '!' || line[1] == '#') {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// A separator anywhere but at the end anchors the rule to the
+		// directory containing the .gitignore file.
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		r.pattern = strings.Split(line, "/")
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// matches reports whether r matches the entry with the given slash-separated
+// path elements, relative to the directory containing the .gitignore file,
+// which is a directory if isDir is set.
+func (r ignoreRule) matches(elems []string, isDir bool) bool {
+	var ok bool
+	if r.anchored {
+		ok = matchElems(r.pattern, elems)
+	} else {
+		ok, _ = path.Match(r.pattern[0], elems[len(elems)-1])
+	}
+	return ok && (!r.dirOnly || isDir)
+}
+
+// matchElems matches path elements against pattern elements, where a "**"
+// pattern element matches any number of path elements. A trailing "**" must
+// match at least one element.
+func matchElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return len(elems) > 0
+			}
+			for i := 0; i <= len(elems); i++ {
+				if matchElems(rest, elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIgnoreRuleMatches(t *testing.T) {
+	for _, tt := range []struct {
+		rule  string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"*.o", "a.o", false, true},
+		{"*.o", "sub/a.o", false, true},
+		{"*.o", "a.c", false, false},
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"build/", "sub/build", true, true},
+		{"/build", "build", false, true},
+		{"/build", "sub/build", false, false},
+		{"doc/*.txt", "doc/a.txt", false, true},
+		{"doc/*.txt", "doc/sub/a.txt", false, false},
+		{"**/foo", "foo", false, true},
+		{"**/foo", "a/b/foo", false, true},
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**", "a", true, false},
+		{"a/**", "a/x/y", false, true},
+		{`\#hash`, "#hash", false, true},
+		{"trailing   ", "trailing", false, true},
+	} {
+		rules := parseGitignore(tt.rule)
+		if len(rules) != 1 {
+			t.Errorf("parseGitignore(%q) returned %d rules, want 1", tt.rule, len(rules))
+			continue
+		}
+		if got := rules[0].matches(strings.Split(tt.path, "/"), tt.isDir); got != tt.want {
+			t.Errorf("rule %q matching %q (dir: %v) = %v, want %v", tt.rule, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestParseGitignoreSkipsComments(t *testing.T) {
+	if rules := parseGitignore("# comment\n\n  \r\n/\n"); len(rules) != 0 {
+		t.Errorf("parseGitignore returned %d rules for a file with none, want 0", len(rules))
+	}
+}
+
+func TestGlobGitignore(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobGitignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		".gitignore":          "*.log\n!keep.log\n",
+		"repo/.git/HEAD":      "",
+		"repo/.gitignore":     "build/\n/top.txt\n",
+		"repo/a.txt":          "",
+		"repo/a.log":          "",
+		"repo/keep.log":       "",
+		"repo/top.txt":        "",
+		"repo/build/out.txt":  "",
+		"repo/src/top.txt":    "",
+		"repo/src/.gitignore": "local.txt\n",
+		"repo/src/local.txt":  "",
+		"repo/src/build/x":    "",
+		"repo/src/main.go":    "",
+		"outside.log":         "",
+	}
+	for name, content := range files {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		// The parent .gitignore is outside the repository and does not apply.
+		{"repo/*", []string{"repo/.git", "repo/.gitignore", "repo/a.log", "repo/a.txt", "repo/keep.log", "repo/src"}},
+		{"repo/*/*", []string{"repo/.git/HEAD", "repo/src/.gitignore", "repo/src/main.go", "repo/src/top.txt"}},
+		// Literal path elements are never ignored.
+		{"repo/build/*", []string{"repo/build/out.txt"}},
+		{"repo/src/local.txt", []string{"repo/src/local.txt"}},
+		{"*.log", []string{}},
+	} {
+		pattern := filepath.Join(tmpDir, filepath.FromSlash(tt.pattern))
+		matches, err := Glob(context.Background(), pattern, WithGitignore())
+		if err != nil {
+			t.Errorf("Glob error for %q: %s", pattern, err)
+			continue
+		}
+		want := make([]string, 0)
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, filepath.FromSlash(w)))
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+		}
+	}
+}
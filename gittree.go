@@ -0,0 +1,118 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// NewGitTree returns a RemoteFS, for WithRemoteFS, of the tree of the git
+// revision rev, such as "HEAD" or "origin/main", in the repository repo. No
+// checkout is needed, and the working tree, if any, is not read: with it,
+// "**/*_test.go" (see WithGlobstar) matches the files a commit has, which
+// git ls-files can't express. Names are relative to the top of the tree.
+//
+// Trees are read with the git command, one git ls-tree per directory, so that
+// a pattern only costs the directories it could match in. Symbolic links are
+// reported as links and not followed, and submodules as empty directories.
+func NewGitTree(repo, rev string) (RemoteFS, error) {
+	t := &gitTree{repo: repo}
+	out, err := t.git("rev-parse", "--verify", "--end-of-options", rev+"^{tree}")
+	if err != nil {
+		return nil, fmt.Errorf("glob: no tree for revision %q in %s: %w", rev, repo, err)
+	}
+	t.tree = string(bytes.TrimSpace(out))
+	return t, nil
+}
+
+// gitTree is the tree of a git revision.
+type gitTree struct {
+	repo string
+	tree string // the hash of the tree
+}
+
+// git runs git in the repository with args, returning its output.
+func (t *gitTree) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", t.repo, "--literal-pathspecs"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, err
+}
+
+// lsTree lists the entries of the tree that pathspec selects.
+func (t *gitTree) lsTree(op, name string, pathspec ...string) ([]fs.FileInfo, error) {
+	out, err := t.git(append([]string{"ls-tree", "-z", "--long", "--full-tree", t.tree, "--"}, pathspec...)...)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	var infos []fs.FileInfo
+	for _, line := range bytes.Split(out, []byte{0}) {
+		// <mode> SP <type> SP <object> SP <size> TAB <path>
+		meta, p, ok := strings.Cut(string(line), "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			continue
+		}
+		fi := remoteInfo{name: path.Base(p)}
+		fi.size, _ = strconv.ParseInt(fields[3], 10, 64)
+		switch fields[0] {
+		case "040000", "160000":
+			fi.dir = true
+		case "120000":
+			fi.mode = fs.ModeSymlink
+		}
+		infos = append(infos, fi)
+	}
+	return infos, nil
+}
+
+// treePath returns the path of name in the tree, or "" for the top.
+func treePath(name string) string {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." {
+		return ""
+	}
+	return name
+}
+
+func (t *gitTree) ReadDir(name string) ([]fs.FileInfo, error) {
+	p := treePath(name)
+	if p == "" {
+		return t.lsTree("readdir", name)
+	}
+	return t.lsTree("readdir", name, p+"/")
+}
+
+func (t *gitTree) Stat(name string) (fs.FileInfo, error) {
+	p := treePath(name)
+	if p == "" {
+		return remoteInfo{name: ".", dir: true}, nil
+	}
+	infos, err := t.lsTree("stat", name, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return infos[0], nil
+}
+
+// Lstat is Stat, since symbolic links are not followed.
+func (t *gitTree) Lstat(name string) (fs.FileInfo, error) {
+	return t.Stat(name)
+}
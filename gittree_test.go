@@ -0,0 +1,92 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobGitTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("skipping test without git: %s", err)
+	}
+	tmpDir, err := ioutil.TempDir("", "TestGlobGitTree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+	}
+	write := func(name, data string) {
+		t.Helper()
+		p := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	for _, name := range []string{"go.mod", "cmd/tool/main.go", "pkg/a/a.go", "pkg/a/a_test.go", "pkg/b/b_test.go", "pkg/[x]/x.go", "docs/README"} {
+		write(name, name)
+	}
+	write("pkg/empty.go", "")
+	git("add", "-A")
+	git("commit", "-q", "-m", "first")
+	git("tag", "v1")
+	// Neither the later commit nor the working tree is visible at v1.
+	write("pkg/c/c_test.go", "")
+	git("add", "-A")
+	git("commit", "-q", "-m", "second")
+	write("pkg/d/d_test.go", "")
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "*", want: []string{"cmd", "docs", "go.mod", "pkg"}},
+		{pattern: "**/*_test.go", opts: []Option{WithGlobstar()}, want: []string{"pkg/a/a_test.go", "pkg/b/b_test.go"}},
+		{pattern: "pkg/*/*.go", want: []string{"pkg/[x]/x.go", "pkg/a/a.go", "pkg/a/a_test.go", "pkg/b/b_test.go"}},
+		{pattern: Escape("pkg/[x]") + "/*", want: []string{"pkg/[x]/x.go"}},
+		{pattern: "pkg/*.go", opts: []Option{WithNonEmptyFiles()}, want: []string{}},
+		{pattern: "cmd/tool/main.go", want: []string{"cmd/tool/main.go"}},
+		{pattern: "pkg/c/*", want: []string{}},
+	}
+	tree, err := NewGitTree(tmpDir, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithRemoteFS(tree))...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) at v1, -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	if _, err := NewGitTree(tmpDir, "nonexistent"); err == nil {
+		t.Errorf("NewGitTree(%q) succeeded, want error", "nonexistent")
+	}
+}
@@ -9,12 +9,19 @@
 package glob
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Glob is similar to filepath.Glob but with different performance concerns.
@@ -24,8 +31,11 @@ import (
 // memory and O(n) time, where m is the number of match results, d is the depth
 // of the directory tree the pattern is concerned with, and n is the number of
 // files in that tree.
-func Glob(ctx context.Context, pattern string) ([]string, error) {
-	gr := Stream(pattern)
+//
+// If ctx is done before all matches have been found, Glob returns
+// context.Cause(ctx) rather than a partial result.
+func Glob(ctx context.Context, pattern string, opts ...Option) ([]string, error) {
+	gr := Stream(pattern, opts...)
 	ctx, cancel := context.WithCancel(ctx)
 	go func() {
 		<-ctx.Done()
@@ -36,6 +46,10 @@ func Glob(ctx context.Context, pattern string) ([]string, error) {
 	ret := make([]string, 0)
 	for {
 		match, err := gr.Next()
+		if err != nil && ctx.Err() != nil {
+			// The Stream failed because it was closed.
+			return nil, context.Cause(ctx)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -44,31 +58,144 @@ func Glob(ctx context.Context, pattern string) ([]string, error) {
 		}
 		ret = append(ret, match)
 	}
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
 	return ret, nil
 }
 
 // Result is a stream of results from globbing against a pattern.
 type Result struct {
 	errors  chan error
-	results chan string
+	results chan found
 	cancel  context.CancelFunc
+	w       *walker
+	merged  []*Result // see MergeStreams
 }
 
 // Stream Returns a Result from which glob matches can be streamed.
 //
 // Stream supports the same pattern syntax and produces the same matches as Go's
-// filepath.Glob, but makes no ordering guarantees.
-func Stream(pattern string) Result {
+// filepath.Glob, but makes no ordering guarantees unless WithSortedOrder is
+// given. Options may be given to enable behavior that filepath.Glob does not
+// have.
+//
+// On Windows, patterns may use forward slashes as well as backslashes as path
+// separators; matches use backslashes unless WithForwardSlashes is given. A
+// pattern that starts with a drive letter but no separator, such as
+// `C:src\*.go`, is relative to the current directory of that drive, as in
+// cmd.exe, and so are its matches. Paths longer than MAX_PATH need no \\?\
+// prefix, and matches only have one if the pattern does.
+//
+// Files and directories deleted while the Stream runs are treated as if they
+// had never existed, rather than reported as errors. A match may still be
+// deleted between being read from its directory and being received; only
+// options that filter matches by metadata, such as WithNonEmptyFiles, check
+// that it still exists.
+//
+// Unlike in most shells, wildcards match names beginning with a dot, and "**"
+// (see WithGlobstar) descends into hidden directories: hidden files are only
+// skipped when an option such as WithGitignore or WithCommonIgnores excludes
+// them, or with WithMinimatch.
+//
+// With WithInlineFlags, a path element of the pattern that starts with
+// "(?i)", as in `logs/(?i)readme*`, matches names regardless of case, while
+// the others stay case-sensitive.
+//
+// As in shells, and unlike with filepath.Glob, which matches nothing, a
+// pattern ending in a separator, such as `build/*/`, matches only
+// directories and symbolic links to them, and its matches end in a separator
+// too.
+func Stream(pattern string, opts ...Option) Result {
 	ctx, cancel := context.WithCancel(context.Background())
 	g := Result{
 		errors:  make(chan error),
-		results: make(chan string),
+		results: make(chan found),
 		cancel:  cancel,
 	}
+	w := &walker{fsys: osFS{}, pattern: pattern}
+	g.w = w
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+	// The walker has its own context so that it can stop itself on error,
+	// or at the deadline of WithTimeout, without that being mistaken for
+	// Close.
+	wctx, abort := context.WithCancel(ctx)
+	if deadline, ok := w.opts.stopAt(); ok {
+		var stop context.CancelFunc
+		wctx, stop = context.WithDeadline(wctx, deadline)
+		cancelCtx := abort
+		abort = func() {
+			stop()
+			cancelCtx()
+		}
+	}
+	w.ctx, w.cancel, w.abort = wctx, wctx.Done(), abort
+	w.useBackend(wctx)
+	if w.opts.sorted {
+		if p, err := w.expand(pattern); err == nil {
+			// Alternatives are matched one after the other.
+			w.pathOrder = pathOrdered(p, w.opts.globstar) && (!w.opts.braces || len(expandBraces(pattern)) == 1)
+		}
+	}
+	if w.opts.concurrency > 1 && !w.opts.sorted {
+		w.sem = make(chan struct{}, w.opts.concurrency-1)
+	}
+	if w.opts.tracer != nil {
+		w.span = w.opts.tracer.StartStream(pattern)
+	}
+	w.began = time.Now()
 	go func() {
 		defer close(g.results)
 		defer close(g.errors)
-		if err := stream(pattern, g.results, ctx.Done()); err != nil {
+		defer abort()
+		start := w.start
+		if w.opts.braces {
+			start = w.alternatives(start)
+		}
+		if w.opts.forwardSlashes && filepath.Separator != '/' {
+			start = w.rewrite(start, func(p string) (string, bool) {
+				return filepath.ToSlash(p), true
+			})
+		}
+		if w.opts.resultCache != nil {
+			start = w.useResultCache(start)
+		}
+		if w.opts.resume != nil {
+			start = w.resume(start)
+		}
+		var matches int64
+		if w.span != nil {
+			start = w.rewrite(start, func(p string) (string, bool) {
+				matches++
+				return p, true
+			})
+		}
+		if w.opts.spill {
+			start = w.spill(start)
+		}
+		var stop func()
+		if w.opts.progress != nil {
+			stop = g.reportProgress()
+		}
+		err := start(pattern, g.results)
+		if err == nil && ctx.Err() == nil && errors.Is(wctx.Err(), context.DeadlineExceeded) {
+			// The Stream ran out of time, rather than being closed.
+			err = context.DeadlineExceeded
+		}
+		if stop != nil {
+			stop()
+		}
+		took := time.Since(w.began)
+		g.ended(took)
+		if w.span != nil {
+			w.span.End(g.Stats(), matches, err)
+		}
+		if w.opts.debug != nil {
+			w.debug("glob: done", "pattern", pattern, "dirs", atomic.LoadInt64(&w.visited), "entries", atomic.LoadInt64(&w.examined), "duration", took, "err", err)
+		}
+		if err != nil {
 			select {
 			case g.errors <- err:
 			case <-ctx.Done():
@@ -82,6 +209,20 @@ func Stream(pattern string) Result {
 // the matches are exhausted.
 //
 // Next might block while reading directory entries in the background.
+//
+// Next is safe to call from several goroutines at once, so that a pool of
+// workers can take matches from one Stream directly: each match is returned
+// to exactly one of them. If the Stream fails, one caller gets the error, and
+// the others then get an empty string, as if the matches were exhausted. The
+// order of WithSortedOrder holds across calls, but not between what the
+// callers go on to do; nor does a Checkpoint tell which callers have finished
+// with the matches it counts.
+//
+// Only an empty string with a nil error means that every match has been
+// returned. Next returns ErrClosed once the Stream has been closed, including
+// a call blocked in Next when Close is called, and context.DeadlineExceeded
+// if WithTimeout or WithDeadline stopped the Stream first, so a caller can
+// tell complete results from partial ones with errors.Is.
 func (g *Result) Next() (string, error) {
 	return g.NextWithContext(context.Background())
 }
@@ -90,78 +231,511 @@ func (g *Result) Next() (string, error) {
 // string when the matches are exhausted.
 //
 // NextWithContext might block while reading directory entries in the
-// background, but respects context cancelation.
+// background, but respects context cancelation: if ctx is done first, it
+// returns context.Cause(ctx), which is context.Canceled or
+// context.DeadlineExceeded unless ctx was canceled with a cause of its own,
+// and the Stream can still be read from with another context. Like Next, it
+// is safe for concurrent use, as are the two together.
 func (g *Result) NextWithContext(ctx context.Context) (string, error) {
+	m, err := g.next(ctx)
+	return m.path, err
+}
+
+// next is NextWithContext, returning the directory entry of the match as
+// well, if it was read from a directory.
+func (g *Result) next(ctx context.Context) (found, error) {
 	// Note: Next never returns filepath.ErrBadPattern if it has previously
 	// returned a match. This isn't specified but it's highly desirable in
 	// terms of least-surprise. I don't think there's a concise way for this
 	// comment to justify this claim; you have to just read `stream` and
 	// `filepath.Match` to convince yourself.
+	if atomic.LoadInt32(&g.w.closed) != 0 {
+		return found{}, ErrClosed
+	}
 	select {
 	case err := <-g.errors:
-		g.Close()
-		return "", err
+		g.close()
+		return found{}, g.endedBy(err)
 	case r := <-g.results:
+		if r.path == "" {
+			if err := g.endedBy(nil); err != nil {
+				return found{}, err
+			}
+		}
+		g.received(r)
 		return r, nil
 	case <-ctx.Done():
-		return "", ctx.Err()
+		return found{}, context.Cause(ctx)
+	}
+}
+
+// endedBy returns the error with which Next reports that the Stream's
+// goroutine has ended with err: ErrClosed if it ended because the Stream was
+// closed, rather than because the matches were exhausted.
+func (g *Result) endedBy(err error) error {
+	if err == nil && atomic.LoadInt32(&g.w.closed) != 0 {
+		return ErrClosed
+	}
+	return err
+}
+
+// WriteTo writes the remaining matches to w, each followed by a newline or
+// the separator set by WithSeparator, and returns the number of bytes
+// written. Matches are buffered while more are ready, and written out as soon
+// as the Stream has to wait for the next one, so a consumer at the other end
+// of a pipe sees them as they are found.
+//
+// WriteTo stops at the first error from the Stream or from w; the Stream is
+// closed if w fails.
+func (g *Result) WriteTo(w io.Writer) (int64, error) {
+	sep := byte('\n')
+	if g.w.opts.separatorSet {
+		sep = g.w.opts.separator
+	}
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for {
+		var m found
+		var err error
+		select {
+		case err = <-g.errors:
+			g.close()
+			err = g.endedBy(err)
+		case m = <-g.results:
+			if m.path == "" {
+				err = g.endedBy(nil)
+			}
+			g.received(m)
+		default:
+			if err := bw.Flush(); err != nil {
+				g.Close()
+				return cw.n, err
+			}
+			m, err = g.next(context.Background())
+		}
+		if err != nil || m.path == "" {
+			if ferr := bw.Flush(); err == nil {
+				err = ferr
+			}
+			return cw.n, err
+		}
+		bw.WriteString(m.path)
+		if err := bw.WriteByte(sep); err != nil {
+			g.Close()
+			return cw.n, err
+		}
 	}
 }
 
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
 // Close cancels the in-progress globbing. You can call this any time, including
 // concurrently with Next. You don't need to call it if Next has returned an
-// empty string.
+// empty string. Once it has been called, Next returns ErrClosed.
+//
+// Close doesn't wait for a directory read that is blocked, on a dead network
+// mount for example: the Stream gives up on it and ends, and the directory is
+// closed once the read returns.
 func (g *Result) Close() error {
-	g.cancel()
+	atomic.StoreInt32(&g.w.closed, 1)
+	g.close()
 	return nil
 }
 
+// close is Close for a Stream that has ended by itself, after which Next
+// keeps returning what it did.
+func (g *Result) close() {
+	g.cancel()
+	g.consumed()
+}
+
+// Stats counts the work done by a Stream so far.
+type Stats struct {
+	// Dirs is the number of directories read.
+	Dirs int64
+	// Entries is the number of directory entries examined.
+	Entries int64
+	// SpecialFiles is the number of FIFOs, sockets and devices that options
+	// filtering matches by metadata skipped without calling stat on them
+	// (see WithStatSpecialFiles).
+	SpecialFiles int64
+	// Matches is the number of matches received from the Stream.
+	Matches int64
+	// Errors is the number of errors that didn't stop the Stream: those of
+	// directories and symbolic links skipped because they couldn't be
+	// stat'ed, other than for not existing.
+	Errors int64
+	// Duration is the wall time the Stream has run for, or ran for if it has
+	// ended.
+	Duration time.Duration
+}
+
+// Stats returns counts of the work done by the Stream so far. It may be called
+// at any time, including concurrently with Next.
+func (g *Result) Stats() Stats {
+	d := time.Since(g.w.began)
+	if atomic.LoadInt32(&g.w.ended) != 0 {
+		d = time.Duration(atomic.LoadInt64(&g.w.took))
+	}
+	st := Stats{
+		Dirs:         atomic.LoadInt64(&g.w.visited),
+		Entries:      atomic.LoadInt64(&g.w.examined),
+		SpecialFiles: atomic.LoadInt64(&g.w.special),
+		Matches:      atomic.LoadInt64(&g.w.matched),
+		Errors:       atomic.LoadInt64(&g.w.errs),
+		Duration:     d,
+	}
+	for _, m := range g.merged {
+		ms := m.Stats()
+		st.Dirs += ms.Dirs
+		st.Entries += ms.Entries
+		st.SpecialFiles += ms.SpecialFiles
+		st.Errors += ms.Errors
+	}
+	return st
+}
+
+// found is a match sent down a results channel, with its directory entry if
+// it was read from a directory.
+type found struct {
+	path string
+	d    fs.DirEntry
+}
+
+// walker holds the state shared by every stage of a single Stream.
+type walker struct {
+	visited  int64 // accessed atomically; see visit
+	examined int64 // accessed atomically; see yield
+	special  int64 // accessed atomically; see stat
+	matched  int64 // accessed atomically; see received
+	pending  int64 // accessed atomically; see openDir
+	errs     int64 // accessed atomically; see skipped
+	took     int64 // accessed atomically; see ended
+	ended    int32 // accessed atomically; set once took is
+	consumed int32 // accessed atomically; see consumed
+	closed   int32 // accessed atomically; see Close
+	finished int32 // accessed atomically; see finish
+	began    time.Time
+
+	opts   options
+	ctx    context.Context // done when cancel is closed
+	cancel <-chan struct{}
+	abort  context.CancelFunc // closes cancel
+	sem    chan struct{}      // see spawn
+	fsys   filesystem
+	ignore *gitignore
+	// filterRoot is the directory the paths WithFilterRules matches are
+	// relative to.
+	filterRoot string
+	span       StreamSpan // see WithTracer
+	// pathOrder is set if the matches are in path order, as described
+	// for MergeStreams.
+	pathOrder bool
+	pattern   string // as given to Stream
+
+	// See WithResume and Checkpoint.
+	resumeAfter string
+	lastMu      sync.Mutex
+	last        string // the last match received, with WithSortedOrder
+}
+
+// useBackend sets the filesystem to read to the one chosen by the options,
+// the operating system's by default. ctx bounds requests to an ObjectLister.
+func (w *walker) useBackend(ctx context.Context) {
+	switch {
+	case w.opts.fs != nil:
+		w.fsys = ioFS{w.opts.fs}
+		w.opts.forwardSlashes = true
+	case w.opts.lister != nil:
+		w.fsys = newObjectFS(ctx, w.opts.lister)
+		w.opts.forwardSlashes = true
+	case w.opts.remote != nil:
+		w.fsys = remoteFS{w.opts.remote}
+		w.opts.forwardSlashes = true
+	}
+}
+
+// start is the entry point of the background goroutine started by Stream. It
+// handles the parts of pattern that only make sense for the final path
+// element before handing over to stream.
+func (w *walker) start(pattern string, results chan<- found) error {
+	// The pattern is checked as given, so that a *PatternError locates the
+	// problem in what the caller wrote.
+	if err := validate(pattern, w.opts.posixClasses); err != nil {
+		return err
+	}
+	pattern, err := w.expand(pattern)
+	if err != nil {
+		return err
+	}
+	pattern, dirOnly := splitDirOnly(pattern)
+	done, err := w.prepare(pattern)
+	if err != nil {
+		return err
+	}
+	defer done()
+	base, pattern, rel, err := w.anchor(pattern)
+	if err != nil {
+		return err
+	}
+	if err := w.resumeFrom(base, rel); err != nil {
+		return err
+	}
+	w.filterRoot, _ = splitScope(pattern)
+	if base != "." && !rooted(w.filterRoot) {
+		w.filterRoot = filepath.Join(base, w.filterRoot)
+	}
+	w.useLimiter()
+	w.useDirCache()
+	match := func(pattern string, results chan<- found) error {
+		if depth, ok := globstarDepth(pattern); ok && w.opts.globstar {
+			// The current directory is not itself a match.
+			return w.globstar(base, false, depth, results, true)
+		}
+		if base != "." {
+			pattern = filepath.Join(Escape(base), pattern)
+		}
+		if w.opts.shares && runtime.GOOS == "windows" {
+			if server, sharePattern, rest, ok := splitShare(pattern); ok {
+				return w.streamShares(server, sharePattern, rest, results)
+			}
+		}
+		return w.streamFinal(pattern, results)
+	}
+	if dirOnly {
+		match = w.rewriteFound(match, w.keepDir)
+	}
+	// Matches are deduplicated by the paths they were found at, before they
+	// are made relative to a root.
+	if w.opts.dedup {
+		match = w.rewrite(match, newDeduper(w.fsys).keep)
+	}
+	if w.opts.linkDedup {
+		match = w.rewrite(match, newLinkDeduper(w).keep)
+	}
+	if rel && base != "." {
+		match = w.rewrite(match, func(p string) (string, bool) {
+			if rel, err := filepath.Rel(base, p); err == nil {
+				p = rel
+			}
+			return p, true
+		})
+	}
+	if w.opts.confined && w.opts.absolute {
+		// Within a confined root, matches are found relative to it.
+		abs, err := filepath.Abs(w.opts.root)
+		if err != nil {
+			return err
+		}
+		match = w.rewrite(match, func(p string) (string, bool) {
+			return filepath.Join(abs, p), true
+		})
+	}
+	if dirOnly {
+		// Matches are spelled as the pattern is, as in shells.
+		match = w.rewrite(match, func(p string) (string, bool) {
+			return p + string(filepath.Separator), true
+		})
+	}
+	return match(pattern, results)
+}
+
+// expand rewrites pattern as the options make it mean, before anything is
+// matched against it.
+func (w *walker) expand(pattern string) (string, error) {
+	if w.opts.tilde {
+		p, err := expandTilde(pattern)
+		if err != nil {
+			return "", err
+		}
+		pattern = p
+	}
+	if w.opts.env {
+		p, err := expandEnv(pattern)
+		if err != nil {
+			return "", err
+		}
+		pattern = p
+	}
+	// Which separator the pattern uses is insignificant, as a backslash is
+	// not an escape character on Windows, but it shows in literal parts of
+	// matches.
+	pattern = filepath.FromSlash(pattern)
+	if w.opts.basename && pattern != "" && !strings.ContainsRune(pattern, filepath.Separator) && filepath.VolumeName(pattern) == "" {
+		pattern = "**" + string(filepath.Separator) + pattern
+	}
+	if w.opts.minimatch {
+		pattern = minimatchClasses(pattern)
+	}
+	pattern, err := expandSyntax(pattern, &w.opts)
+	if err != nil {
+		return "", err
+	}
+	if w.opts.globstar {
+		pattern = collapseGlobstars(pattern)
+	}
+	return pattern, nil
+}
+
+// prepare opens the confined root of WithConfinedRoot, if any, and sets up
+// WithGitignore, for the expanded pattern. The caller must call done once it
+// has finished reading the filesystem.
+func (w *walker) prepare(pattern string) (done func(), err error) {
+	done = func() {}
+	if w.opts.confined {
+		if rooted(pattern) {
+			return nil, fmt.Errorf("glob: pattern %q is not relative to the confined root", pattern)
+		}
+		r, err := openConfined(w.opts.confinedRoot)
+		if err != nil {
+			return nil, err
+		}
+		if w.opts.within != "" {
+			sub, err := r.openWithin(w.opts.within)
+			r.Close()
+			if err != nil {
+				return nil, err
+			}
+			r = sub
+		}
+		done = func() { r.Close() }
+		w.fsys = r
+	}
+	if w.opts.gitignore {
+		// Within a confined root or an fs.FS, the .gitignore files above
+		// the root don't apply.
+		cwd := ""
+		if _, ok := w.fsys.(osFS); ok {
+			cwd, _ = os.Getwd()
+		}
+		w.ignore = newGitignore(w.fsys, cwd)
+	}
+	return done, nil
+}
+
+// streamFinal is stream for the whole pattern (or what is left of it to match
+// after start), including its data streams.
+func (w *walker) streamFinal(pattern string, results chan<- found) error {
+	if w.opts.dataStreams {
+		if file, streamPattern, ok := splitDataStream(pattern); ok {
+			return w.streamDataStreams(file, streamPattern, results)
+		}
+	}
+	return w.stream(pattern, results, true)
+}
+
+// rewrite returns a function like start that passes each match through f
+// before sending it down the results channel, or drops it if f returns false.
+// f is only ever called from one goroutine at a time.
+func (w *walker) rewrite(start func(string, chan<- found) error, f func(string) (string, bool)) func(string, chan<- found) error {
+	return w.rewriteFound(start, func(m found) (found, bool) {
+		p, ok := f(m.path)
+		return found{p, m.d}, ok
+	})
+}
+
+// rewriteFound is rewrite for functions that need the directory entries of
+// the matches as well as their paths.
+func (w *walker) rewriteFound(start func(string, chan<- found) error, f func(found) (found, bool)) func(string, chan<- found) error {
+	return func(pattern string, results chan<- found) error {
+		raw := make(chan found)
+		var err error
+		go func() {
+			err = start(pattern, raw)
+			close(raw)
+		}()
+		for m := range raw {
+			m, ok := f(m)
+			if !ok {
+				continue
+			}
+			select {
+			case results <- m:
+			case <-w.cancel:
+				// Drain raw until start notices cancel.
+			}
+		}
+		return err
+	}
+}
+
 // stream finds files matching pattern and sends their paths on the results
 // channel. It stops (returning nil) if the cancel channel is closed.
 // The caller must drain the results channel.
-func stream(pattern string, results chan<- string, cancel <-chan struct{}) error {
+//
+// final is set if the results are matches of the whole pattern, rather than
+// directories to continue matching from.
+func (w *walker) stream(pattern string, results chan<- found, final bool) error {
 	if !hasMeta(pattern) {
-		if _, err := os.Lstat(pattern); err != nil {
+		fi, err := w.fsys.Lstat(pattern)
+		if err != nil {
+			return nil
+		}
+		d := fs.FileInfoToDirEntry(fi)
+		if final && w.filtered(pattern, d) {
 			return nil
 		}
 		select {
-		case results <- pattern:
-		case <-cancel:
+		case results <- found{pattern, d}:
+		case <-w.cancel:
 		}
 		return nil
 	}
 
 	dir, file := filepath.Split(pattern)
 	volumeLen := 0
+	driveRelative := false
 	if runtime.GOOS == "windows" {
+		driveRelative = len(dir) == 2 && dir[1] == ':'
 		volumeLen, dir = cleanGlobPathWindows(dir)
 	} else {
 		dir = cleanGlobPath(dir)
 	}
 
 	if !hasMeta(dir[volumeLen:]) {
-		return glob(dir, file, results, cancel)
+		if depth, ok := globstarDepth(file); ok && driveRelative && w.opts.globstar {
+			// Like "**" on its own, "C:**" doesn't match the current
+			// directory of C: itself.
+			return w.globstar(dir, false, depth, results, final)
+		}
+		return w.glob(dir, file, results, final)
 	}
 
 	// Prevent infinite recursion. See Go issue 15879.
 	if dir == pattern {
-		return filepath.ErrBadPattern
+		return badPattern(pattern)
 	}
 
-	dirMatches := make(chan string)
+	dirMatches := make(chan found)
 	var streamErr error
 	go func() {
-		streamErr = stream(dir, dirMatches, cancel)
+		streamErr = w.stream(dir, dirMatches, false)
 		close(dirMatches)
 	}()
 
+	var g group
 	for d := range dirMatches {
-		if err := glob(d, file, results, cancel); err != nil {
+		if g.failed() {
 			// Drain channel before returning
-			for range dirMatches {
-			}
-			return err
+			continue
 		}
+		d := d.path
+		w.spawn(&g, func() error {
+			return w.glob(d, file, results, final)
+		})
+	}
+	if err := g.wait(); err != nil {
+		return err
 	}
 
 	return streamErr
@@ -199,51 +773,124 @@ func cleanGlobPathWindows(path string) (prefixLen int, cleaned string) {
 	}
 }
 
+// joinPrefix returns the prefix that the name of an entry of the directory dir
+// is appended to for its path, so that filepath.Join(dir, name) is
+// joinPrefix(dir)+name: the path of each entry of a directory then takes a
+// single allocation, rather than the several of cleaning the joined path
+// afresh.
+func joinPrefix(dir string) string {
+	// Cleaning never removes a final element that isn't "." or "..", as no
+	// entry's name is.
+	p := filepath.Join(dir, "_")
+	return p[:len(p)-1]
+}
+
 // glob searches for files matching pattern in the directory dir
 // and sends them down the results channel. It stops if the cancel channel is
-// closed.
-func glob(dir, pattern string, results chan<- string, cancel <-chan struct{}) error {
-	fi, err := os.Stat(dir)
+// closed. final is as for stream.
+func (w *walker) glob(dir, pattern string, results chan<- found, final bool) error {
+	if depth, ok := globstarDepth(pattern); ok && w.opts.globstar {
+		return w.globstar(dir, true, depth, results, final)
+	}
+	if w.passed(dir) {
+		return nil
+	}
+
+	fi, err := w.fsys.Stat(dir)
 	if err != nil {
+		w.skipped(dir, err)
 		return nil
 	}
 	if !fi.IsDir() {
 		return nil
 	}
-	d, err := os.Open(dir)
+	if err := w.visit(dir); err != nil {
+		return err
+	}
+	// Names that match a normalized pattern, or by their short names, may
+	// not share its prefix.
+	prefix := ""
+	if !w.opts.normalize && !w.opts.shortNames {
+		prefix = literalPrefix(pattern)
+	}
+	d, err := w.openDir(dir, prefix)
 	if err != nil {
 		return err
 	}
 	defer d.Close()
 
+	// Entries named literally by the pattern are never skipped.
+	skip := w.excludes() && hasMeta(pattern)
+	var nfcPattern string
+	var short map[string]string // see WithShortNames
+	if w.opts.normalize {
+		nfcPattern = nfc(pattern)
+	}
+	join := joinPrefix(dir)
+
 	for {
 		select {
-		case <-cancel:
+		case <-w.cancel:
 			return nil
 		default:
 		}
 
-		names, err := d.Readdirnames(1)
+		e, err := d.next()
 		if err == io.EOF {
 			return nil
 		}
 		if err != nil {
 			return err
 		}
-		n := names[0]
+		w.yield()
+		n := e.Name()
 
 		matched, err := filepath.Match(pattern, n)
 		if err != nil {
-			return err
+			return badPattern(pattern)
 		}
-		if matched {
-			select {
-			case results <- filepath.Join(dir, n):
-			case <-cancel:
-				return nil
+		if !matched && w.opts.normalize {
+			matched, _ = filepath.Match(nfcPattern, nfc(n))
+		}
+		if !matched && w.opts.shortNames {
+			if short == nil {
+				short = w.shortNames(dir)
+			}
+			if s, ok := short[n]; ok {
+				matched, _ = filepath.Match(pattern, s)
 			}
 		}
+		if !matched || skip && w.excluded(dir, e) || w.opts.hiddenFrom(n, pattern) {
+			continue
+		}
+		p := join + n
+		if final && w.filtered(p, e) {
+			continue
+		}
+		select {
+		case results <- found{p, e}:
+		case <-w.cancel:
+			return nil
+		}
+	}
+}
+
+// yield records that a directory entry has been examined, and calls
+// runtime.Gosched once for every WithYieldEvery of them.
+func (w *walker) yield() {
+	n := atomic.AddInt64(&w.examined, 1)
+	if every := w.opts.yieldEvery; every > 0 && n%int64(every) == 0 {
+		runtime.Gosched()
+	}
+}
+
+// literalPrefix returns the part of the pattern element before its first
+// metacharacter, which every name it matches begins with.
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
 	}
+	return pattern
 }
 
 // hasMeta reports whether path contains any of the magic characters
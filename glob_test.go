@@ -8,7 +8,9 @@ package glob
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -16,7 +18,10 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -87,11 +92,373 @@ func TestGlob(t *testing.T) {
 	}
 }
 
+func TestGlobYieldEvery(t *testing.T) {
+	for _, n := range []int{1, 2, 1000} {
+		matches, err := Glob(context.Background(), "testdata/*/*", WithYieldEvery(n))
+		if err != nil {
+			t.Errorf("Glob error with WithYieldEvery(%d): %s", n, err)
+			continue
+		}
+		want := []string{"testdata/a/a", "testdata/a/b", "testdata/a/c", "testdata/b/a"}
+		for i := range want {
+			want[i] = filepath.FromSlash(want[i])
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob with WithYieldEvery(%d), -want +got: %v", n, diff)
+		}
+	}
+}
+
+func TestGlobInodeOrder(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithInodeOrder()},
+		{WithInodeOrder(), WithGlobstar()},
+	} {
+		for _, pattern := range []string{"testdata/*/*", "testdata/**/b"} {
+			pattern = filepath.FromSlash(pattern)
+			matches, err := Glob(context.Background(), pattern, opts...)
+			if err != nil {
+				t.Errorf("Glob error for %q: %s", pattern, err)
+				continue
+			}
+			want, err := Glob(context.Background(), pattern, opts[1:]...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+				t.Errorf("Bad results from Glob(%#q) with WithInodeOrder(), -want +got: %v", pattern, diff)
+			}
+		}
+	}
+}
+
+func TestGlobSortedOrder(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobSortedOrder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Create entries in an order unrelated to their names, so that directory
+	// order is unlikely to be sorted by accident.
+	for _, d := range []string{"m", "b", "z", "a"} {
+		for _, f := range []string{"q", "c", "x", "a", "k"} {
+			p := filepath.Join(tmpDir, d, f)
+			if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, pattern := range []string{"*", "*/*", "?/[a-m]", "[^z]/*"} {
+		pattern = filepath.Join(Escape(tmpDir), pattern)
+		want, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, opts := range [][]Option{
+			{WithSortedOrder()},
+			{WithSortedOrder(), WithConcurrency(4)},
+			{WithInodeOrder(), WithSortedOrder()},
+		} {
+			matches, err := Glob(context.Background(), pattern, opts...)
+			if err != nil {
+				t.Errorf("Glob(%q) error: %s", pattern, err)
+				continue
+			}
+			if diff := cmp.Diff(want, matches); diff != "" {
+				t.Errorf("Glob(%q) with WithSortedOrder is not in filepath.Glob order, -want +got: %v", pattern, diff)
+			}
+		}
+	}
+}
+
+func TestGlobForwardSlashes(t *testing.T) {
+	// Patterns may use forward slashes on every platform.
+	for _, pattern := range []string{"testdata/a/*", "testdata/*/c", "testdata/a/c"} {
+		matches, err := Glob(context.Background(), pattern)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", pattern, err)
+			continue
+		}
+		for _, m := range matches {
+			if want := filepath.FromSlash(filepath.ToSlash(m)); m != want {
+				t.Errorf("Glob(%q) returned %q, want %q", pattern, m, want)
+			}
+		}
+
+		slashed, err := Glob(context.Background(), pattern, WithForwardSlashes())
+		if err != nil {
+			t.Errorf("Glob(%q) error with WithForwardSlashes: %s", pattern, err)
+			continue
+		}
+		for i := range matches {
+			matches[i] = filepath.ToSlash(matches[i])
+		}
+		if diff := cmp.Diff(matches, slashed, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithForwardSlashes, -want +got: %v", pattern, diff)
+		}
+	}
+}
+
+func TestGlobLongPath(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobLongPath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Well past MAX_PATH on Windows.
+	deep := tmpDir
+	for i := 0; i < 20; i++ {
+		deep = filepath.Join(deep, strings.Repeat("d", 20))
+	}
+	if err := os.MkdirAll(deep, 0777); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(deep, "file.txt")
+	if err := ioutil.WriteFile(file, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := filepath.Join(Escape(tmpDir), strings.Repeat("d*"+string(filepath.Separator), 20)+"*.txt")
+	matches, err := Glob(context.Background(), pattern)
+	if err != nil {
+		t.Fatalf("Glob(%q) error: %s", pattern, err)
+	}
+	if diff := cmp.Diff([]string{file}, matches); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", pattern, diff)
+	}
+}
+
+func TestStats(t *testing.T) {
+	r := Stream("testdata/*/*")
+	n := 0
+	for {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == "" {
+			break
+		}
+		n++
+	}
+	// testdata and its subdirectories a and b are read. Other entries of
+	// testdata that match "*" are files, which can't be read as directories.
+	st := r.Stats()
+	if st.Dirs < 3 {
+		t.Errorf("Stats().Dirs = %d, want at least 3", st.Dirs)
+	}
+	if st.Entries < int64(n) {
+		t.Errorf("Stats().Entries = %d, want at least the %d matches", st.Entries, n)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	want, err := Glob(context.Background(), "testdata/*/*", WithSortedOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		opts []Option
+		sep  string
+	}{
+		{sep: "\n"},
+		{opts: []Option{WithSeparator(0)}, sep: "\x00"},
+	} {
+		var b strings.Builder
+		r := Stream("testdata/*/*", append(tt.opts, WithSortedOrder())...)
+		n, err := r.WriteTo(&b)
+		if err != nil {
+			t.Errorf("WriteTo error: %s", err)
+		}
+		if got := strings.Join(want, tt.sep) + tt.sep; b.String() != got || n != int64(len(got)) {
+			t.Errorf("WriteTo wrote %q (%d bytes), want %q", b.String(), n, got)
+		}
+	}
+
+	r := Stream("testdata/[")
+	if _, err := r.WriteTo(ioutil.Discard); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("WriteTo with a bad pattern returned error %v, want %v", err, filepath.ErrBadPattern)
+	}
+
+	// A pipe with no reader fails the first write.
+	pr, pw := io.Pipe()
+	pr.Close()
+	r = Stream("testdata/**", WithGlobstar())
+	if _, err := r.WriteTo(pw); err != io.ErrClosedPipe {
+		t.Errorf("WriteTo to a closed pipe returned error %v, want %v", err, io.ErrClosedPipe)
+	}
+}
+
 func TestGlobError(t *testing.T) {
 	_, err := Glob(context.Background(), "[]")
 	if err == nil {
 		t.Error("expected error for bad pattern; got none")
 	}
+
+	// The error locates the problem, whether or not anything is matched
+	// against it.
+	for _, tt := range []struct {
+		pattern string
+		want    PatternError
+	}{
+		{"[]", PatternError{Pattern: "[]", Construct: "[]", Msg: "empty character class"}},
+		{"testdata/*/[", PatternError{Pattern: "testdata/*/[", Offset: 11, Construct: "[", Msg: "unterminated character class"}},
+		{"nonexistent/a[", PatternError{Pattern: "nonexistent/a[", Offset: 13, Construct: "[", Msg: "unterminated character class"}},
+	} {
+		_, err := Glob(context.Background(), tt.pattern)
+		var pe *PatternError
+		if !errors.As(err, &pe) || !errors.Is(err, filepath.ErrBadPattern) {
+			t.Errorf("Glob(%q) returned error %v, want a *PatternError", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, *pe); diff != "" {
+			t.Errorf("Bad error from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobContextCause(t *testing.T) {
+	cause := errors.New("user pressed escape")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(cause)
+	matches, err := Glob(ctx, "testdata/*")
+	if err != cause {
+		t.Errorf("Glob with canceled context returned error %v, want %v", err, cause)
+	}
+	if matches != nil {
+		t.Errorf("Glob with canceled context returned matches %v, want none", matches)
+	}
+
+	ctx, cancel2 := context.WithTimeout(context.Background(), 0)
+	defer cancel2()
+	if _, err := Glob(ctx, "testdata/*"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Glob with expired context returned error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestGlobTimeout(t *testing.T) {
+	s := stuckFS{
+		MapFS:   fstest.MapFS{"a/x": {}, "stuck/y": {}},
+		reading: make(chan struct{}),
+		release: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	defer close(s.release)
+	// The matches found in time are received before the error.
+	r := Stream("*/*", WithFS(s), WithSortedOrder(), WithTimeout(50*time.Millisecond))
+	if m, err := r.Next(); m != "a/x" || err != nil {
+		t.Errorf("Next() = %q, %v, want %q", m, err, "a/x")
+	}
+	if m, err := r.Next(); m != "" || err != context.DeadlineExceeded {
+		t.Errorf("Next() = %q, %v, want %v", m, err, context.DeadlineExceeded)
+	}
+
+	for _, tt := range []struct {
+		opts []Option
+		err  error
+	}{
+		{opts: []Option{WithTimeout(time.Minute)}},
+		{opts: []Option{WithDeadline(time.Now().Add(time.Minute))}},
+		{opts: []Option{WithDeadline(time.Now().Add(-time.Second))}, err: context.DeadlineExceeded},
+		// The earlier of the two applies.
+		{opts: []Option{WithTimeout(time.Minute), WithDeadline(time.Now().Add(-time.Second))}, err: context.DeadlineExceeded},
+	} {
+		if _, err := Glob(context.Background(), "testdata/*", tt.opts...); err != tt.err {
+			t.Errorf("Glob returned error %v, want %v", err, tt.err)
+		}
+	}
+}
+
+func TestNextWithContextCause(t *testing.T) {
+	gr := Stream("testdata/*")
+	defer gr.Close()
+
+	cause := errors.New("request abandoned")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(cause)
+	// Next may still return matches that are ready, but must eventually
+	// report the cause.
+	for i := 0; i < 10; i++ {
+		if _, err := gr.NextWithContext(ctx); err != nil {
+			if err != cause {
+				t.Errorf("NextWithContext returned error %v, want %v", err, cause)
+			}
+			return
+		}
+	}
+	t.Errorf("NextWithContext with canceled context never returned an error")
+}
+
+func TestNextConcurrent(t *testing.T) {
+	fsys := fstest.MapFS{}
+	var want []string
+	for i := 0; i < 1000; i++ {
+		name := fmt.Sprintf("dir%d/file%d", i%10, i)
+		fsys[name] = &fstest.MapFile{}
+		want = append(want, name)
+	}
+
+	// Each match is received by one of the workers.
+	r := Stream("*/*", WithFS(fsys))
+	var mu sync.Mutex
+	var got []string
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				m, err := r.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if m == "" {
+					return
+				}
+				mu.Lock()
+				got = append(got, m)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", "*/*", diff)
+	}
+	if st := r.Stats(); st.Matches != int64(len(want)) {
+		t.Errorf("Stats().Matches = %d, want %d", st.Matches, len(want))
+	}
+
+	// The error is received by one of them, and the others see the end.
+	r = Stream("[]", WithFS(fsys))
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			m, err := r.Next()
+			if m != "" {
+				err = fmt.Errorf("Next() = %q, want no match", m)
+			}
+			errs <- err
+		}()
+	}
+	failed := 0
+	for i := 0; i < 8; i++ {
+		if err := <-errs; errors.Is(err, filepath.ErrBadPattern) {
+			failed++
+		} else if err != nil {
+			t.Error(err)
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d calls to Next returned %v, want 1", failed, filepath.ErrBadPattern)
+	}
 }
 
 func TestGlobUNC(t *testing.T) {
@@ -308,6 +675,172 @@ func TestWindowsGlob(t *testing.T) {
 	}
 }
 
+func TestWindowsDriveRelativeGlob(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skipf("skipping windows specific test")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestWindowsDriveRelativeGlob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := os.MkdirAll(filepath.Join(tmpDir, "a"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "a", "x"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	drive := tmpDir[:2] // C:
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: drive + "*", want: []string{drive + "a"}},
+		{pattern: drive + `a\*`, want: []string{drive + `a\x`}},
+		{pattern: drive + "?/*", want: []string{drive + `a\x`}},
+		{pattern: drive + ".", want: []string{drive + "."}},
+		{pattern: drive + "**", opts: []Option{WithGlobstar()}, want: []string{drive + "a", drive + `a\x`}},
+		{pattern: drive + `a\**`, opts: []Option{WithGlobstar()}, want: []string{drive + "a", drive + `a\x`}},
+		{pattern: drive + "*", opts: []Option{WithAbsolutePaths()}, want: []string{filepath.Join(tmpDir, "a")}},
+		{pattern: drive + "**", opts: []Option{WithGlobstar(), WithAbsolutePaths()}, want: []string{filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, `a\x`)}},
+		{pattern: tmpDir[2:] + `\a`, opts: []Option{WithAbsolutePaths()}, want: []string{filepath.Join(tmpDir, "a")}},
+	}
+	for _, tt := range tests {
+		matches, err := Glob(context.Background(), tt.pattern, tt.opts...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobAbsolutePaths(t *testing.T) {
+	if err := os.Chdir("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir("..")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "match", want: []string{"match"}},
+		{pattern: "*/a", want: []string{"a/a", "b/a"}},
+		{pattern: "./mat?h", want: []string{"match"}},
+		{pattern: "../testdata/match", want: []string{"match"}},
+		{pattern: "no-existo/*", want: []string{}},
+		{pattern: "a/c/**", opts: []Option{WithGlobstar()}, want: []string{"a/c", "a/c/d", "a/c/d/e", "a/c/d/e/f", "a/c/d/e/f/a", "a/c/d/e/f/b", "a/c/d/e/f/c"}},
+	}
+	for _, tt := range tests {
+		want := []string{}
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(wd, filepath.FromSlash(w)))
+		}
+		matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithAbsolutePaths())...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithAbsolutePaths, -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	// "**" on its own doesn't match the working directory, which the pattern
+	// is relative to.
+	matches, err := Glob(context.Background(), "**", WithGlobstar(), WithAbsolutePaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range matches {
+		if m == wd || !filepath.IsAbs(m) {
+			t.Errorf("Glob(\"**\") with WithAbsolutePaths matched %q", m)
+		}
+	}
+}
+
+func TestGlobRoot(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs, err := filepath.Abs("testdata/match")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "match", want: []string{"match"}},
+		{pattern: "*/a", want: []string{"a/a", "b/a"}},
+		{pattern: ".", want: []string{"."}},
+		{pattern: "no-existo/*", want: []string{}},
+		{pattern: "a/c/**", opts: []Option{WithGlobstar()}, want: []string{"a/c", "a/c/d", "a/c/d/e", "a/c/d/e/f", "a/c/d/e/f/a", "a/c/d/e/f/b", "a/c/d/e/f/c"}},
+		{pattern: "**/b", opts: []Option{WithGlobstar()}, want: []string{"a/b", "b", "a/c/d/e/f/b"}},
+		{pattern: Escape(abs), want: []string{abs}},
+		{pattern: "*/a", opts: []Option{WithAbsolutePaths()}, want: []string{filepath.Join(wd, "testdata/a/a"), filepath.Join(wd, "testdata/b/a")}},
+	}
+	for _, tt := range tests {
+		want := []string{}
+		for _, w := range tt.want {
+			want = append(want, filepath.FromSlash(w))
+		}
+		matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithRoot("testdata"))...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithRoot, -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	// "**" on its own doesn't match the root.
+	matches, err := Glob(context.Background(), "**", WithGlobstar(), WithRoot("testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 14 {
+		t.Errorf("Glob(\"**\") with WithRoot = %q, want the 14 entries beneath the root", matches)
+	}
+
+	// WithSubRoot goes beneath the root rather than replacing it.
+	matches, err = Glob(context.Background(), "*", WithRoot("testdata"), WithSubRoot("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, matches, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q) with WithSubRoot, -want +got: %v", "*", diff)
+	}
+}
+
 func TestNonWindowsGlobEscape(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skipf("skipping non-windows specific test")
@@ -344,14 +877,60 @@ func TestPartialGlob(t *testing.T) {
 	}
 
 	match, err := gr.Next()
-	if err != nil {
-		t.Errorf("After Close(), Next() returned unexpected error: %v", err)
+	if err != ErrClosed {
+		t.Errorf("After Close(), Next() returned error %v, want %v", err, ErrClosed)
 	}
 	if match != "" {
 		t.Errorf("After Close(), Next() returned non-empty match %q", match)
 	}
 }
 
+func TestNextTellsHowStreamEnded(t *testing.T) {
+	fsys := fstest.MapFS{"a/x": {}, "stuck/y": {}}
+
+	// Exhausted.
+	r := Stream("a/*", WithFS(fsys))
+	for {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next() returned unexpected error: %v", err)
+		}
+		if m == "" {
+			break
+		}
+	}
+	if m, err := r.Next(); m != "" || err != nil {
+		t.Errorf("After the matches were exhausted, Next() = %q, %v, want no match and no error", m, err)
+	}
+
+	// Closed while Next is blocked.
+	s := stuckFS{MapFS: fsys, reading: make(chan struct{}), release: make(chan struct{}), closed: make(chan struct{})}
+	defer close(s.release)
+	r = Stream("stuck/*", WithFS(s))
+	errs := make(chan error)
+	go func() {
+		_, err := r.Next()
+		errs <- err
+	}()
+	<-s.reading
+	r.Close()
+	if err := <-errs; !errors.Is(err, ErrClosed) {
+		t.Errorf("Next() blocked when the Stream was closed returned error %v, want %v", err, ErrClosed)
+	}
+
+	// Canceled, leaving the Stream to be read on.
+	r = Stream("a/*", WithFS(fsys))
+	defer r.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.NextWithContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("NextWithContext with a canceled context returned error %v, want %v", err, context.Canceled)
+	}
+	if m, err := r.Next(); m == "" || err != nil {
+		t.Errorf("After NextWithContext was canceled, Next() = %q, %v, want a match", m, err)
+	}
+}
+
 func TestCloseInvalidPattern(t *testing.T) {
 	gr := Stream("[]") // This is an invalid glob pattern.
 	err := gr.Close()
@@ -359,3 +938,79 @@ func TestCloseInvalidPattern(t *testing.T) {
 		t.Errorf("Close() on invalid patterns' result returned unexpected error: %v", err)
 	}
 }
+
+func TestJoinPrefix(t *testing.T) {
+	dirs := []string{".", "a", "a/b", "./a", "a/../b", "..", "../a", "/", "/a", ""}
+	if runtime.GOOS == "windows" {
+		dirs = append(dirs, `C:`, `C:\`, `C:\a`, `\\host\share`, `\\host\share\a`)
+	}
+	for _, dir := range dirs {
+		dir = filepath.FromSlash(dir)
+		if got, want := joinPrefix(dir)+"x", filepath.Join(dir, "x"); got != want {
+			t.Errorf("joinPrefix(%q)+%q = %q, want %q", dir, "x", got, want)
+		}
+	}
+}
+
+// benchmarkTree creates a directory of files and directories under a
+// temporary directory, and changes to it for the benchmark.
+func benchmarkTree(b *testing.B, files, dirs int) {
+	tmpDir, err := ioutil.TempDir("", "globbench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.RemoveAll(tmpDir) })
+	for i := 0; i < dirs; i++ {
+		dir := filepath.Join(tmpDir, fmt.Sprintf("dir%d", i))
+		if err := os.Mkdir(dir, 0777); err != nil {
+			b.Fatal(err)
+		}
+		for j := 0; j < files; j++ {
+			name := filepath.Join(dir, fmt.Sprintf("file%d.go", j))
+			if j%2 == 1 {
+				name = filepath.Join(dir, fmt.Sprintf("file%d.txt", j))
+			}
+			if err := ioutil.WriteFile(name, nil, 0666); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.Chdir(cwd) })
+}
+
+func benchmarkGlob(b *testing.B, pattern string, want int, opts ...Option) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matches, err := Glob(context.Background(), pattern, opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(matches) != want {
+			b.Fatalf("Glob(%q) matched %d files, want %d", pattern, len(matches), want)
+		}
+	}
+	b.ReportMetric(float64(b.N*want)/b.Elapsed().Seconds(), "matches/s")
+}
+
+func BenchmarkGlob(b *testing.B) {
+	benchmarkTree(b, 10000, 1)
+	benchmarkGlob(b, "dir0/*.go", 5000)
+}
+
+func BenchmarkGlobNested(b *testing.B) {
+	benchmarkTree(b, 100, 100)
+	benchmarkGlob(b, "*/*.go", 5000)
+}
+
+func BenchmarkGlobstar(b *testing.B) {
+	benchmarkTree(b, 100, 100)
+	benchmarkGlob(b, "**/*.go", 5000, WithGlobstar())
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import "context"
+
+// A Globber holds options that apply to every pattern it evaluates, so that
+// an application can configure globbing once, for instance:
+//
+//	g := glob.NewGlobber(glob.WithGlobstar(), glob.WithGitignore(), glob.WithConcurrency(8))
+//	matches, err := g.Glob(ctx, "src/**/*.go")
+//
+// A Globber caches nothing by itself: each of its patterns reads the tree
+// afresh unless a cache is among its options. The options that hold state,
+// such as the caches of WithDirCache and WithResultCache, share it between
+// every pattern of the Globber, and of those derived from it with With, as do
+// the filesystems of options such as WithFS and WithRemoteFS. A Globber is
+// safe for concurrent use.
+type Globber struct {
+	opts []Option
+}
+
+// NewGlobber returns a Globber that applies opts to every pattern.
+func NewGlobber(opts ...Option) *Globber {
+	return &Globber{opts: append([]Option(nil), opts...)}
+}
+
+// With returns a Globber that applies opts after those of gb, without
+// changing gb.
+func (gb *Globber) With(opts ...Option) *Globber {
+	return &Globber{opts: gb.with(opts)}
+}
+
+// Glob is like the package-level Glob, applying the options of gb before opts.
+func (gb *Globber) Glob(ctx context.Context, pattern string, opts ...Option) ([]string, error) {
+	return Glob(ctx, pattern, gb.with(opts)...)
+}
+
+// Stream is like the package-level Stream, applying the options of gb before
+// opts.
+func (gb *Globber) Stream(pattern string, opts ...Option) Result {
+	return Stream(pattern, gb.with(opts)...)
+}
+
+// Watch is like the package-level Watch, applying the options of gb before
+// opts.
+func (gb *Globber) Watch(pattern string, opts ...Option) Watcher {
+	return Watch(pattern, gb.with(opts)...)
+}
+
+// Complete is like the package-level Complete, applying the options of gb
+// before opts.
+func (gb *Globber) Complete(ctx context.Context, partial string, n int, opts ...Option) ([]string, error) {
+	return Complete(ctx, partial, n, gb.with(opts)...)
+}
+
+// Match reports whether name matches pattern, as the Globber would match it if
+// name existed, applying the options of gb before opts. Like Explain, it
+// doesn't touch the filesystem: of the options, only WithGlobstar,
+// WithMinimatch and those that expand the pattern, such as WithTildeExpansion
+// and WithBraceExpansion, affect it. name must be relative if pattern is, and absolute if pattern is;
+// it names a directory if it ends in a separator, which it must to match a
+// pattern that does. Match returns a *PatternError if pattern is malformed.
+func (gb *Globber) Match(pattern, name string, opts ...Option) (bool, error) {
+	match, err := newPathMatcher(pattern, gb.with(opts))
+	if err != nil {
+		return false, err
+	}
+	return match(name), nil
+}
+
+// with returns the options of gb followed by opts, so that opts override them.
+func (gb *Globber) with(opts []Option) []Option {
+	return append(gb.opts[:len(gb.opts):len(gb.opts)], opts...)
+}
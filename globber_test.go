@@ -0,0 +1,99 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobber(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobber")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, f := range []string{"a.go", "sub/b.go", "sub/c.txt"} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gb := NewGlobber(WithGlobstar(), WithSortedOrder())
+	pattern := filepath.Join(Escape(tmpDir), "**", "*.go")
+	want := []string{filepath.Join(tmpDir, "a.go"), filepath.Join(tmpDir, "sub", "b.go")}
+
+	matches, err := gb.Glob(context.Background(), pattern)
+	if err != nil {
+		t.Fatalf("Glob(%q) error: %s", pattern, err)
+	}
+	if diff := cmp.Diff(want, matches); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", pattern, diff)
+	}
+
+	// Options passed to a call, or to With, are applied after the Globber's.
+	if _, err := gb.Glob(context.Background(), pattern, WithMaxVisitedDirs(1)); err == nil {
+		t.Errorf("Glob(%q, WithMaxVisitedDirs(1)) succeeded, want a LimitError", pattern)
+	}
+	r := gb.With(WithForwardSlashes()).Stream(pattern)
+	defer r.Close()
+	var got []string
+	for {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next() returned unexpected error: %v", err)
+		}
+		if m == "" {
+			break
+		}
+		got = append(got, m)
+	}
+	if diff := cmp.Diff([]string{filepath.ToSlash(want[0]), filepath.ToSlash(want[1])}, got); diff != "" {
+		t.Errorf("Bad results from Stream(%q), -want +got: %v", pattern, diff)
+	}
+
+	// Match applies the Globber's options without reading anything.
+	for _, tt := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{pattern: "**/*.go", name: "a/b/c.go", want: true},
+		{pattern: "**/*.go", name: "c.go", want: true},
+		{pattern: "*/*.go", name: "a/b/c.go"},
+		{pattern: "**/*.go", name: "a/b/c.txt"},
+		{pattern: "**/*.{txt,md}", name: "a/b/c.txt", want: true},
+		{pattern: "a/*/", name: "a/b/", want: true},
+		{pattern: "a/*/", name: "a/b"},
+		{pattern: "a/*", name: "a/b/", want: true},
+	} {
+		got, err := gb.Match(tt.pattern, tt.name, WithBraceExpansion())
+		if err != nil {
+			t.Errorf("Match(%q, %q) returned unexpected error: %v", tt.pattern, tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+	if _, err := gb.Match("[", "a"); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("Match(%q, %q) returned error %v, want %v", "[", "a", err, filepath.ErrBadPattern)
+	}
+
+	// With doesn't change the Globber it was derived from.
+	if n := len(gb.opts); n != 2 {
+		t.Errorf("NewGlobber(...).With(...) changed the original Globber: it has %d options, want 2", n)
+	}
+}
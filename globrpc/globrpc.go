@@ -0,0 +1,255 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+// Package globrpc serves globs over RPC, as the server-streaming Glob method
+// of the Globber service defined in glob.proto.
+//
+// The package does not depend on gRPC or protobuf: Server implements the
+// method on plain Go types, and the code generated from glob.proto, into a
+// package of the server's choosing such as globpb below, is connected to it
+// with a few lines in the server binary:
+//
+//	type globberServer struct {
+//		globpb.UnimplementedGlobberServer
+//		s *globrpc.Server
+//	}
+//
+//	func (g globberServer) Glob(req *globpb.GlobRequest, stream globpb.Globber_GlobServer) error {
+//		err := g.s.Glob(stream.Context(), globrpc.Request{
+//			Pattern:     req.GetPattern(),
+//			Root:        req.GetRoot(),
+//			Globstar:    req.GetGlobstar(),
+//			Sorted:      req.GetSorted(),
+//			Gitignore:   req.GetGitignore(),
+//			Concurrency: int(req.GetConcurrency()),
+//		}, func(matches []string) error {
+//			return stream.Send(&globpb.GlobResponse{Matches: matches})
+//		})
+//		if err == nil {
+//			return nil
+//		}
+//		st := status.New(codes.Code(globrpc.Code(err)), err.Error())
+//		if v, ok := globrpc.Violation(err); ok {
+//			st, _ = st.WithDetails(&errdetails.BadRequest{
+//				FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: v.Field, Description: v.Description}},
+//			})
+//		}
+//		return st.Err()
+//	}
+package globrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+// Request is a GlobRequest.
+type Request struct {
+	Pattern string
+	// Root is the directory Pattern is relative to, itself relative to the
+	// root of the server, as set by glob.WithConfinedRoot or glob.WithRoot
+	// in the Options of the Server, or to its working directory. A Root
+	// that is absolute or leads out of the server's root with ".." elements
+	// is rejected, and within a confined root, so is one that leads out of
+	// it through symbolic links.
+	Root        string
+	Globstar    bool
+	Sorted      bool
+	Gitignore   bool
+	Concurrency int
+}
+
+// Server serves globs.
+type Server struct {
+	// Options are applied to every glob before those set by requests.
+	// Servers that take patterns from untrusted clients should confine them
+	// with glob.WithConfinedRoot, and bound them with options such as
+	// glob.WithMaxVisitedDirs.
+	Options []glob.Option
+	// MaxBatch is the largest number of matches sent at once. It defaults
+	// to 1000.
+	MaxBatch int
+	// MaxConcurrency bounds the Concurrency of requests. It defaults to 1.
+	MaxConcurrency int
+}
+
+// Glob calls send with the matches of req, in batches of those found while
+// the previous batch was being sent, until the matches are exhausted, ctx is
+// done, or send fails. It returns the error that stopped it, which Code
+// classifies.
+func (s *Server) Glob(ctx context.Context, req Request, send func(matches []string) error) error {
+	if err := glob.Validate(req.Pattern, s.Options...); err != nil {
+		return err
+	}
+	if req.Root != "" && !filepath.IsLocal(filepath.FromSlash(req.Root)) {
+		return &rootError{req.Root}
+	}
+	opts := append([]glob.Option{glob.WithForwardSlashes()}, s.Options...)
+	if req.Root != "" {
+		opts = append(opts, glob.WithSubRoot(req.Root))
+	}
+	if req.Globstar {
+		opts = append(opts, glob.WithGlobstar())
+	}
+	if req.Sorted {
+		opts = append(opts, glob.WithSortedOrder())
+	}
+	if req.Gitignore {
+		opts = append(opts, glob.WithGitignore())
+	}
+	maxConcurrency := s.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+	if n := req.Concurrency; n > 1 {
+		if n > maxConcurrency {
+			n = maxConcurrency
+		}
+		opts = append(opts, glob.WithConcurrency(n))
+	}
+	maxBatch := s.MaxBatch
+	if maxBatch <= 0 {
+		maxBatch = 1000
+	}
+
+	r := glob.Stream(req.Pattern, opts...)
+	defer r.Close()
+	// Matches are collected into the next batch while one is being sent.
+	batches := make(chan []string)
+	sent := make(chan error, 1)
+	go func() {
+		defer close(sent)
+		for b := range batches {
+			if err := send(b); err != nil {
+				sent <- err
+				return
+			}
+		}
+	}()
+	var batch []string
+	// hand gives the batch to the sender, waiting for it if wait is set.
+	hand := func(wait bool) error {
+		if !wait {
+			select {
+			case batches <- batch:
+				batch = nil
+			case err := <-sent:
+				return err
+			default:
+			}
+			return nil
+		}
+		select {
+		case batches <- batch:
+			batch = nil
+			return nil
+		case err := <-sent:
+			return err
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+	err := func() error {
+		for {
+			m, err := r.NextWithContext(ctx)
+			if err != nil {
+				return err
+			}
+			if m == "" {
+				if len(batch) == 0 {
+					return nil
+				}
+				return hand(true)
+			}
+			batch = append(batch, m)
+			if err := hand(len(batch) >= maxBatch); err != nil {
+				return err
+			}
+		}
+	}()
+	close(batches)
+	if serr := <-sent; serr != nil && err == nil {
+		err = serr
+	}
+	return err
+}
+
+// These are the canonical status codes of gRPC that Code returns.
+const (
+	codeOK                = 0
+	codeCanceled          = 1
+	codeUnknown           = 2
+	codeInvalidArgument   = 3
+	codeDeadlineExceeded  = 4
+	codeNotFound          = 5
+	codePermissionDenied  = 7
+	codeResourceExhausted = 8
+)
+
+// Code returns the canonical gRPC status code, as a codes.Code, for an error
+// returned by Server.Glob.
+func Code(err error) uint32 {
+	var limit *glob.LimitError
+	var root *rootError
+	switch {
+	case err == nil:
+		return codeOK
+	case errors.As(err, &root):
+		return codeInvalidArgument
+	case errors.Is(err, context.Canceled):
+		return codeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codeDeadlineExceeded
+	case errors.Is(err, filepath.ErrBadPattern):
+		return codeInvalidArgument
+	case errors.As(err, &limit):
+		return codeResourceExhausted
+	case errors.Is(err, fs.ErrPermission):
+		return codePermissionDenied
+	case errors.Is(err, fs.ErrNotExist):
+		return codeNotFound
+	}
+	return codeUnknown
+}
+
+// rootError is the error of Server.Glob for a Request whose Root is not
+// within the server's root.
+type rootError struct {
+	root string
+}
+
+func (e *rootError) Error() string {
+	return fmt.Sprintf("globrpc: root %q is not within the server's root", e.root)
+}
+
+// FieldViolation describes what is wrong with a field of a request, as a
+// google.rpc.BadRequest.FieldViolation does.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Violation returns the field violation of a request that Server.Glob
+// rejected with err for a malformed pattern or a root outside of the server's.
+func Violation(err error) (FieldViolation, bool) {
+	var root *rootError
+	if errors.As(err, &root) {
+		return FieldViolation{Field: "root", Description: "must be a relative path within the server's root"}, true
+	}
+	var pe *glob.PatternError
+	if !errors.As(err, &pe) {
+		return FieldViolation{}, false
+	}
+	return FieldViolation{
+		Field:       "pattern",
+		Description: fmt.Sprintf("%s at offset %d: %q", pe.Msg, pe.Offset, pe.Construct),
+	}, true
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package globrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	glob "github.com/google/go-streaming-globber"
+	"github.com/google/go-streaming-globber/globtest"
+)
+
+func TestServerGlob(t *testing.T) {
+	tree := fstest.MapFS{}
+	for i := 0; i < 50; i++ {
+		tree[fmt.Sprintf("logs/host%02d/messages", i)] = &fstest.MapFile{}
+	}
+	root := globtest.TempTree(t, tree)
+	var want []string
+	for i := 0; i < 50; i++ {
+		want = append(want, fmt.Sprintf("logs/host%02d/messages", i))
+	}
+
+	s := &Server{Options: []glob.Option{glob.WithConfinedRoot(root)}, MaxBatch: 8, MaxConcurrency: 4}
+	var got []string
+	batches := 0
+	err := s.Glob(context.Background(), Request{Pattern: "logs/*/messages", Concurrency: 16}, func(matches []string) error {
+		if len(matches) == 0 || len(matches) > 8 {
+			t.Errorf("send called with %d matches, want 1 to 8", len(matches))
+		}
+		batches++
+		got = append(got, matches...)
+		// A slow client makes matches pile up into batches.
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Glob error: %s", err)
+	}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(x, y string) bool { return x < y })); diff != "" {
+		t.Errorf("Glob sent diff (-want +got):\n%s", diff)
+	}
+	if batches >= len(want) {
+		t.Errorf("Glob sent %d batches for %d matches, want fewer", batches, len(want))
+	}
+
+	// Sorted matches come in order, across batches.
+	got = nil
+	err = s.Glob(context.Background(), Request{Pattern: "**/messages", Globstar: true, Sorted: true}, func(matches []string) error {
+		got = append(got, matches...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Glob error: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Glob sent diff in sorted order (-want +got):\n%s", diff)
+	}
+
+	sendErr := errors.New("client went away")
+	err = s.Glob(context.Background(), Request{Pattern: "logs/*/*"}, func([]string) error { return sendErr })
+	if err != sendErr {
+		t.Errorf("Glob with a failing send returned %v, want %v", err, sendErr)
+	}
+
+	// A Root is within the server's root.
+	got = nil
+	err = s.Glob(context.Background(), Request{Pattern: "host0*/messages", Root: "logs", Sorted: true}, func(matches []string) error {
+		got = append(got, matches...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Glob error: %s", err)
+	}
+	want = nil
+	for i := 0; i < 10; i++ {
+		want = append(want, fmt.Sprintf("host%02d/messages", i))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Glob with a Root sent diff (-want +got):\n%s", diff)
+	}
+
+	// So it is when the server's root isn't confined.
+	got = nil
+	s = &Server{Options: []glob.Option{glob.WithRoot(root)}}
+	err = s.Glob(context.Background(), Request{Pattern: "host0*/messages", Root: "logs", Sorted: true}, func(matches []string) error {
+		got = append(got, matches...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Glob error: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Glob with a Root under WithRoot sent diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerGlobErrors(t *testing.T) {
+	root := globtest.TempTree(t, globtest.TxtarTree("-- a/b/c --\n-- a/d/e --\n"))
+	send := func([]string) error { return nil }
+
+	err := (&Server{}).Glob(context.Background(), Request{Pattern: "a/[b"}, send)
+	if got := Code(err); got != codeInvalidArgument {
+		t.Errorf("Code(%v) = %d, want %d", err, got, codeInvalidArgument)
+	}
+	want := FieldViolation{Field: "pattern", Description: `unterminated character class at offset 2: "[b"`}
+	if v, ok := Violation(err); !ok || v != want {
+		t.Errorf("Violation(%v) = %+v, %v; want %+v", err, v, ok, want)
+	}
+
+	s := &Server{Options: []glob.Option{glob.WithConfinedRoot(root), glob.WithMaxVisitedDirs(1)}}
+	err = s.Glob(context.Background(), Request{Pattern: "*/*/*"}, send)
+	if got := Code(err); got != codeResourceExhausted {
+		t.Errorf("Code(%v) = %d, want %d", err, got, codeResourceExhausted)
+	}
+	if _, ok := Violation(err); ok {
+		t.Errorf("Violation(%v) reported a violation", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = (&Server{Options: []glob.Option{glob.WithConfinedRoot(root)}}).Glob(ctx, Request{Pattern: "*/*/*"}, send)
+	if got := Code(err); got != codeCanceled {
+		t.Errorf("Code(%v) = %d, want %d", err, got, codeCanceled)
+	}
+
+	// A Root can't escape the server's root.
+	s = &Server{Options: []glob.Option{glob.WithConfinedRoot(filepath.Join(root, "a"))}}
+	for _, r := range []string{"..", "b/../..", root, "/"} {
+		var sent []string
+		err := s.Glob(context.Background(), Request{Pattern: "*", Root: r}, func(matches []string) error {
+			sent = append(sent, matches...)
+			return nil
+		})
+		if got := Code(err); got != codeInvalidArgument {
+			t.Errorf("Glob with Root %q: Code(%v) = %d, want %d", r, err, got, codeInvalidArgument)
+		}
+		want := FieldViolation{Field: "root", Description: "must be a relative path within the server's root"}
+		if v, ok := Violation(err); !ok || v != want {
+			t.Errorf("Violation(%v) = %+v, %v; want %+v", err, v, ok, want)
+		}
+		if len(sent) > 0 {
+			t.Errorf("Glob with Root %q sent %q, want nothing", r, sent)
+		}
+	}
+}
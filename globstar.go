@@ -0,0 +1,273 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// globstar sends every file and directory beneath dir down the results
+// channel, preceded by dir itself if self is set. It stops if the cancel
+// channel is closed. If depth is not 0, only those at most depth levels
+// beneath dir are sent, as for a "**N" element.
+//
+// If final is not set, only the directories that are descended into are sent,
+// since those are the only ones that the rest of the pattern applies within.
+func (w *walker) globstar(dir string, self bool, depth int, results chan<- found, final bool) error {
+	fi, err := w.fsys.Stat(dir)
+	if err != nil {
+		w.skipped(dir, err)
+		return nil
+	}
+	if !fi.IsDir() {
+		return nil
+	}
+	if self && !(final && w.filtered(dir, nil)) {
+		select {
+		case results <- found{filepath.Clean(dir), fs.FileInfoToDirEntry(fi)}:
+		case <-w.cancel:
+			return nil
+		}
+	}
+	var dev uint64
+	if w.opts.oneFileSystem {
+		id, _ := w.identity(dir, fi)
+		dev = id.dev
+	}
+	var g group
+	w.spawn(&g, func() error {
+		return w.descend(&g, dir, dev, []os.FileInfo{fi}, 0, depth, results, final)
+	})
+	return g.wait()
+}
+
+// descend sends the contents of dir down the results channel, recursing into
+// subdirectories. dev identifies the filesystem traversal is confined to by
+// WithOneFileSystem. When following symbolic links or reparse points,
+// ancestors holds the directories between the root of the traversal and dir,
+// inclusive; links counts the symbolic links and reparse points followed to
+// get to dir. If levels is not 0, it is the number of levels, dir's own
+// entries included, left to send. Subdirectories are descended into as part of
+// g.
+func (w *walker) descend(g *group, dir string, dev uint64, ancestors []os.FileInfo, links, levels int, results chan<- found, final bool) error {
+	if err := w.visit(dir); err != nil {
+		return err
+	}
+	d, err := w.openDir(dir, "")
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	join := joinPrefix(dir)
+
+	for {
+		select {
+		case <-w.cancel:
+			return nil
+		default:
+		}
+
+		e, err := d.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		w.yield()
+
+		if !final && e.Type()&(fs.ModeDir|fs.ModeSymlink|fs.ModeIrregular) == 0 {
+			// Only directories are descended into, and so sent.
+			continue
+		}
+		if w.excludes() && w.excluded(dir, e) || w.opts.hiddenFrom(e.Name(), "**") {
+			continue
+		}
+		p := join + e.Name()
+		rfi, reparse := w.reparseDir(p, e)
+		if reparse && w.opts.reparse == ReparseSkip {
+			continue
+		}
+
+		if final && !w.filtered(p, e) {
+			select {
+			case results <- found{p, e}:
+			case <-w.cancel:
+				return nil
+			}
+		}
+
+		// Directories are only stat'ed when following symbolic links or
+		// reparse points, which needs their identity to detect cycles, or when
+		// staying on one filesystem; otherwise the type from the listing is
+		// all there is to know.
+		var fi os.FileInfo
+		sublinks := links
+		if e.Type()&os.ModeSymlink != 0 || reparse {
+			fi = rfi
+			if !reparse {
+				if !w.opts.followSymlinks {
+					continue
+				}
+				if fi, err = w.fsys.Stat(p); err != nil {
+					w.skipped(p, err)
+					continue
+				}
+				if !fi.IsDir() {
+					continue
+				}
+			}
+			if inCycle(fi, ancestors) {
+				w.debug("glob: not following link back to an ancestor", "path", p)
+				continue
+			}
+			sublinks++
+			if max := w.opts.maxLinkDepth; max > 0 && sublinks > max {
+				return &LimitError{Limit: "WithMaxLinkDepth", Max: max, Path: p}
+			}
+		} else if !e.IsDir() {
+			continue
+		} else if w.opts.followSymlinks || w.opts.reparse == ReparseDescend || w.opts.oneFileSystem {
+			if fi, err = e.Info(); err != nil {
+				continue
+			}
+		}
+		if w.opts.oneFileSystem {
+			if id, ok := w.identity(p, fi); ok && id.dev != dev {
+				w.debug("glob: not descending into another filesystem", "path", p)
+				continue
+			}
+		}
+
+		if !final {
+			select {
+			case results <- found{p, e}:
+			case <-w.cancel:
+				return nil
+			}
+		}
+		if g.failed() {
+			return nil
+		}
+		if w.passed(p) || levels == 1 {
+			continue
+		}
+		sublevels := levels
+		if levels > 0 {
+			sublevels--
+		}
+		subancestors := ancestors
+		if w.opts.followSymlinks || w.opts.reparse == ReparseDescend {
+			subancestors = append(ancestors[:len(ancestors):len(ancestors)], fi)
+		}
+		w.spawn(g, func() error {
+			return w.descend(g, p, dev, subancestors, sublinks, sublevels, results, final)
+		})
+	}
+}
+
+// inCycle reports whether descending into the directory fi would revisit one
+// of its ancestors.
+func inCycle(fi os.FileInfo, ancestors []os.FileInfo) bool {
+	for _, a := range ancestors {
+		if os.SameFile(fi, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// visit records that dir is about to be read, enforcing WithMaxVisitedDirs.
+func (w *walker) visit(dir string) error {
+	n := atomic.AddInt64(&w.visited, 1)
+	if max := w.opts.maxVisitedDirs; max > 0 && n > int64(max) {
+		return &LimitError{Limit: "WithMaxVisitedDirs", Max: max, Path: dir}
+	}
+	return nil
+}
+
+// collapseGlobstars replaces runs of consecutive "**" elements in pattern with
+// a single one, which matches the same paths without producing duplicates. A
+// run of "**N" elements becomes one whose depth is their sum, or "**" if any
+// of them is one.
+func collapseGlobstars(pattern string) string {
+	if !strings.Contains(pattern, "**") {
+		return pattern
+	}
+	var b strings.Builder
+	// run is the depth of the run of "**" elements being read, which is
+	// written once it ends.
+	run, inRun := 0, false
+	start := 0
+	for i := 0; i <= len(pattern); i++ {
+		if i < len(pattern) && !os.IsPathSeparator(pattern[i]) {
+			continue
+		}
+		elem := pattern[start:i]
+		if depth, ok := globstarDepth(elem); ok {
+			if !inRun {
+				if start > 0 {
+					b.WriteByte(pattern[start-1])
+				}
+				run, inRun = depth, true
+			} else if run != 0 {
+				run += depth
+				if depth == 0 {
+					run = 0
+				}
+			}
+		} else {
+			if inRun {
+				b.WriteString(globstarElem(run))
+				inRun = false
+			}
+			if start > 0 {
+				// Keep the separator preceding this element.
+				b.WriteByte(pattern[start-1])
+			}
+			b.WriteString(elem)
+		}
+		start = i + 1
+	}
+	if inRun {
+		b.WriteString(globstarElem(run))
+	}
+	return b.String()
+}
+
+// globstarDepth reports whether the pattern element elem is a globstar
+// element, "**" or "**N", and returns the most levels it matches, N, or 0 for
+// "**".
+func globstarDepth(elem string) (depth int, ok bool) {
+	if !strings.HasPrefix(elem, "**") {
+		return 0, false
+	}
+	if elem == "**" {
+		return 0, true
+	}
+	digits := elem[2:]
+	if digits[0] == '0' || strings.Trim(digits, "0123456789") != "" || len(digits) > 4 {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(digits)
+	return n, true
+}
+
+// globstarElem returns the globstar element that matches at most depth
+// levels, or any number if depth is 0.
+func globstarElem(depth int) string {
+	if depth == 0 {
+		return "**"
+	}
+	return "**" + strconv.Itoa(depth)
+}
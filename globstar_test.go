@@ -0,0 +1,339 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobstar(t *testing.T) {
+	if err := os.Chdir("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir("..")
+
+	for _, tt := range []struct {
+		pattern string
+		results []string
+	}{
+		{"**", []string{"a", "a/a", "a/b", "a/c", "a/c/d", "a/c/d/e", "a/c/d/e/f", "a/c/d/e/f/a", "a/c/d/e/f/b", "a/c/d/e/f/c", "b", "b/a", "match", "other"}},
+		{"**/b", []string{"a/b", "a/c/d/e/f/b", "b"}},
+		{"a/**/f/?", []string{"a/c/d/e/f/a", "a/c/d/e/f/b", "a/c/d/e/f/c"}},
+		{"a/c/**", []string{"a/c", "a/c/d", "a/c/d/e", "a/c/d/e/f", "a/c/d/e/f/a", "a/c/d/e/f/b", "a/c/d/e/f/c"}},
+		{"a/**/**/e", []string{"a/c/d/e"}},
+		{"b/**/a", []string{"b/a"}},
+		{"match/**", []string{}},
+		{"no-existo/**", []string{}},
+		// Depth-limited.
+		{"**2", []string{"a", "a/a", "a/b", "a/c", "b", "b/a", "match", "other"}},
+		{"a/**1", []string{"a", "a/a", "a/b", "a/c"}},
+		{"**1/b", []string{"a/b", "b"}},
+		{"a/**1/e", []string{}},
+		{"a/**2/e", []string{"a/c/d/e"}},
+		{"a/**1/**1/e", []string{"a/c/d/e"}},
+	} {
+		pattern := filepath.FromSlash(tt.pattern)
+		results := make([]string, 0)
+		for _, r := range tt.results {
+			results = append(results, filepath.FromSlash(r))
+		}
+		matches, err := Glob(context.Background(), pattern, WithGlobstar())
+		if err != nil {
+			t.Errorf("Glob error for %q: %s", pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(results, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%#q, WithGlobstar()), -want +got: %v", pattern, diff)
+		}
+	}
+}
+
+func TestGlobstarSortedOrder(t *testing.T) {
+	if err := os.Chdir("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir("..")
+
+	for _, tt := range []struct {
+		pattern string
+		results []string
+	}{
+		{"**", []string{"a", "a/a", "a/b", "a/c", "a/c/d", "a/c/d/e", "a/c/d/e/f", "a/c/d/e/f/a", "a/c/d/e/f/b", "a/c/d/e/f/c", "b", "b/a", "match", "other"}},
+		// Each directory's matches come before those of its subdirectories.
+		{"**/b", []string{"b", "a/b", "a/c/d/e/f/b"}},
+		{"**/?", []string{"a", "b", "a/a", "a/b", "a/c", "a/c/d", "a/c/d/e", "a/c/d/e/f", "a/c/d/e/f/a", "a/c/d/e/f/b", "a/c/d/e/f/c", "b/a"}},
+		{"a/c/**", []string{"a/c", "a/c/d", "a/c/d/e", "a/c/d/e/f", "a/c/d/e/f/a", "a/c/d/e/f/b", "a/c/d/e/f/c"}},
+	} {
+		pattern := filepath.FromSlash(tt.pattern)
+		var results []string
+		for _, r := range tt.results {
+			results = append(results, filepath.FromSlash(r))
+		}
+		for _, opts := range [][]Option{
+			{WithGlobstar(), WithSortedOrder()},
+			{WithGlobstar(), WithSortedOrder(), WithConcurrency(4)},
+		} {
+			matches, err := Glob(context.Background(), pattern, opts...)
+			if err != nil {
+				t.Errorf("Glob error for %q: %s", pattern, err)
+				continue
+			}
+			if diff := cmp.Diff(results, matches); diff != "" {
+				t.Errorf("Bad results from Glob(%#q, WithGlobstar(), WithSortedOrder()), -want +got: %v", pattern, diff)
+			}
+		}
+	}
+}
+
+func TestGlobstarDisabled(t *testing.T) {
+	matches, err := Glob(context.Background(), filepath.FromSlash("testdata/**/b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.FromSlash("testdata/a/b")}
+	if diff := cmp.Diff(want, matches); diff != "" {
+		t.Errorf("Bad results from Glob without WithGlobstar(), -want +got: %v", diff)
+	}
+}
+
+func TestCollapseGlobstars(t *testing.T) {
+	for _, tt := range []struct{ pattern, want string }{
+		{"**", "**"},
+		{"**/**", "**"},
+		{"a/**/**/**/b", "a/**/b"},
+		{"/**/**", "/**"},
+		{"a/**b/**/**", "a/**b/**"},
+		{"**/a/**", "**/a/**"},
+		{"**2/**3/a", "**5/a"},
+		{"a/**2/**/**1", "a/**"},
+		{"**1/a/**1", "**1/a/**1"},
+		{"**0/**01/***", "**0/**01/***"},
+	} {
+		if got := collapseGlobstars(filepath.FromSlash(tt.pattern)); got != filepath.FromSlash(tt.want) {
+			t.Errorf("collapseGlobstars(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestGlobHiddenFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobHiddenFiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{".hidden", "visible", ".config/app/settings"} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"*", []string{".config", ".hidden", "visible"}},
+		{"?hidden", []string{".hidden"}},
+		{"*/*/*", []string{".config/app/settings"}},
+		{"**/settings", []string{".config/app/settings"}},
+	} {
+		pattern := filepath.Join(tmpDir, filepath.FromSlash(tt.pattern))
+		matches, err := Glob(context.Background(), pattern, WithGlobstar())
+		if err != nil {
+			t.Errorf("Glob error for %q: %s", pattern, err)
+			continue
+		}
+		want := make([]string, 0)
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, filepath.FromSlash(w)))
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+		}
+		if strings.Contains(tt.pattern, "**") {
+			// filepath.Glob has no globstar to compare with.
+			continue
+		}
+		fpMatches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(fpMatches, matches, sortStringSlices); diff != "" {
+			t.Errorf("Glob(%#q) differs from filepath.Glob, -filepath.Glob +Glob: %v", pattern, diff)
+		}
+	}
+}
+
+func TestGlobstarSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("skipping symlink test on Windows")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestGlobstarSymlinks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// tree/loop points back at tree, and tree/other points at a directory
+	// outside of it.
+	for _, dir := range []string{"tree/sub", "other"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"tree/sub/x", "other/x"} {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, file), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(tmpDir, "tree"), filepath.Join(tmpDir, "tree/sub/loop")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(tmpDir, "other"), filepath.Join(tmpDir, "tree/other")); err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := filepath.Join(tmpDir, "tree/**/x")
+	for _, tt := range []struct {
+		opts []Option
+		want []string
+	}{
+		{[]Option{WithGlobstar()}, []string{"tree/sub/x"}},
+		{[]Option{WithGlobstar(), WithFollowSymlinks()}, []string{"tree/sub/x", "tree/other/x"}},
+	} {
+		matches, err := Glob(context.Background(), pattern, tt.opts...)
+		if err != nil {
+			t.Errorf("Glob error for %q: %s", pattern, err)
+			continue
+		}
+		want := make([]string, 0)
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, w))
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+		}
+	}
+
+	for _, tt := range []struct {
+		opt   Option
+		limit string
+	}{
+		{WithMaxLinkDepth(0), ""},
+		{WithMaxLinkDepth(1), ""},
+		// Each of tree, tree/sub and tree/other is read once while matching
+		// "**" and once more while matching "x".
+		{WithMaxVisitedDirs(5), "WithMaxVisitedDirs"},
+		{WithMaxVisitedDirs(6), ""},
+	} {
+		_, err := Glob(context.Background(), pattern, WithGlobstar(), WithFollowSymlinks(), tt.opt)
+		var le *LimitError
+		switch {
+		case tt.limit == "" && err != nil:
+			t.Errorf("Glob returned unexpected error: %v", err)
+		case tt.limit != "" && !errors.As(err, &le):
+			t.Errorf("Glob returned error %v, want a *LimitError", err)
+		case tt.limit != "" && le.Limit != tt.limit:
+			t.Errorf("Glob returned a *LimitError for %s, want %s", le.Limit, tt.limit)
+		}
+	}
+}
+
+func TestGlobstarMaxLinkDepth(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("skipping symlink test on Windows")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestGlobstarMaxLinkDepth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Each of d0..d3 contains a link to the next, so reaching d3 from d0
+	// takes three links.
+	for i := 0; i < 4; i++ {
+		if err := os.Mkdir(filepath.Join(tmpDir, "d"+string('0'+rune(i))), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		target := filepath.Join(tmpDir, "d"+string('1'+rune(i)))
+		if err := os.Symlink(target, filepath.Join(tmpDir, "d"+string('0'+rune(i)), "next")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pattern := filepath.Join(tmpDir, "d0/**")
+	if _, err := Glob(context.Background(), pattern, WithGlobstar(), WithFollowSymlinks(), WithMaxLinkDepth(3)); err != nil {
+		t.Errorf("Glob with WithMaxLinkDepth(3) returned unexpected error: %v", err)
+	}
+	_, err = Glob(context.Background(), pattern, WithGlobstar(), WithFollowSymlinks(), WithMaxLinkDepth(2))
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != "WithMaxLinkDepth" || le.Max != 2 {
+		t.Errorf("Glob with WithMaxLinkDepth(2) returned error %v, want a *LimitError for WithMaxLinkDepth(2)", err)
+	}
+}
+
+func TestGlobstarOneFileSystem(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("skipping symlink test on Windows")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestGlobstarOneFileSystem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// The test needs a directory on another filesystem, which it reaches
+	// through a symbolic link.
+	root, ok := fileIdentity(tmpDir, nil)
+	if !ok {
+		t.Skipf("file identities are not available on %s", runtime.GOOS)
+	}
+	var mount string
+	for _, dir := range []string{"/dev/shm", "/dev", "/proc/self/fdinfo", "/sys/kernel"} {
+		if id, ok := fileIdentity(dir, nil); ok && id.dev != root.dev {
+			mount = dir
+			break
+		}
+	}
+	if mount == "" {
+		t.Skipf("no directory on another filesystem from %s", tmpDir)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "x"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(mount, filepath.Join(tmpDir, "mnt")); err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := filepath.Join(Escape(tmpDir), "**")
+	matches, err := Glob(context.Background(), pattern, WithGlobstar(), WithFollowSymlinks(), WithOneFileSystem())
+	if err != nil {
+		t.Fatalf("Glob error for %q: %s", pattern, err)
+	}
+	want := []string{tmpDir, filepath.Join(tmpDir, "mnt"), filepath.Join(tmpDir, "x")}
+	if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+	}
+}
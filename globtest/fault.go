@@ -0,0 +1,112 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package globtest
+
+import (
+	"io/fs"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+// FaultFS is a glob.RemoteFS, for glob.WithRemoteFS, that injects faults into
+// the operations of another, so that tests can exercise error paths
+// deterministically:
+//
+//	fsys := &globtest.FaultFS{
+//		RemoteFS: globtest.RemoteFS(tree),
+//		Fault: func(op, name string) error {
+//			if op == "readdir" && name == "secret" {
+//				return fs.ErrPermission
+//			}
+//			time.Sleep(10 * time.Millisecond) // a slow disk
+//			return nil
+//		},
+//	}
+//	matches, err := glob.Glob(ctx, "*/*", glob.WithRemoteFS(fsys))
+//
+// As with filepath.Glob, a file that can't be stat'ed is taken not to exist,
+// while a directory that can't be read ends the glob with the error.
+type FaultFS struct {
+	glob.RemoteFS
+	// Fault is called before each operation with the name of the operation,
+	// "readdir", "stat" or "lstat", and the name it is given. If it returns
+	// an error, the operation fails with it, wrapped in an *fs.PathError,
+	// instead of calling RemoteFS. Fault may be called concurrently.
+	Fault func(op, name string) error
+}
+
+// fault returns the error to fail op on name with, if any.
+func (f *FaultFS) fault(op, name string) error {
+	if f.Fault == nil {
+		return nil
+	}
+	if err := f.Fault(op, name); err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return nil
+}
+
+func (f *FaultFS) ReadDir(name string) ([]fs.FileInfo, error) {
+	if err := f.fault("readdir", name); err != nil {
+		return nil, err
+	}
+	return f.RemoteFS.ReadDir(name)
+}
+
+func (f *FaultFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.fault("stat", name); err != nil {
+		return nil, err
+	}
+	return f.RemoteFS.Stat(name)
+}
+
+func (f *FaultFS) Lstat(name string) (fs.FileInfo, error) {
+	if err := f.fault("lstat", name); err != nil {
+		return nil, err
+	}
+	return f.RemoteFS.Lstat(name)
+}
+
+// RemoteFS returns a glob.RemoteFS of tree, such as an fstest.MapFS, for
+// glob.WithRemoteFS or as the RemoteFS of a FaultFS. Names are those of tree,
+// relative and slash-separated. Symbolic links are only recognized if tree
+// has a method Lstat(name string) (fs.FileInfo, error).
+func RemoteFS(tree fs.FS) glob.RemoteFS {
+	return remoteFS{tree}
+}
+
+// remoteFS is an fs.FS as a glob.RemoteFS.
+type remoteFS struct {
+	tree fs.FS
+}
+
+func (r remoteFS) ReadDir(name string) ([]fs.FileInfo, error) {
+	entries, err := fs.ReadDir(r.tree, name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, len(entries))
+	for i, e := range entries {
+		if infos[i], err = e.Info(); err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+func (r remoteFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.tree, name)
+}
+
+func (r remoteFS) Lstat(name string) (fs.FileInfo, error) {
+	if l, ok := r.tree.(interface {
+		Lstat(name string) (fs.FileInfo, error)
+	}); ok {
+		return l.Lstat(name)
+	}
+	return r.Stat(name)
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package globtest
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+// remoteEngine is an Engine backed by glob.Glob on a tree through
+// glob.WithRemoteFS.
+type remoteEngine struct {
+	fsys glob.RemoteFS
+}
+
+func (e remoteEngine) Glob(ctx context.Context, pattern string) ([]string, error) {
+	return glob.Glob(ctx, pattern, glob.WithRemoteFS(e.fsys))
+}
+
+func TestRemoteFS(t *testing.T) {
+	TestEngine(t, func(t *testing.T, tree fs.FS) Engine {
+		return remoteEngine{fsys: &FaultFS{RemoteFS: RemoteFS(tree)}}
+	})
+}
+
+func TestFaultFS(t *testing.T) {
+	fsys := &FaultFS{
+		RemoteFS: RemoteFS(Tree),
+		Fault: func(op, name string) error {
+			if op == "readdir" && name == "a" {
+				return fs.ErrPermission
+			}
+			return nil
+		},
+	}
+	_, err := glob.Glob(context.Background(), "a/*", glob.WithRemoteFS(fsys))
+	var pathErr *fs.PathError
+	if !errors.Is(err, fs.ErrPermission) || !errors.As(err, &pathErr) || pathErr.Op != "readdir" || pathErr.Path != "a" {
+		t.Errorf("Glob(%q) returned error %v, want readdir a: %v", "a/*", err, fs.ErrPermission)
+	}
+	for pattern, want := range map[string][]string{"b/*": {"b/a"}, "a": {"a"}} {
+		got, err := glob.Glob(context.Background(), pattern, glob.WithRemoteFS(fsys))
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Glob(%q) returned diff (-want +got):\n%s", pattern, diff)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package globtest
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+// TxtarTree returns the tree of files of a txtar archive, as used by the go
+// command's tests: each file starts with a line "-- name --", and its contents
+// are the lines up to the next such line. Text before the first file is a
+// comment. A name ending in a slash is an empty directory.
+//
+//	-- logs/app.log --
+//	started
+//	-- logs/old/ --
+func TxtarTree(archive string) fstest.MapFS {
+	tree := fstest.MapFS{}
+	var name string
+	var data strings.Builder
+	flush := func() {
+		if name == "" {
+			return
+		}
+		if dir := strings.TrimSuffix(name, "/"); dir != name {
+			tree[dir] = &fstest.MapFile{Mode: fs.ModeDir | 0777}
+		} else {
+			tree[name] = &fstest.MapFile{Data: []byte(data.String())}
+		}
+		data.Reset()
+	}
+	for _, line := range strings.SplitAfter(archive, "\n") {
+		if n, ok := txtarName(line); ok {
+			flush()
+			name = n
+			continue
+		}
+		if name != "" {
+			data.WriteString(line)
+		}
+	}
+	flush()
+	return tree
+}
+
+// txtarName returns the name of the file that line starts, if it does.
+func txtarName(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "-- ") || !strings.HasSuffix(line, " --") || len(line) < len("-- x --") {
+		return "", false
+	}
+	return strings.TrimSpace(line[len("-- ") : len(line)-len(" --")]), true
+}
+
+// TempTree writes tree to a new temporary directory, removed when t ends, and
+// returns the directory. It fails t if tree can't be written.
+func TempTree(t testing.TB, tree fs.FS) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := WriteTree(dir, tree); err != nil {
+		t.Fatalf("writing tree to %s: %v", dir, err)
+	}
+	return dir
+}
+
+// Matches returns the matches of pattern, relative to root, with glob.Glob
+// and opts. The pattern and the matches are slash-separated and relative to
+// root, so the same test runs on every platform. It fails t if Glob returns an
+// error.
+func Matches(t testing.TB, root, pattern string, opts ...glob.Option) []string {
+	t.Helper()
+	matches, err := globIn(context.Background(), root, pattern, opts...)
+	if err != nil {
+		t.Fatalf("Glob(%q) in %s returned unexpected error: %v", pattern, root, err)
+	}
+	return matches
+}
+
+// CheckMatches checks that the matches of pattern in root, as returned by
+// Matches, are want, in any order, reporting a diff to t if they aren't.
+func CheckMatches(t testing.TB, root, pattern string, want []string, opts ...glob.Option) {
+	t.Helper()
+	got := Matches(t, root, pattern, opts...)
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(x, y string) bool { return x < y }), cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("Glob(%q) returned diff (-want +got):\n%s", pattern, diff)
+	}
+}
+
+// globIn runs glob.Glob with a slash-separated pattern relative to root.
+func globIn(ctx context.Context, root, pattern string, opts ...glob.Option) ([]string, error) {
+	prefix := filepath.ToSlash(root) + "/"
+	opts = append([]glob.Option{glob.WithForwardSlashes()}, opts...)
+	matches, err := glob.Glob(ctx, glob.Escape(prefix)+pattern, opts...)
+	for i, m := range matches {
+		matches[i] = strings.TrimPrefix(m, prefix)
+	}
+	return matches, err
+}
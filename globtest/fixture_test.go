@@ -0,0 +1,53 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package globtest
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+func TestTxtarTree(t *testing.T) {
+	tree := TxtarTree(`A comment.
+-- logs/app.log --
+started
+stopped
+-- logs/empty.log --
+-- logs/old/ --
+-- README --
+-- not a name
+`)
+	want := fstest.MapFS{
+		"logs/app.log":   {Data: []byte("started\nstopped\n")},
+		"logs/empty.log": {Data: []byte{}},
+		"logs/old":       {Mode: fs.ModeDir | 0777},
+		"README":         {Data: []byte("-- not a name\n")},
+	}
+	if diff := cmp.Diff(want, tree); diff != "" {
+		t.Errorf("TxtarTree returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckMatches(t *testing.T) {
+	root := TempTree(t, TxtarTree(`
+-- logs/app.log --
+-- logs/app.log.1 --
+-- logs/old/ --
+-- logs/web/access.log --
+`))
+	CheckMatches(t, root, "logs/*", []string{"logs/app.log", "logs/app.log.1", "logs/old", "logs/web"})
+	CheckMatches(t, root, "logs/**/*.log", []string{"logs/app.log", "logs/web/access.log"}, glob.WithGlobstar())
+	CheckMatches(t, root, "logs/old/*", nil)
+	if got := Matches(t, root, "logs/web/*", glob.WithSortedOrder()); !cmp.Equal(got, []string{"logs/web/access.log"}) {
+		t.Errorf("Matches returned %q", got)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+// Package globtest checks that globbing implementations behave like glob.Stream.
+//
+// Engines that live outside this module, such as ones backed by an index or a
+// remote store, can run TestEngine from their own tests to verify that they
+// meet the same contract as the package they stand in for.
+//
+// Tests of code that uses glob can build their fixtures with the helpers of
+// this package: TxtarTree or an fstest.MapFS describes a tree, TempTree
+// writes it to a temporary directory, and CheckMatches globs it and reports
+// any difference from the expected matches. FaultFS injects failures and
+// delays into the filesystem operations of a glob, and VerifyNoLeaks checks
+// that no Stream was left running.
+package globtest
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// An Engine finds the matches of patterns in a tree of files.
+type Engine interface {
+	// Glob returns every match of pattern, in any order. Both the pattern and
+	// the matches are slash-separated and relative to the root of the tree
+	// the Engine was created for.
+	//
+	// The pattern syntax is that of filepath.Match, applied to each path
+	// element. A malformed pattern must be reported with an error wrapping
+	// filepath.ErrBadPattern once a directory is matched against it.
+	Glob(ctx context.Context, pattern string) ([]string, error)
+}
+
+// NewEngine returns an Engine whose files are those of tree. It may fail t if
+// the Engine cannot be set up.
+type NewEngine func(t *testing.T, tree fs.FS) Engine
+
+// Tree is the tree of files TestEngine passes to its NewEngine.
+var Tree = fstest.MapFS{
+	"a/a":             {Data: []byte("aa")},
+	"a/b":             {Data: []byte("ab")},
+	"a/c":             {Data: []byte("ac")},
+	"b/a":             {Data: []byte("ba")},
+	"dir/sub/file.go": {Data: []byte("package sub\n")},
+	"dir/sub/file.c":  {},
+	"dir/empty":       {Mode: fs.ModeDir | 0777},
+	".dot":            {},
+	".hidden/x":       {},
+}
+
+// engineTests lists the matches of each pattern in Tree in sorted order.
+var engineTests = []struct {
+	pattern string
+	want    []string
+	wantErr error
+}{
+	{pattern: "a", want: []string{"a"}},
+	{pattern: "a/b", want: []string{"a/b"}},
+	{pattern: "nonexistent", want: nil},
+	{pattern: "*", want: []string{".dot", ".hidden", "a", "b", "dir"}},
+	{pattern: ".*", want: []string{".dot", ".hidden"}},
+	{pattern: "a/*", want: []string{"a/a", "a/b", "a/c"}},
+	{pattern: "*/a", want: []string{"a/a", "b/a"}},
+	{pattern: "?/?", want: []string{"a/a", "a/b", "a/c", "b/a"}},
+	{pattern: "a/[ab]", want: []string{"a/a", "a/b"}},
+	{pattern: "a/[^a]", want: []string{"a/b", "a/c"}},
+	{pattern: "a/[b-z]", want: []string{"a/b", "a/c"}},
+	{pattern: "*/*/*.go", want: []string{"dir/sub/file.go"}},
+	{pattern: "dir/*", want: []string{"dir/empty", "dir/sub"}},
+	{pattern: "dir/empty/*", want: nil},
+	{pattern: "a/a/*", want: nil},
+	{pattern: "nonexistent/*", want: nil},
+	{pattern: "[", wantErr: filepath.ErrBadPattern},
+	{pattern: "a/[", wantErr: filepath.ErrBadPattern},
+	{pattern: "*/[]", wantErr: filepath.ErrBadPattern},
+}
+
+// TestEngine checks that the Engine returned by newEngine for Tree behaves
+// like glob.Stream.
+func TestEngine(t *testing.T, newEngine NewEngine) {
+	e := newEngine(t, Tree)
+	for _, tt := range engineTests {
+		got, err := e.Glob(context.Background(), tt.pattern)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Glob(%q) returned error %v, want %v", tt.pattern, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, cmpopts.SortSlices(func(x, y string) bool { return x < y }), cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Glob(%q) returned diff (-want +got):\n%s", tt.pattern, diff)
+		}
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := e.Glob(ctx, "*/*"); err == nil {
+			t.Errorf("Glob with a canceled context returned no error")
+		}
+	})
+}
+
+// TestSortedEngine checks that the Engine returned by newEngine for Tree
+// behaves like glob.Stream with glob.WithSortedOrder: in addition to what
+// TestEngine checks, the matches must come in the order filepath.Glob returns
+// them.
+func TestSortedEngine(t *testing.T, newEngine NewEngine) {
+	TestEngine(t, newEngine)
+
+	e := newEngine(t, Tree)
+	for _, tt := range engineTests {
+		if tt.wantErr != nil {
+			continue
+		}
+		got, err := e.Glob(context.Background(), tt.pattern)
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Glob(%q) returned diff in sorted order (-want +got):\n%s", tt.pattern, diff)
+		}
+	}
+}
+
+// WriteTree writes the files and directories of tree to dir, which must
+// exist.
+func WriteTree(dir string, tree fs.FS) error {
+	return fs.WalkDir(tree, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(p))
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0777)
+		}
+		data, err := fs.ReadFile(tree, p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dst, data, 0666)
+	})
+}
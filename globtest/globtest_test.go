@@ -0,0 +1,66 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package globtest
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+// streamEngine is an Engine backed by glob.Glob on a copy of the tree in a
+// temporary directory.
+type streamEngine struct {
+	root string
+	opts []glob.Option
+}
+
+func (e streamEngine) Glob(ctx context.Context, pattern string) ([]string, error) {
+	return globIn(ctx, e.root, pattern, e.opts...)
+}
+
+func TestSortedStream(t *testing.T) {
+	TestSortedEngine(t, func(t *testing.T, tree fs.FS) Engine {
+		return streamEngine{root: TempTree(t, tree), opts: []glob.Option{glob.WithSortedOrder(), glob.WithConcurrency(4)}}
+	})
+}
+
+func TestStream(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []glob.Option
+	}{
+		{name: "Default"},
+		{name: "Concurrency", opts: []glob.Option{glob.WithConcurrency(4)}},
+		{name: "InodeOrder", opts: []glob.Option{glob.WithInodeOrder()}},
+		{name: "YieldEvery", opts: []glob.Option{glob.WithYieldEvery(1)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			TestEngine(t, func(t *testing.T, tree fs.FS) Engine {
+				return streamEngine{root: TempTree(t, tree), opts: tt.opts}
+			})
+		})
+	}
+}
+
+// fsEngine is an Engine backed by glob.Glob on the tree itself, through
+// glob.WithFS.
+type fsEngine struct {
+	tree fs.FS
+}
+
+func (e fsEngine) Glob(ctx context.Context, pattern string) ([]string, error) {
+	return glob.Glob(ctx, pattern, glob.WithFS(e.tree))
+}
+
+func TestFS(t *testing.T) {
+	TestEngine(t, func(t *testing.T, tree fs.FS) Engine {
+		return fsEngine{tree: tree}
+	})
+}
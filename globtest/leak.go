@@ -0,0 +1,85 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package globtest
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+// leakGrace is how long VerifyNoLeaks waits for goroutines to end.
+const leakGrace = 5 * time.Second
+
+// VerifyNoLeaks fails the test if goroutines of the glob package are still
+// running, as those of a Stream that was neither exhausted nor closed are:
+//
+//	func TestSearch(t *testing.T) {
+//		defer globtest.VerifyNoLeaks(t)
+//		...
+//	}
+//
+// A Stream's goroutines end shortly after it is closed, rather than there and
+// then, so VerifyNoLeaks gives them a few seconds to. It reports every
+// goroutine of the glob package, its subpackages and the code they call back
+// into, other than those of tests; tests that call it mustn't run in parallel
+// with others that use the glob package.
+func VerifyNoLeaks(t testing.TB) {
+	t.Helper()
+	deadline := time.Now().Add(leakGrace)
+	for {
+		leaked := leakedGoroutines()
+		if len(leaked) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("globtest: %d goroutines of the glob package are still running:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// leakedGoroutines returns the stacks of the goroutines other than the
+// calling one that are running code of the glob package, or were started by
+// it, and aren't those of tests.
+func leakedGoroutines() []string {
+	pkg := reflect.TypeOf(glob.Result{}).PkgPath()
+	self := reflect.TypeOf(FaultFS{}).PkgPath()
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	// The first stack is the calling goroutine's.
+	stacks := strings.Split(string(buf), "\n\n")[1:]
+	var leaked []string
+	for _, s := range stacks {
+		if strings.Contains(s, "testing.tRunner(") {
+			continue
+		}
+		for _, line := range strings.Split(s, "\n") {
+			line = strings.TrimPrefix(line, "created by ")
+			if strings.HasPrefix(line, self+".") {
+				continue
+			}
+			if strings.HasPrefix(line, pkg+".") || strings.HasPrefix(line, pkg+"/") {
+				leaked = append(leaked, s)
+				break
+			}
+		}
+	}
+	return leaked
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package globtest
+
+import (
+	"testing"
+
+	glob "github.com/google/go-streaming-globber"
+)
+
+func TestVerifyNoLeaks(t *testing.T) {
+	VerifyNoLeaks(t)
+
+	// A Stream that is neither read to the end nor closed leaks its
+	// goroutines.
+	r := glob.Stream("*/*", glob.WithFS(Tree))
+	if m, err := r.Next(); err != nil || m == "" {
+		t.Fatalf("Next() = %q, %v, want a match", m, err)
+	}
+	if leaked := leakedGoroutines(); len(leaked) == 0 {
+		t.Error("No goroutines leaked by a Stream that wasn't closed")
+	}
+	r.Close()
+	VerifyNoLeaks(t)
+}
@@ -0,0 +1,290 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewWebDAVFS returns a RemoteFS, for WithRemoteFS, of the WebDAV collection
+// at base, which is listed with PROPFIND requests made with client. Names are
+// paths relative to base, so the URL of a match m is base.JoinPath(m).
+//
+// Requests are not canceled when the Stream is; use a client with a Timeout to
+// bound them.
+func NewWebDAVFS(client *http.Client, base *url.URL) RemoteFS {
+	return &httpFS{client: client, base: base, webdav: true}
+}
+
+// NewHTTPIndexFS is like NewWebDAVFS, but lists directories by reading the
+// index pages that web servers such as Apache and nginx generate for them:
+// the links of a page that lead to the directory's own children are its
+// entries, and those ending in a slash are directories.
+//
+// Index pages don't reliably give sizes or modification times, so options that
+// filter matches by them cost a HEAD request per file.
+func NewHTTPIndexFS(client *http.Client, base *url.URL) RemoteFS {
+	return &httpFS{client: client, base: base}
+}
+
+// httpFS is a tree of files served over HTTP.
+type httpFS struct {
+	client *http.Client
+	base   *url.URL
+	webdav bool
+	// dirs remembers the directories seen in listings, so that stat'ing
+	// them, as glob does before reading them, doesn't cost a request.
+	dirs sync.Map
+}
+
+// url returns the URL of name, as a directory if dir is set.
+func (h *httpFS) url(name string, dir bool) *url.URL {
+	u := h.base.JoinPath(strings.TrimPrefix(name, "/"))
+	if dir && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u
+}
+
+// do makes a request for u, returning an error for unsuccessful statuses.
+func (h *httpFS) do(op, name, method string, u *url.URL, header http.Header, body string) (*http.Response, error) {
+	req, err := http.NewRequest(method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		err = fs.ErrNotExist
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = fs.ErrPermission
+	default:
+		err = fmt.Errorf("%s %s: %s", method, u.Redacted(), resp.Status)
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (h *httpFS) Stat(name string) (fs.FileInfo, error) {
+	base := path.Base(name)
+	if _, ok := h.dirs.Load(path.Clean(name)); ok {
+		return remoteInfo{name: base, dir: true}, nil
+	}
+	if h.webdav {
+		infos, err := h.propfind("stat", name, "0")
+		if err != nil {
+			return nil, err
+		}
+		if len(infos) == 0 {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+		}
+		fi := infos[0].(*remoteInfo)
+		fi.name = base
+		return fi, nil
+	}
+	if name := path.Clean(name); name == "." || name == "/" {
+		return remoteInfo{name: base, dir: true}, nil
+	}
+	// Servers redirect the URL of a directory without its trailing slash to
+	// the one with it, if they don't 404 it.
+	resp, err := h.do("stat", name, http.MethodHead, h.url(name, false), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if strings.HasSuffix(resp.Request.URL.Path, "/") {
+		return remoteInfo{name: base, dir: true}, nil
+	}
+	fi := remoteInfo{name: base, modTime: lastModified(resp.Header)}
+	if resp.ContentLength > 0 {
+		fi.size = resp.ContentLength
+	}
+	return fi, nil
+}
+
+// Lstat is Stat: symbolic links aren't visible over HTTP.
+func (h *httpFS) Lstat(name string) (fs.FileInfo, error) {
+	return h.Stat(name)
+}
+
+func (h *httpFS) ReadDir(name string) ([]fs.FileInfo, error) {
+	var infos []fs.FileInfo
+	var err error
+	if h.webdav {
+		infos, err = h.propfind("readdir", name, "1")
+		if len(infos) > 0 {
+			// The collection itself comes first.
+			infos = infos[1:]
+		}
+	} else {
+		infos, err = h.index(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range infos {
+		if fi.IsDir() {
+			h.dirs.Store(path.Join(name, fi.Name()), true)
+		}
+	}
+	return infos, nil
+}
+
+// propfindBody asks for the properties that remoteInfo reports.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getlastmodified/></prop></propfind>`
+
+// multistatus is the body of a response to PROPFIND.
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				Collection    *struct{} `xml:"DAV: resourcetype>collection"`
+				ContentLength string    `xml:"DAV: getcontentlength"`
+				LastModified  string    `xml:"DAV: getlastmodified"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// propfind returns the properties of name, and also of its members if depth
+// is "1", in the order the server lists them.
+func (h *httpFS) propfind(op, name, depth string) ([]fs.FileInfo, error) {
+	u := h.url(name, depth == "1")
+	header := http.Header{"Depth": {depth}, "Content-Type": {"application/xml; charset=utf-8"}}
+	resp, err := h.do(op, name, "PROPFIND", u, header, propfindBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	var infos []fs.FileInfo
+	for _, r := range ms.Responses {
+		href, err := resp.Request.URL.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		fi := &remoteInfo{name: path.Base(strings.TrimSuffix(href.Path, "/"))}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			fi.dir = fi.dir || ps.Prop.Collection != nil
+			if n, err := strconv.ParseInt(ps.Prop.ContentLength, 10, 64); err == nil {
+				fi.size = n
+			}
+			if t, err := http.ParseTime(ps.Prop.LastModified); err == nil {
+				fi.modTime = t
+			}
+		}
+		infos = append(infos, fi)
+	}
+	return infos, nil
+}
+
+// hrefPattern matches the links of an index page. Index pages are generated
+// and simple enough that this does without a full HTML parser.
+var hrefPattern = regexp.MustCompile(`(?i)<a\s[^>]*?href\s*=\s*["']([^"']*)["']`)
+
+// index returns the entries of the directory name listed by its index page.
+func (h *httpFS) index(name string) ([]fs.FileInfo, error) {
+	resp, err := h.do("readdir", name, http.MethodGet, h.url(name, true), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	page, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	dir := resp.Request.URL
+	seen := map[string]bool{}
+	var infos []fs.FileInfo
+	for _, m := range hrefPattern.FindAllSubmatch(page, -1) {
+		href, err := dir.Parse(xmlUnescape(string(m[1])))
+		if err != nil || href.Host != dir.Host || href.RawQuery != "" {
+			// Such as links to sort the listing.
+			continue
+		}
+		child, ok := strings.CutPrefix(href.Path, dir.Path)
+		isDir := strings.HasSuffix(child, "/")
+		child = strings.TrimSuffix(child, "/")
+		if !ok || child == "" || strings.Contains(child, "/") || seen[child] {
+			continue
+		}
+		seen[child] = true
+		if isDir {
+			infos = append(infos, &remoteInfo{name: child, dir: true})
+		} else {
+			infos = append(infos, &indexFile{remoteInfo: remoteInfo{name: child}, h: h, path: path.Join(name, child)})
+		}
+	}
+	return infos, nil
+}
+
+// xmlUnescape replaces the character references of s, such as &amp;.
+func xmlUnescape(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	var t string
+	if err := xml.Unmarshal([]byte("<a>"+s+"</a>"), &t); err != nil {
+		return s
+	}
+	return t
+}
+
+// lastModified returns the time of the Last-Modified header, or the zero time.
+func lastModified(header http.Header) time.Time {
+	t, _ := http.ParseTime(header.Get("Last-Modified"))
+	return t
+}
+
+// indexFile describes a file listed on an index page, whose size and
+// modification time are only known once it is stat'ed, on first use.
+type indexFile struct {
+	remoteInfo
+	h    *httpFS
+	path string
+	once sync.Once
+}
+
+func (fi *indexFile) stat() {
+	fi.once.Do(func() {
+		if st, err := fi.h.Stat(fi.path); err == nil {
+			fi.size, fi.modTime = st.Size(), st.ModTime()
+		}
+	})
+}
+
+func (fi *indexFile) Size() int64        { fi.stat(); return fi.size }
+func (fi *indexFile) ModTime() time.Time { fi.stat(); return fi.modTime }
@@ -0,0 +1,163 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// davHandler serves fsys under /files/ as a WebDAV collection for PROPFIND,
+// and with http.FileServer, which generates index pages, otherwise.
+func davHandler(fsys fs.FS) http.Handler {
+	files := http.StripPrefix("/files", http.FileServer(http.FS(fsys)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PROPFIND" {
+			files.ServeHTTP(w, r)
+			return
+		}
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/files"), "/")
+		if name == "" {
+			name = "."
+		}
+		fi, err := fs.Stat(fsys, name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		infos := []fs.FileInfo{fi}
+		hrefs := []string{r.URL.Path}
+		if fi.IsDir() && r.Header.Get("Depth") == "1" {
+			entries, _ := fs.ReadDir(fsys, name)
+			for _, e := range entries {
+				info, _ := e.Info()
+				infos = append(infos, info)
+				hrefs = append(hrefs, (&url.URL{Path: path.Join(r.URL.Path, e.Name())}).EscapedPath())
+			}
+		}
+		w.WriteHeader(207)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:">`)
+		for i, fi := range infos {
+			prop := fmt.Sprintf("<D:getcontentlength>%d</D:getcontentlength>", fi.Size())
+			if fi.IsDir() {
+				prop = "<D:resourcetype><D:collection/></D:resourcetype>"
+			}
+			fmt.Fprintf(w, `<D:response><D:href>%s</D:href><D:propstat><D:prop>%s<D:getlastmodified>%s</D:getlastmodified></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`,
+				html.EscapeString(hrefs[i]), prop, fi.ModTime().UTC().Format(http.TimeFormat))
+		}
+		fmt.Fprint(w, `</D:multistatus>`)
+	})
+}
+
+func TestGlobHTTP(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tree := fstest.MapFS{
+		"pub/linux/v1.0.tar.gz":       {Data: []byte("v1.0"), ModTime: modTime},
+		"pub/linux/v1.1.tar.gz":       {Data: []byte("v1.1"), ModTime: modTime},
+		"pub/linux/v1.1.tar.gz.sig":   {ModTime: modTime},
+		"pub/linux/docs/README":       {ModTime: modTime},
+		"pub/bsd/v2.tar.gz":           {ModTime: modTime},
+		"pub/with space/a & b.tar.gz": {Data: []byte("ab"), ModTime: modTime},
+		"robots.txt":                  {ModTime: modTime},
+	}
+	srv := httptest.NewServer(davHandler(tree))
+	defer srv.Close()
+	base, err := url.Parse(srv.URL + "/files/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "*", want: []string{"pub", "robots.txt"}},
+		{pattern: "pub/*/*.tar.gz", want: []string{"pub/bsd/v2.tar.gz", "pub/linux/v1.0.tar.gz", "pub/linux/v1.1.tar.gz", "pub/with space/a & b.tar.gz"}},
+		{pattern: "pub/*/*.tar.gz", opts: []Option{WithNonEmptyFiles()}, want: []string{"pub/linux/v1.0.tar.gz", "pub/linux/v1.1.tar.gz", "pub/with space/a & b.tar.gz"}},
+		{pattern: "pub/**/README", opts: []Option{WithGlobstar()}, want: []string{"pub/linux/docs/README"}},
+		{pattern: "pub/linux/v1.1.tar.gz", want: []string{"pub/linux/v1.1.tar.gz"}},
+		{pattern: "pub/nonexistent/*", want: []string{}},
+	}
+	for _, backend := range []struct {
+		name string
+		fsys RemoteFS
+	}{
+		{"WebDAV", NewWebDAVFS(srv.Client(), base)},
+		{"index", NewHTTPIndexFS(srv.Client(), base)},
+	} {
+		for _, tt := range tests {
+			matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithRemoteFS(backend.fsys))...)
+			if err != nil {
+				t.Errorf("Glob(%q) over %s error: %s", tt.pattern, backend.name, err)
+				continue
+			}
+			if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+				t.Errorf("Bad results from Glob(%q) over %s, -want +got: %v", tt.pattern, backend.name, diff)
+			}
+		}
+	}
+
+	// Matches name URLs relative to the base.
+	if got, want := base.JoinPath("pub/with space/a & b.tar.gz").String(), srv.URL+"/files/pub/with%20space/a%20&%20b.tar.gz"; got != want {
+		t.Errorf("URL of match = %s, want %s", got, want)
+	}
+}
+
+func TestHTTPIndexLinks(t *testing.T) {
+	const page = `<html><body><h1>Index of /mirror</h1><table>
+<tr><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th></tr>
+<tr><td><img src="/icons/back.gif"></td><td><a href="/">Parent Directory</a></td></tr>
+<tr><td><a href="a.iso">a.iso</a></td></tr>
+<tr><td><A HREF='b%20c.iso'>b c.iso</A></td></tr>
+<tr><td><a class="dir" href="sub/">sub/</a></td></tr>
+<tr><td><a href="/mirror/d.iso">d.iso</a></td></tr>
+<tr><td><a href="sub/e.iso">too deep</a></td></tr>
+<tr><td><a href="https://example.com/mirror/f.iso">elsewhere</a></td></tr>
+<tr><td><a href="a.iso">a.iso, again</a></td></tr>
+</table></body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mirror/" {
+			fmt.Fprint(w, page)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	base, err := url.Parse(srv.URL + "/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := NewHTTPIndexFS(srv.Client(), base).ReadDir(".")
+	if err != nil {
+		t.Fatalf("ReadDir error: %s", err)
+	}
+	var got []string
+	for _, fi := range infos {
+		if fi.IsDir() {
+			got = append(got, fi.Name()+"/")
+		} else {
+			got = append(got, fi.Name())
+		}
+	}
+	if diff := cmp.Diff([]string{"a.iso", "b c.iso", "sub/", "d.iso"}, got); diff != "" {
+		t.Errorf("Bad entries of index page, -want +got: %v", diff)
+	}
+}
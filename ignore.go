@@ -0,0 +1,47 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// commonIgnores holds the names of the directories skipped by
+// WithCommonIgnores.
+var commonIgnores = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"__pycache__":  true,
+	"node_modules": true,
+	"target":       true,
+	"vendor":       true,
+}
+
+// excluded reports whether the entry d of directory dir, which matched a
+// wildcard, is to be skipped.
+func (w *walker) excluded(dir string, d fs.DirEntry) bool {
+	if w.opts.commonIgnores && commonIgnores[d.Name()] && d.IsDir() {
+		w.debug("glob: skipping commonly ignored directory", "path", filepath.Join(dir, d.Name()))
+		return true
+	}
+	if w.ignore != nil && w.ignore.ignored(dir, d.Name(), d.IsDir()) {
+		w.debug("glob: skipping ignored entry", "path", filepath.Join(dir, d.Name()))
+		return true
+	}
+	if len(w.opts.filterRules) > 0 && w.ruleExcluded(dir, d.Name(), d.IsDir()) {
+		w.debug("glob: skipping entry excluded by a filter rule", "path", filepath.Join(dir, d.Name()))
+		return true
+	}
+	return false
+}
+
+// excludes reports whether excluded can skip any entries.
+func (w *walker) excludes() bool {
+	return w.ignore != nil || w.opts.commonIgnores || len(w.opts.filterRules) > 0
+}
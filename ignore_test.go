@@ -0,0 +1,69 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobCommonIgnores(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobCommonIgnores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{
+		"main.go",
+		"target", // A file, not a directory: not skipped.
+		".git/config",
+		"node_modules/left-pad/index.js",
+		"vendor/lib/lib.go",
+		"pkg/lib.go",
+		"pkg/__pycache__/lib.pyc",
+	} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"*", []string{"main.go", "pkg", "target"}},
+		{"*/*", []string{"pkg/lib.go"}},
+		{"**/*.go", []string{"main.go", "pkg/lib.go"}},
+		{"vendor/**/*.go", []string{"vendor/lib/lib.go"}},
+		{"*/config", []string{}},
+		{".git/*", []string{".git/config"}},
+	} {
+		pattern := filepath.Join(tmpDir, filepath.FromSlash(tt.pattern))
+		matches, err := Glob(context.Background(), pattern, WithCommonIgnores(), WithGlobstar())
+		if err != nil {
+			t.Errorf("Glob error for %q: %s", pattern, err)
+			continue
+		}
+		want := make([]string, 0)
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(tmpDir, filepath.FromSlash(w)))
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+		}
+	}
+}
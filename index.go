@@ -0,0 +1,354 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// indexVersion is the version of the format written by Index.WriteTo.
+const indexVersion = 1
+
+// An Index holds the paths of a directory tree in memory, so that patterns
+// can be matched against the tree much faster than by reading it, at the cost
+// of seeing it as it was when the Index was built or last refreshed:
+//
+//	ix, err := glob.BuildIndex(ctx, "/src/monorepo")
+//	...
+//	matches, err := ix.Glob(ctx, "**/BUILD", glob.WithGlobstar())
+//
+// An Index records the names and types of entries, and the modification times
+// of directories. Other metadata, as needed by options such as WithMinSize, is
+// read from the tree when used. Matches are slash-separated paths relative to
+// the root of the Index, as with WithFS. Symbolic links are indexed but not
+// followed.
+//
+// An Index can be saved with WriteTo and loaded with ReadIndex. It is safe for
+// concurrent use, including Refresh concurrently with queries.
+type Index struct {
+	root string
+
+	mu     sync.RWMutex
+	dirs   map[string]*indexDir // by slash-separated path, "." for root
+	verify bool                 // see SetVerify
+}
+
+// indexDir is the listing of a directory in an Index.
+type indexDir struct {
+	ModTime time.Time
+	Entries []indexEntry // sorted by name
+}
+
+// indexEntry is an entry of a directory in an Index.
+type indexEntry struct {
+	Name string
+	Type fs.FileMode
+}
+
+// indexFileData is the form in which an Index is written.
+type indexFileData struct {
+	Version int
+	Root    string
+	Dirs    map[string]*indexDir
+}
+
+// BuildIndex reads the tree rooted at the directory root into an Index.
+// Directories below root that can't be read are left out, to be tried again by
+// Refresh.
+func BuildIndex(ctx context.Context, root string) (*Index, error) {
+	ix := &Index{root: root}
+	if err := ix.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// ReadIndex reads an Index written by Index.WriteTo. Its root is the directory
+// it was built from.
+func ReadIndex(r io.Reader) (*Index, error) {
+	var data indexFileData
+	if err := gob.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("glob: reading index: %w", err)
+	}
+	if data.Version != indexVersion {
+		return nil, fmt.Errorf("glob: reading index: unsupported version %d", data.Version)
+	}
+	return &Index{root: data.Root, dirs: data.Dirs}, nil
+}
+
+// WriteTo writes the Index to w, for ReadIndex, and returns the number of
+// bytes written.
+func (ix *Index) WriteTo(w io.Writer) (int64, error) {
+	ix.mu.RLock()
+	data := indexFileData{Version: indexVersion, Root: ix.root, Dirs: ix.dirs}
+	ix.mu.RUnlock()
+	cw := &countingWriter{w: w}
+	err := gob.NewEncoder(cw).Encode(&data)
+	return cw.n, err
+}
+
+// Root returns the directory the Index was built from.
+func (ix *Index) Root() string {
+	return ix.root
+}
+
+// Refresh brings the Index up to date with the tree. Only directories whose
+// modification times have changed since they were indexed are read again, so
+// that refreshing an unchanged tree costs a stat per directory; a change made
+// within the resolution of the filesystem's timestamps of the directory being
+// indexed may go unnoticed. An Index being refreshed keeps answering queries
+// as it was, until Refresh returns.
+func (ix *Index) Refresh(ctx context.Context) error {
+	ix.mu.RLock()
+	old := ix.dirs
+	ix.mu.RUnlock()
+	dirs := make(map[string]*indexDir, len(old))
+	if err := ix.scan(ctx, ".", old, dirs); err != nil {
+		return err
+	}
+	ix.mu.Lock()
+	ix.dirs = dirs
+	ix.mu.Unlock()
+	return nil
+}
+
+// scan indexes the directory name and the directories below it into dirs,
+// reusing the listings in old of those that haven't changed.
+func (ix *Index) scan(ctx context.Context, name string, old, dirs map[string]*indexDir) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	p := filepath.Join(ix.root, filepath.FromSlash(name))
+	fi, err := os.Lstat(p)
+	if err == nil && !fi.IsDir() {
+		err = &fs.PathError{Op: "scan", Path: p, Err: errors.New("not a directory")}
+	}
+	if err != nil {
+		if name == "." {
+			return err
+		}
+		return nil
+	}
+	d, ok := old[name]
+	if !ok || !d.ModTime.Equal(fi.ModTime()) {
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			if name == "." {
+				return err
+			}
+			return nil
+		}
+		d = newIndexDir(fi.ModTime(), entries)
+	}
+	dirs[name] = d
+	for _, e := range d.Entries {
+		if e.Type.IsDir() {
+			if err := ix.scan(ctx, path.Join(name, e.Name), old, dirs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetVerify sets whether queries check each directory they read against the
+// tree, at the cost of a stat per directory. A directory whose modification
+// time has changed since it was indexed is read from the tree instead, and
+// one that no longer exists is taken to be empty, so that matches in it are
+// current; the Index itself is left as it is until Refresh.
+func (ix *Index) SetVerify(verify bool) {
+	ix.mu.Lock()
+	ix.verify = verify
+	ix.mu.Unlock()
+}
+
+// Glob is like the package-level Glob, matching pattern against the Index.
+func (ix *Index) Glob(ctx context.Context, pattern string, opts ...Option) ([]string, error) {
+	return Glob(ctx, pattern, append(opts[:len(opts):len(opts)], WithFS(ix))...)
+}
+
+// Stream is like the package-level Stream, matching pattern against the Index.
+func (ix *Index) Stream(pattern string, opts ...Option) Result {
+	return Stream(pattern, append(opts[:len(opts):len(opts)], WithFS(ix))...)
+}
+
+// lookup returns the listing of the directory name, or the entry name in the
+// listing of its directory.
+func (ix *Index) lookup(name string) (*indexDir, indexEntry, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if d, ok := ix.dirs[name]; ok {
+		return d, indexEntry{Name: path.Base(name), Type: fs.ModeDir}, true
+	}
+	d, ok := ix.dirs[path.Dir(name)]
+	if !ok {
+		return nil, indexEntry{}, false
+	}
+	base := path.Base(name)
+	i := sort.Search(len(d.Entries), func(i int) bool { return d.Entries[i].Name >= base })
+	if i == len(d.Entries) || d.Entries[i].Name != base {
+		return nil, indexEntry{}, false
+	}
+	return nil, d.Entries[i], true
+}
+
+// Open opens the file name, which is slash-separated and relative to the root
+// of the Index, as for fs.FS. Directories are read from the Index, and files
+// from the tree.
+func (ix *Index) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	d, e, ok := ix.lookup(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	var err error
+	if d == nil {
+		if e.Type.IsDir() {
+			// A directory that couldn't be read.
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return os.Open(ix.real(name))
+	}
+	ix.mu.RLock()
+	verify := ix.verify
+	ix.mu.RUnlock()
+	if verify {
+		if d, err = ix.verified(name, d); err != nil {
+			return nil, err
+		}
+	}
+	entries := make([]fs.DirEntry, len(d.Entries))
+	for i, e := range d.Entries {
+		entries[i] = fs.FileInfoToDirEntry(ix.info(path.Join(name, e.Name), e))
+	}
+	info := ix.info(name, indexEntry{Name: path.Base(name), Type: fs.ModeDir})
+	info.modTime, info.once = d.ModTime, nil
+	return &indexDirFile{info: info, entries: entries}, nil
+}
+
+// verified returns the listing d of the directory name as it is in the tree,
+// for SetVerify.
+func (ix *Index) verified(name string, d *indexDir) (*indexDir, error) {
+	p := ix.real(name)
+	fi, err := os.Lstat(p)
+	if err != nil {
+		return nil, err
+	}
+	if fi.ModTime().Equal(d.ModTime) {
+		return d, nil
+	}
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	return newIndexDir(fi.ModTime(), entries), nil
+}
+
+// newIndexDir returns the listing in an Index of a directory read from disk.
+func newIndexDir(modTime time.Time, entries []fs.DirEntry) *indexDir {
+	d := &indexDir{ModTime: modTime, Entries: make([]indexEntry, len(entries))}
+	for i, e := range entries {
+		d.Entries[i] = indexEntry{Name: e.Name(), Type: e.Type()}
+	}
+	return d
+}
+
+// Stat returns a FileInfo describing the file name, following a symbolic link
+// on disk.
+func (ix *Index) Stat(name string) (fs.FileInfo, error) {
+	fi, err := ix.Lstat(name)
+	if err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+		return fi, err
+	}
+	return os.Stat(ix.real(name))
+}
+
+// Lstat returns a FileInfo describing the file name, without following a
+// symbolic link.
+func (ix *Index) Lstat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrInvalid}
+	}
+	d, e, ok := ix.lookup(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	fi := ix.info(name, e)
+	if d != nil {
+		fi.modTime, fi.once = d.ModTime, nil
+	}
+	return fi, nil
+}
+
+// real returns the path on disk of the file name of the Index.
+func (ix *Index) real(name string) string {
+	return filepath.Join(ix.root, filepath.FromSlash(name))
+}
+
+func (ix *Index) info(name string, e indexEntry) *indexInfo {
+	return &indexInfo{remoteInfo: remoteInfo{name: e.Name, dir: e.Type.IsDir(), mode: e.Type}, path: ix.real(name), once: &sync.Once{}}
+}
+
+// indexInfo describes a file of an Index, whose size and modification time
+// are only read from disk on first use.
+type indexInfo struct {
+	remoteInfo
+	path string
+	once *sync.Once // nil once known
+}
+
+func (fi *indexInfo) stat() {
+	if fi.once == nil {
+		return
+	}
+	fi.once.Do(func() {
+		if st, err := os.Lstat(fi.path); err == nil {
+			fi.size, fi.modTime = st.Size(), st.ModTime()
+		}
+	})
+}
+
+func (fi *indexInfo) Size() int64        { fi.stat(); return fi.size }
+func (fi *indexInfo) ModTime() time.Time { fi.stat(); return fi.modTime }
+
+// indexDirFile is a directory of an Index being read.
+type indexDirFile struct {
+	info    *indexInfo
+	entries []fs.DirEntry // not yet returned
+}
+
+func (d *indexDirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *indexDirFile) Close() error               { return nil }
+
+func (d *indexDirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.path, Err: errors.New("is a directory")}
+}
+
+func (d *indexDirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n > 0 && len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n <= 0 || n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIndex(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestIndex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	write := func(name, data string) {
+		t.Helper()
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"BUILD", "a/BUILD", "a/a.go", "a/b/BUILD", "a/b/b.go", "c/c.go"} {
+		write(name, name)
+	}
+	write("a/empty.go", "")
+	write("c/.gitignore", "*.go\n")
+
+	ix, err := BuildIndex(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "**/BUILD", opts: []Option{WithGlobstar()}, want: []string{"BUILD", "a/BUILD", "a/b/BUILD"}},
+		{pattern: "*/*.go", want: []string{"a/a.go", "a/empty.go", "c/c.go"}},
+		{pattern: "*/*.go", opts: []Option{WithNonEmptyFiles()}, want: []string{"a/a.go", "c/c.go"}},
+		{pattern: "**/*.go", opts: []Option{WithGlobstar(), WithGitignore()}, want: []string{"a/a.go", "a/b/b.go", "a/empty.go"}},
+		{pattern: "a/b", want: []string{"a/b"}},
+		{pattern: "d/*", want: []string{}},
+	}
+	check := func(ix *Index, when string) {
+		t.Helper()
+		for _, tt := range tests {
+			matches, err := ix.Glob(context.Background(), tt.pattern, tt.opts...)
+			if err != nil {
+				t.Errorf("Glob(%q) %s error: %s", tt.pattern, when, err)
+				continue
+			}
+			if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+				t.Errorf("Bad results from Glob(%q) %s, -want +got: %v", tt.pattern, when, diff)
+			}
+		}
+	}
+	check(ix, "from the built index")
+
+	var b bytes.Buffer
+	if _, err := ix.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadIndex(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Root() != tmpDir {
+		t.Errorf("Root() = %q after ReadIndex, want %q", read.Root(), tmpDir)
+	}
+	check(read, "from the index read back")
+
+	// Changes are seen once the index is refreshed. Modification times are
+	// set explicitly, as the changes may come sooner than the filesystem's
+	// timestamps tick.
+	write("d/d.go", "d")
+	if err := os.RemoveAll(filepath.Join(tmpDir, "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	for _, dir := range []string{".", "a"} {
+		if err := os.Chtimes(filepath.Join(tmpDir, dir), later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check(read, "before Refresh")
+	if err := read.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tests = []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "**/BUILD", opts: []Option{WithGlobstar()}, want: []string{"BUILD", "a/BUILD"}},
+		{pattern: "*/*.go", want: []string{"a/a.go", "a/empty.go", "c/c.go", "d/d.go"}},
+	}
+	check(read, "after Refresh")
+
+	if _, err := BuildIndex(context.Background(), filepath.Join(tmpDir, "nonexistent")); err == nil {
+		t.Errorf("BuildIndex of a nonexistent directory succeeded, want error")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+)
+
+const inodeOrderSupported = true
+
+// entriesByInode returns the entries of the directory f, whose path is dir,
+// sorted by inode number.
+func entriesByInode(dir string, f *os.File) ([]fs.DirEntry, error) {
+	type entry struct {
+		ino uint64
+		de  fs.DirEntry
+	}
+	var entries []entry
+
+	r, err := newGetdentsReader(dir, f)
+	if err != nil {
+		return nil, err
+	}
+	// f is closed by the caller.
+	defer direntBufs.Put(r.buf)
+	for {
+		name, typ, ino, ok, err := r.raw()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		d, err := newDirent(dir, name, typ)
+		if err != nil {
+			// The entry was removed since the directory was read.
+			continue
+		}
+		entries = append(entries, entry{ino: ino, de: d})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ino < entries[j].ino })
+	des := make([]fs.DirEntry, len(entries))
+	for i, e := range entries {
+		des[i] = e.de
+	}
+	return des, nil
+}
+
+// dirent is an fs.DirEntry built from a raw directory entry.
+type dirent struct {
+	dir  string
+	name string
+	typ  fs.FileMode
+}
+
+// newDirent returns the entry name of dir, whose d_type is typ. Entries of
+// unknown type are looked up.
+func newDirent(dir, name string, typ uint8) (*dirent, error) {
+	d := &dirent{}
+	if err := d.set(dir, name, typ); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// set makes d the entry name of dir, as for newDirent.
+func (d *dirent) set(dir, name string, typ uint8) error {
+	*d = dirent{dir: dir, name: name}
+	switch typ {
+	case syscall.DT_REG:
+	case syscall.DT_DIR:
+		d.typ = fs.ModeDir
+	case syscall.DT_LNK:
+		d.typ = fs.ModeSymlink
+	case syscall.DT_FIFO:
+		d.typ = fs.ModeNamedPipe
+	case syscall.DT_SOCK:
+		d.typ = fs.ModeSocket
+	case syscall.DT_CHR:
+		d.typ = fs.ModeDevice | fs.ModeCharDevice
+	case syscall.DT_BLK:
+		d.typ = fs.ModeDevice
+	default:
+		fi, err := os.Lstat(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		d.typ = fi.Mode().Type()
+	}
+	return nil
+}
+
+func (d *dirent) Name() string               { return d.name }
+func (d *dirent) IsDir() bool                { return d.typ.IsDir() }
+func (d *dirent) Type() fs.FileMode          { return d.typ }
+func (d *dirent) Info() (fs.FileInfo, error) { return os.Lstat(filepath.Join(d.dir, d.name)) }
+func (d *dirent) String() string             { return fs.FormatDirEntry(d) }
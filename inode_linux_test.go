@@ -0,0 +1,67 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEntriesByInode(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestEntriesByInode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var want []string
+	for i := 0; i < 500; i++ {
+		name := fmt.Sprintf("file-%03d", i)
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, name)
+	}
+
+	f, err := os.Open(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	entries, err := entriesByInode(tmpDir, f)
+	if err != nil {
+		t.Fatalf("entriesByInode returned unexpected error: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type() != 0 {
+			t.Errorf("entriesByInode returned %v for a regular file", e)
+		}
+		names = append(names, e.Name())
+	}
+	if diff := cmp.Diff(want, names, sortStringSlices); diff != "" {
+		t.Errorf("entriesByInode returned wrong names, -want +got: %v", diff)
+	}
+
+	inode := func(name string) uint64 {
+		fi, err := os.Lstat(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Sys().(*syscall.Stat_t).Ino
+	}
+	if !sort.SliceIsSorted(names, func(i, j int) bool { return inode(names[i]) < inode(names[j]) }) {
+		t.Errorf("entriesByInode returned names out of inode order")
+	}
+}
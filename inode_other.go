@@ -0,0 +1,27 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build !linux
+
+package glob
+
+import (
+	"io/fs"
+	"os"
+)
+
+const (
+	inodeOrderSupported = false
+	getdentsSupported   = false
+)
+
+func entriesByInode(dir string, f *os.File) ([]fs.DirEntry, error) {
+	panic("unreachable")
+}
+
+func newGetdentsReader(dir string, f *os.File) (dirReader, error) {
+	panic("unreachable")
+}
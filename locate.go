@@ -0,0 +1,157 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mlocateMagic begins an mlocate database.
+const mlocateMagic = "\x00mlocate"
+
+// ReadLocateDB reads a database written by the updatedb of mlocate, usually
+// /var/lib/mlocate/mlocate.db, into an Index, so that patterns can be matched
+// against the tree it lists without reading it:
+//
+//	f, err := os.Open("/var/lib/mlocate/mlocate.db")
+//	...
+//	ix, err := glob.ReadLocateDB(bufio.NewReader(f))
+//	...
+//	ix.SetVerify(true)
+//	matches, err := ix.Glob(ctx, "etc/**/*.conf", glob.WithGlobstar())
+//
+// The root of the Index is the directory the database was built from, usually
+// "/", and matches are relative to it. The database records directories'
+// modification times, so the Index can be checked against the tree as it is
+// queried with SetVerify, or brought up to date with Refresh, which reads only
+// the directories that changed since updatedb ran. The database doesn't tell
+// symbolic links from files, nor follow them.
+//
+// The databases of plocate are compressed with zstd, which the standard
+// library doesn't support, and can't be read; plocate-build reads mlocate
+// databases, so a system that keeps one for plocate has one to read here.
+//
+// Like any Index, the one returned doesn't apply the access checks that
+// locate makes on behalf of unprivileged users for a database with its
+// visibility flag set: a program reading such a database for other users must
+// make them itself.
+func ReadLocateDB(r io.Reader) (*Index, error) {
+	br := bufio.NewReader(r)
+	fail := func(err error) (*Index, error) {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("glob: reading locate database: %w", err)
+	}
+
+	var header struct {
+		Magic      [8]byte
+		ConfigSize uint32
+		Version    uint8
+		Visibility uint8
+		_          [2]byte
+	}
+	if err := binary.Read(br, binary.BigEndian, &header); err != nil {
+		return fail(err)
+	}
+	if string(header.Magic[:]) != mlocateMagic {
+		return fail(errors.New("not an mlocate database"))
+	}
+	if header.Version != 0 {
+		return fail(fmt.Errorf("unsupported version %d", header.Version))
+	}
+	root, err := readCString(br)
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := io.CopyN(io.Discard, br, int64(header.ConfigSize)); err != nil {
+		return fail(err)
+	}
+
+	dirs := make(map[string]*indexDir)
+	for {
+		var dirHeader struct {
+			Sec  uint64
+			Nsec uint32
+			_    [4]byte
+		}
+		if err := binary.Read(br, binary.BigEndian, &dirHeader); err == io.EOF {
+			break
+		} else if err != nil {
+			return fail(err)
+		}
+		dir, err := readCString(br)
+		if err != nil {
+			return fail(err)
+		}
+		name, ok := locateRel(root, dir)
+		if !ok {
+			return fail(fmt.Errorf("directory %q outside of root %q", dir, root))
+		}
+		d := &indexDir{ModTime: time.Unix(int64(dirHeader.Sec), int64(dirHeader.Nsec))}
+		for {
+			typ, err := br.ReadByte()
+			if err != nil {
+				return fail(err)
+			}
+			if typ == 2 {
+				// The end of the directory.
+				break
+			}
+			if typ > 2 {
+				return fail(fmt.Errorf("bad entry type %d in %q", typ, dir))
+			}
+			e, err := readCString(br)
+			if err != nil {
+				return fail(err)
+			}
+			var mode fs.FileMode
+			if typ == 1 {
+				mode = fs.ModeDir
+			}
+			d.Entries = append(d.Entries, indexEntry{Name: e, Type: mode})
+		}
+		sort.Slice(d.Entries, func(i, j int) bool { return d.Entries[i].Name < d.Entries[j].Name })
+		dirs[name] = d
+	}
+	if _, ok := dirs["."]; !ok {
+		return fail(fmt.Errorf("no listing of root %q", root))
+	}
+	return &Index{root: root, dirs: dirs}, nil
+}
+
+// readCString reads a NUL-terminated string.
+func readCString(br *bufio.Reader) (string, error) {
+	b, err := br.ReadBytes(0)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(b, []byte{0})), nil
+}
+
+// locateRel returns the path of the directory dir of a locate database,
+// relative to its root, as in an Index.
+func locateRel(root, dir string) (string, bool) {
+	if dir == root {
+		return ".", true
+	}
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	if !strings.HasPrefix(dir, prefix) {
+		return "", false
+	}
+	return path.Clean(dir[len(prefix):]), true
+}
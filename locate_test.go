@@ -0,0 +1,140 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// mlocateDB returns an mlocate database of the tree below root, as updatedb
+// would write it.
+func mlocateDB(t *testing.T, root string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	config := "prune_bind_mounts\x000\x00\x00"
+	b.WriteString(mlocateMagic)
+	binary.Write(&b, binary.BigEndian, uint32(len(config)))
+	b.Write([]byte{0, 1, 0, 0})
+	b.WriteString(root + "\x00")
+	b.WriteString(config)
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.IsDir() {
+			return err
+		}
+		binary.Write(&b, binary.BigEndian, uint64(fi.ModTime().Unix()))
+		binary.Write(&b, binary.BigEndian, uint32(fi.ModTime().Nanosecond()))
+		b.Write(make([]byte, 4))
+		b.WriteString(p + "\x00")
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			typ := byte(0)
+			if e.IsDir() {
+				typ = 1
+			}
+			b.WriteByte(typ)
+			b.WriteString(e.Name() + "\x00")
+		}
+		b.WriteByte(2)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestReadLocateDB(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestReadLocateDB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpDir = filepath.ToSlash(tmpDir)
+	for _, name := range []string{"etc/hosts", "etc/ssh/sshd.conf", "etc/app.conf", "usr/lib/libc.so"} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db := mlocateDB(t, tmpDir)
+
+	ix, err := ReadLocateDB(bytes.NewReader(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ix.Root() != tmpDir {
+		t.Errorf("Root() = %q, want %q", ix.Root(), tmpDir)
+	}
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "etc/**/*.conf", opts: []Option{WithGlobstar()}, want: []string{"etc/app.conf", "etc/ssh/sshd.conf"}},
+		{pattern: "*/*", want: []string{"etc/app.conf", "etc/hosts", "etc/ssh", "usr/lib"}},
+		{pattern: "usr/lib/*.so", want: []string{"usr/lib/libc.so"}},
+	}
+	for _, tt := range tests {
+		matches, err := ix.Glob(context.Background(), tt.pattern, tt.opts...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	// Queries verified against the tree see changes since the database
+	// was written, but only in directories whose modification times
+	// changed.
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "etc", "new.conf"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(tmpDir, "etc"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"etc/app.conf", "etc/new.conf"}
+	matches, err := ix.Glob(context.Background(), "etc/*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want[:1], matches, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q) without verifying, -want +got: %v", "etc/*.conf", diff)
+	}
+	ix.SetVerify(true)
+	matches, err = ix.Glob(context.Background(), "etc/*.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q) verifying, -want +got: %v", "etc/*.conf", diff)
+	}
+
+	for _, bad := range [][]byte{nil, []byte("\x00mlocatX"), db[:len(db)-1]} {
+		if _, err := ReadLocateDB(bytes.NewReader(bad)); err == nil || !strings.HasPrefix(err.Error(), "glob: reading locate database") {
+			t.Errorf("ReadLocateDB of a bad database returned %v, want error", err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"sync/atomic"
+)
+
+// debug logs msg with the key-value pairs args at debug level, if WithLogger
+// set a logger.
+func (w *walker) debug(msg string, args ...any) {
+	if w.opts.debug != nil {
+		w.opts.debug(msg, args...)
+	}
+}
+
+// skipped logs and counts that the directory or symbolic link p is skipped
+// because it couldn't be stat'ed. Files deleted while the Stream runs are
+// taken never to have existed, and aren't logged or counted in Stats. Errors
+// remembered by a DirCache are counted, but were logged when first met.
+func (w *walker) skipped(p string, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	atomic.AddInt64(&w.errs, 1)
+	if !errors.As(err, new(cachedError)) {
+		w.debug("glob: skipping path that can't be stat'ed", "path", p, "err", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// MatchPaths returns the paths that match pattern, in the order they are
+// given, as Stream would match them if they existed and nothing else did, so
+// that a list of paths from elsewhere, such as the files a change touches,
+// can be filtered with the same rules:
+//
+//	changed, err := glob.MatchPaths("src/**/*.go", files, glob.WithGlobstar())
+//
+// Like Globber.Match, which it is for many paths, it doesn't touch the
+// filesystem; the paths may use forward slashes on Windows too, and are
+// returned as they are given. MatchPaths returns a *PatternError if pattern
+// is malformed.
+func MatchPaths(pattern string, paths []string, opts ...Option) ([]string, error) {
+	match, err := newPathMatcher(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, p := range paths {
+		if match(p) {
+			matches = append(matches, p)
+		}
+	}
+	return matches, nil
+}
+
+// newPathMatcher returns a function that reports whether a path matches
+// pattern read with opts, as described for Globber.Match.
+func newPathMatcher(pattern string, opts []Option) (func(name string) bool, error) {
+	w := &walker{}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+	// As for Stream, the pattern is checked as given.
+	if err := validate(filepath.FromSlash(pattern), w.opts.posixClasses); err != nil {
+		return nil, err
+	}
+	patterns := []string{pattern}
+	if w.opts.braces {
+		patterns = expandBraces(pattern)
+	}
+	// dirOnly records which of the expanded patterns end in a separator.
+	var dirOnly []bool
+	for i, p := range patterns {
+		p, err := w.expand(p)
+		if err != nil {
+			return nil, err
+		}
+		if err := validate(p, false); err != nil {
+			return nil, err
+		}
+		var d bool
+		p, d = splitDirOnly(p)
+		patterns[i] = cleanPattern(p)
+		dirOnly = append(dirOnly, d)
+	}
+	return func(name string) bool {
+		name = filepath.FromSlash(name)
+		_, isDir := splitDirOnly(name)
+		name = filepath.Clean(name)
+		for i, p := range patterns {
+			if (isDir || !dirOnly[i]) && matchPath(p, name, &w.opts) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// matchPath reports whether the relative path name matches pattern, as Stream
+// would with o if name existed, but without touching the filesystem. Both use
+// the operating system's separator, and are clean. With WithGlobstar, a "**"
+// element matches any number of path elements, including none, and a "**N"
+// element up to N of them; with WithMinimatch, names beginning with a dot are
+// hidden from wildcards as they are from Stream.
+//
+// pattern must be valid.
+func matchPath(pattern, name string, o *options) bool {
+	sep := string(filepath.Separator)
+	return matchPathElems(strings.Split(pattern, sep), strings.Split(name, sep), o)
+}
+
+func matchPathElems(pattern, elems []string, o *options) bool {
+	for len(pattern) > 0 {
+		if depth, ok := globstarDepth(pattern[0]); ok && o.globstar {
+			for i := 0; i <= len(elems) && (depth == 0 || i <= depth); i++ {
+				if matchPathElems(pattern[1:], elems[i:], o) {
+					return true
+				}
+				if i < len(elems) && o.hiddenFrom(elems[i], "**") {
+					break
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], elems[0]); !ok || o.hiddenFrom(elems[0], pattern[0]) {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
+
+// cleanPattern returns pattern without its "." and empty elements, and
+// without the ".." elements that follow a literal element along with that
+// element, as filepath.Clean leaves the names matched against it. Other ".."
+// elements are kept, as the parent of what a wildcard matches is not known.
+func cleanPattern(pattern string) string {
+	vol := filepath.VolumeName(pattern)
+	rest := pattern[len(vol):]
+	sep := string(filepath.Separator)
+	var elems []string
+	for _, e := range strings.Split(rest, sep) {
+		switch {
+		case e == "" || e == ".":
+		case e == ".." && len(elems) > 0 && elems[len(elems)-1] != ".." && !hasMeta(elems[len(elems)-1]):
+			elems = elems[:len(elems)-1]
+		default:
+			elems = append(elems, e)
+		}
+	}
+	clean := strings.Join(elems, sep)
+	if strings.HasPrefix(rest, sep) {
+		clean = sep + clean
+	} else if clean == "" {
+		clean = "."
+	}
+	return vol + clean
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestMatchPaths(t *testing.T) {
+	paths := []string{
+		"README.md",
+		"src/main.go",
+		"src/cmd/tool/main.go",
+		"./src/util.go",
+		"src/data/",
+		"docs/guide.md",
+		"vendor/x/y.go",
+	}
+	for _, tt := range []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "src/*.go", want: []string{"src/main.go", "./src/util.go"}},
+		{pattern: "src/**/*.go", opts: []Option{WithGlobstar()}, want: []string{"src/main.go", "src/cmd/tool/main.go", "./src/util.go"}},
+		// Without WithGlobstar, "**" is "*".
+		{pattern: "src/**/*.go", want: []string{}},
+		{pattern: "*.md", opts: []Option{WithBasenameMatch()}, want: []string{"README.md", "docs/guide.md"}},
+		{pattern: "{src,vendor}/*/", opts: []Option{WithBraceExpansion()}, want: []string{"src/data/"}},
+		{pattern: "(?i)readme*", opts: []Option{WithInlineFlags()}, want: []string{"README.md"}},
+	} {
+		got, err := MatchPaths(tt.pattern, paths, tt.opts...)
+		if err != nil {
+			t.Errorf("MatchPaths(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Bad results from MatchPaths(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	if _, err := MatchPaths("[", paths); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("MatchPaths(%q) returned error %v, want %v", "[", err, filepath.ErrBadPattern)
+	}
+}
+
+func TestMatchPathsAgreesWithGlob(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestMatchPathsAgreesWithGlob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	var paths []string
+	for _, name := range []string{
+		".eslintrc",
+		".git/a.js",
+		"README.md",
+		"src/a.go",
+		"src/b/c.go",
+		"src/b/.d.go",
+		"src/.e/f.go",
+		"web/app.js",
+		"web/lib/x.js",
+		"web/.cache/y.js",
+	} {
+		p := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := filepath.Walk(tmpDir, func(p string, _ os.FileInfo, err error) error {
+		if p != tmpDir {
+			rel, _ := filepath.Rel(tmpDir, p)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		opts    []Option
+	}{
+		{pattern: "./src/*.go"},
+		{pattern: "src//*.go"},
+		{pattern: "src/./b/*.go"},
+		{pattern: "src/b/../*.go"},
+		{pattern: "*"},
+		{pattern: "src/**/*.go", opts: []Option{WithGlobstar()}},
+		{pattern: "./src/**", opts: []Option{WithGlobstar()}},
+		{pattern: "*", opts: []Option{WithMinimatch()}},
+		{pattern: ".*", opts: []Option{WithMinimatch()}},
+		{pattern: "**/*.js", opts: []Option{WithMinimatch()}},
+		{pattern: "src/**", opts: []Option{WithMinimatch()}},
+		{pattern: "src/*/*.go", opts: []Option{WithMinimatch()}},
+		{pattern: "src/.*/*.go", opts: []Option{WithMinimatch()}},
+		{pattern: "{src,web}/*", opts: []Option{WithBraceExpansion()}},
+	} {
+		matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithRoot(tmpDir))...)
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		var want []string
+		for _, m := range matches {
+			want = append(want, filepath.ToSlash(filepath.Clean(m)))
+		}
+		got, err := MatchPaths(tt.pattern, paths, tt.opts...)
+		if err != nil {
+			t.Errorf("MatchPaths(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, got, sortStringSlices, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("MatchPaths(%q) disagrees with Glob, -Glob +MatchPaths: %v", tt.pattern, diff)
+		}
+	}
+
+	got, err := MatchPaths("./src/*.go", []string{"src/a.go", "./src/a.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"src/a.go", "./src/a.go"}, got); diff != "" {
+		t.Errorf("Bad results from MatchPaths(%q), -want +got: %v", "./src/*.go", diff)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MergeStreams returns a Result that yields the matches of every one of
+// streams, for globbing several patterns or roots as one:
+//
+//	a := glob.Stream("/srv/a/**/*.log", glob.WithGlobstar())
+//	b := glob.Stream("/srv/b/**/*.log", glob.WithGlobstar())
+//	r := glob.MergeStreams(&a, &b)
+//	defer r.Close()
+//
+// The streams run concurrently, and their matches are interleaved in the
+// order they are found, without any stream holding up the others. If every
+// stream is in path order (see below), the matches are merged in that order
+// instead, each taken in turn from whichever stream has the one that comes
+// first.
+//
+// A stream is in path order if it was created with WithSortedOrder, and its
+// pattern has no "**" (see WithGlobstar) before its last element: its
+// matches are then ordered element by element, as are the paths of a
+// directory tree walked depth first. A pattern such as "**/*.go" matches the
+// files of each directory before those of its subdirectories, which isn't
+// path order.
+//
+// The merged Result fails with the first error of any of the streams, and
+// closing it closes them all; they are also closed once it is exhausted.
+// Matches are written by WriteTo with the separator of the first stream. Its
+// Stats count the work of every stream, and the matches received from the
+// merged Result. The streams mustn't be read from other than through it.
+func MergeStreams(streams ...*Result) Result {
+	return merge(streams, func(ctx context.Context, g *Result) error {
+		if inPathOrder(streams) {
+			return g.mergeSorted(ctx, streams, false)
+		}
+		return g.mergeFair(ctx, streams, nil)
+	})
+}
+
+// merge returns a Result whose matches are sent down its results channel by
+// run, from streams, until ctx is done because the Result is closed. The
+// streams are closed once run returns, and the Result fails with the error
+// run returns.
+func merge(streams []*Result, run func(ctx context.Context, g *Result) error) Result {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := Result{
+		errors:  make(chan error),
+		results: make(chan found),
+		cancel:  cancel,
+		merged:  streams,
+	}
+	w := &walker{fsys: osFS{}, began: time.Now()}
+	if len(streams) > 0 {
+		w.opts.separator, w.opts.separatorSet = streams[0].w.opts.separator, streams[0].w.opts.separatorSet
+	}
+	g.w = w
+	go func() {
+		defer close(g.results)
+		defer close(g.errors)
+		err := run(ctx, &g)
+		for _, s := range streams {
+			s.Close()
+		}
+		g.ended(time.Since(w.began))
+		if err != nil {
+			select {
+			case g.errors <- err:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return g
+}
+
+// inPathOrder reports whether there are streams and all of them are in path
+// order, as described for MergeStreams.
+func inPathOrder(streams []*Result) bool {
+	for _, s := range streams {
+		if !s.w.pathOrder {
+			return false
+		}
+	}
+	return len(streams) > 0
+}
+
+// mergeFair sends the matches of streams down the results channel as each
+// finds them, until they are exhausted, one of them fails, or ctx is done.
+// Only the matches keep returns true for are sent, if keep is set; it is
+// called from one goroutine at a time.
+func (g *Result) mergeFair(ctx context.Context, streams []*Result, keep func(string) bool) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first error
+	var mu sync.Mutex // held by keep
+	for _, s := range streams {
+		s := s
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				m, err := s.next(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						once.Do(func() { first = err })
+						stop()
+					}
+					return
+				}
+				if m.path == "" {
+					return
+				}
+				if keep != nil {
+					mu.Lock()
+					ok := keep(m.path)
+					mu.Unlock()
+					if !ok {
+						continue
+					}
+				}
+				select {
+				case g.results <- m:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return first
+}
+
+// mergeSorted sends the matches of streams, which are in path order, down the
+// results channel in that order, until they are exhausted, one of them fails, or
+// ctx is done. If dedup is set, a match is only sent once, however many
+// times the streams find it.
+func (g *Result) mergeSorted(ctx context.Context, streams []*Result, dedup bool) error {
+	// heads holds the next match of each stream, empty once it's exhausted.
+	heads := make([]found, len(streams))
+	read := func(i int) error {
+		var err error
+		heads[i], err = streams[i].next(ctx)
+		return err
+	}
+	for i := range streams {
+		if err := read(i); err != nil {
+			return ignoreDone(ctx, err)
+		}
+	}
+	last := ""
+	for {
+		first := -1
+		for i, m := range heads {
+			if m.path != "" && (first < 0 || comparePaths(m.path, heads[first].path) < 0) {
+				first = i
+			}
+		}
+		if first < 0 {
+			return nil
+		}
+		if m := heads[first]; !dedup || m.path != last {
+			select {
+			case g.results <- m:
+			case <-ctx.Done():
+				return nil
+			}
+			last = m.path
+		}
+		if err := read(first); err != nil {
+			return ignoreDone(ctx, err)
+		}
+	}
+}
+
+// ignoreDone returns err, or nil if ctx is done, since the merged Result was
+// closed.
+func ignoreDone(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// pathOrdered reports whether the matches of the expanded pattern are in path
+// order with WithSortedOrder, as described for MergeStreams.
+func pathOrdered(pattern string, globstar bool) bool {
+	if !globstar {
+		return true
+	}
+	elems := strings.FieldsFunc(pattern, func(r rune) bool { return r < 0x80 && os.IsPathSeparator(uint8(r)) })
+	for i := 0; i < len(elems)-1; i++ {
+		if _, ok := globstarDepth(elems[i]); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// comparePaths compares the paths a and b element by element, in path order,
+// returning -1, 0 or +1.
+func comparePaths(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ca, cb := a[i], b[i]
+		if ca == cb {
+			continue
+		}
+		// A separator ends an element, so it comes before any other byte.
+		if os.IsPathSeparator(ca) || ca == '/' {
+			ca = 0
+		}
+		if os.IsPathSeparator(cb) || cb == '/' {
+			cb = 0
+		}
+		if ca < cb {
+			return -1
+		}
+		if ca > cb {
+			return +1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return +1
+	}
+	return 0
+}
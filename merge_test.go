@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func mergeFS() fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, dir := range []string{"a", "a-b", "b", "c"} {
+		for i := 0; i < 20; i++ {
+			fsys[fmt.Sprintf("%s/%02d", dir, i)] = &fstest.MapFile{}
+		}
+	}
+	return fsys
+}
+
+// readAll returns the remaining matches of r.
+func readAll(t *testing.T, r *Result) []string {
+	t.Helper()
+	var matches []string
+	for {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == "" {
+			return matches
+		}
+		matches = append(matches, m)
+	}
+}
+
+func TestMergeStreams(t *testing.T) {
+	fsys := mergeFS()
+	a := Stream("a*/*", WithFS(fsys))
+	b := Stream("[bc]/*", WithFS(fsys))
+	r := MergeStreams(&a, &b)
+	got := readAll(t, &r)
+	want, err := Glob(context.Background(), "*/*", WithFS(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from MergeStreams, -want +got: %v", diff)
+	}
+	// Each stream reads the root and two directories.
+	if st := r.Stats(); st.Dirs != 6 || st.Matches != 80 {
+		t.Errorf("MergeStreams Stats() = %+v, want 6 directories read and 80 matches", st)
+	}
+}
+
+func TestMergeStreamsSorted(t *testing.T) {
+	fsys := mergeFS()
+	a := Stream("[ac]*/*", WithFS(fsys), WithSortedOrder())
+	b := Stream("b/*", WithFS(fsys), WithSortedOrder())
+	r := MergeStreams(&a, &b)
+	got := readAll(t, &r)
+	all := Stream("*/*", WithFS(fsys), WithSortedOrder())
+	want := readAll(t, &all)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Bad results from MergeStreams, -want +got: %v", diff)
+	}
+}
+
+func TestMergeStreamsError(t *testing.T) {
+	fsys := mergeFS()
+	a := Stream("a/*", WithFS(fsys))
+	b := Stream("[", WithFS(fsys))
+	r := MergeStreams(&a, &b)
+	for {
+		m, err := r.Next()
+		if errors.Is(err, filepath.ErrBadPattern) {
+			break
+		}
+		if err != nil || m == "" {
+			t.Fatalf("MergeStreams with a malformed pattern: Next() = %q, %v, want %v", m, err, filepath.ErrBadPattern)
+		}
+	}
+}
+
+func TestMergeStreamsClose(t *testing.T) {
+	fsys := mergeFS()
+	a := Stream("*/*", WithFS(fsys))
+	b := Stream("*/*", WithFS(fsys))
+	r := MergeStreams(&a, &b)
+	if m, err := r.Next(); err != nil || m == "" {
+		t.Fatalf("Next() = %q, %v, want a match", m, err)
+	}
+	r.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadInt32(&a.w.ended) == 0 || atomic.LoadInt32(&b.w.ended) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Streams still running after their MergeStreams was closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestComparePaths(t *testing.T) {
+	sep := string(filepath.Separator)
+	ordered := []string{"a", "a" + sep + "b", "a" + sep + "b" + sep + "c", "a" + sep + "c", "a-b", "a-b" + sep + "a", "b"}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = +1
+			}
+			if got := comparePaths(a, b); got != want {
+				t.Errorf("comparePaths(%q, %q) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
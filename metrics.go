@@ -0,0 +1,71 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithMetrics makes each Stream call f once with its final Stats, when both
+// the Stream has ended and its consumer is done with it: Next has returned an
+// empty string or an error, or Close has been called. f is called from the
+// goroutine calling Next or Close, or from the Stream's own, whichever is last.
+//
+// As for tracing (see Tracer), an adapter for a metrics library is a few
+// lines. For Prometheus, with counters and a histogram registered once:
+//
+//	glob.WithMetrics(func(st glob.Stats) {
+//		dirsRead.Add(float64(st.Dirs))
+//		entriesExamined.Add(float64(st.Entries))
+//		matchesFound.Add(float64(st.Matches))
+//		errorsSkipped.Add(float64(st.Errors))
+//		globDuration.Observe(st.Duration.Seconds())
+//	})
+func WithMetrics(f func(Stats)) Option {
+	return func(o *options) {
+		o.metrics = f
+	}
+}
+
+// received counts m, received by the consumer of the Stream, as a match, or
+// the consumer as done if the matches are exhausted.
+func (g *Result) received(m found) {
+	if m.path == "" {
+		g.consumed()
+		return
+	}
+	atomic.AddInt64(&g.w.matched, 1)
+	if g.w.opts.sorted {
+		g.w.lastMu.Lock()
+		g.w.last = m.path
+		g.w.lastMu.Unlock()
+	}
+}
+
+// consumed records that the consumer of the Stream is done with it.
+func (g *Result) consumed() {
+	if atomic.CompareAndSwapInt32(&g.w.consumed, 0, 1) {
+		g.finish()
+	}
+}
+
+// ended records that the Stream's goroutine has ended, after took.
+func (g *Result) ended(took time.Duration) {
+	atomic.StoreInt64(&g.w.took, int64(took))
+	atomic.StoreInt32(&g.w.ended, 1)
+	g.finish()
+}
+
+// finish calls the function set by WithMetrics once both the Stream's
+// goroutine and its consumer are done, so that the final Stats count every
+// match received.
+func (g *Result) finish() {
+	if atomic.AddInt32(&g.w.finished, 1) == 2 && g.w.opts.metrics != nil {
+		g.w.opts.metrics(g.Stats())
+	}
+}
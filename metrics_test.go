@@ -0,0 +1,68 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestGlobMetrics(t *testing.T) {
+	r := &deniedRemote{
+		slowRemote: slowRemote{fsys: fstest.MapFS{
+			"public/x": {},
+			"public/y": {},
+			"secret/x": {},
+			"old/x":    {},
+		}},
+		denied: "secret",
+	}
+	var got []Stats
+	s := Stream("*/x", WithRemoteFS(r), WithMetrics(func(st Stats) { got = append(got, st) }))
+	n := 0
+	for {
+		m, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == "" {
+			break
+		}
+		n++
+	}
+	s.Close()
+	if len(got) != 1 {
+		t.Fatalf("Metrics called %d times, want once", len(got))
+	}
+	st := got[0]
+	if st.Matches != int64(n) || n != 2 {
+		t.Errorf("Stats.Matches = %d with %d matches received, want 2", st.Matches, n)
+	}
+	if st.Errors != 1 {
+		t.Errorf("Stats.Errors = %d, want 1 for secret", st.Errors)
+	}
+	if st.Dirs != 3 {
+		t.Errorf("Stats.Dirs = %d, want 3", st.Dirs)
+	}
+	if st.Duration <= 0 {
+		t.Errorf("Stats.Duration = %v, want positive", st.Duration)
+	}
+	if final := s.Stats(); final != st {
+		t.Errorf("Stats() = %+v after the Stream ended, want the final %+v", final, st)
+	}
+
+	// A Stream closed early is reported once it ends.
+	done := make(chan Stats, 1)
+	s = Stream("*/*", WithRemoteFS(r), WithMetrics(func(st Stats) { done <- st }))
+	if _, err := s.Next(); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if st := <-done; st.Matches != 1 {
+		t.Errorf("Stats.Matches = %d after one match and Close, want 1", st.Matches)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// fileAttribute* are the Windows file attributes that ReadMFT looks at.
+const (
+	fileAttributeDirectory    = 0x10  // FILE_ATTRIBUTE_DIRECTORY
+	fileAttributeReparsePoint = 0x400 // FILE_ATTRIBUTE_REPARSE_POINT
+)
+
+// ReadMFT reads the names of every file on the NTFS volume, such as "C:", from
+// the volume's master file table into an Index rooted at the volume's root
+// directory, such as `C:\`. Enumerating the master file table is much faster
+// than listing every directory, so that recursive patterns over a large part
+// of a volume are best matched against the Index:
+//
+//	ix, err := glob.ReadMFT("C:")
+//	if err != nil {
+//		// Not an administrator, or not NTFS: fall back to reading the tree.
+//		return glob.Glob(ctx, `C:\src\**\*.go`, glob.WithGlobstar())
+//	}
+//	matches, err := ix.Glob(ctx, "src/**/*.go", glob.WithGlobstar())
+//
+// Reading the master file table requires administrator rights and an NTFS
+// volume; ReadMFT fails otherwise, and on other platforms. Files with several
+// hard links are only listed under one of their names, and reparse points,
+// such as symbolic links and junctions, are indexed as symbolic links. The
+// master file table doesn't record modification times, so Refresh and
+// SetVerify read every directory of an Index from ReadMFT.
+func ReadMFT(volume string) (*Index, error) {
+	root, rootRef, records, err := readMFT(volume)
+	if err != nil {
+		return nil, err
+	}
+	return mftIndex(root, rootRef, records), nil
+}
+
+// mftRecord is a file listed by the master file table of an NTFS volume.
+type mftRecord struct {
+	ref, parent uint64 // file reference numbers
+	name        string
+	attrs       uint32
+}
+
+// mftRef returns the part of a file reference number that identifies the
+// record of the file, without its sequence number.
+func mftRef(ref uint64) uint64 {
+	return ref & (1<<48 - 1)
+}
+
+// mftIndex returns an Index, rooted at root, of the files listed by records
+// that are reachable from the directory rootRef.
+func mftIndex(root string, rootRef uint64, records []mftRecord) *Index {
+	children := make(map[uint64][]int)
+	for i, r := range records {
+		if mftRef(r.ref) < 16 {
+			// Records 0 to 15 are reserved for the metadata files of
+			// NTFS, such as $MFT, including the root directory.
+			continue
+		}
+		p := mftRef(r.parent)
+		children[p] = append(children[p], i)
+	}
+
+	dirs := make(map[string]*indexDir)
+	type queued struct {
+		name string
+		ref  uint64
+	}
+	queue := []queued{{".", mftRef(rootRef)}}
+	for len(queue) > 0 {
+		q := queue[0]
+		queue = queue[1:]
+		d := &indexDir{}
+		for _, i := range children[q.ref] {
+			r := records[i]
+			var typ fs.FileMode
+			switch {
+			case r.attrs&fileAttributeReparsePoint != 0:
+				typ = fs.ModeSymlink
+			case r.attrs&fileAttributeDirectory != 0:
+				typ = fs.ModeDir
+				queue = append(queue, queued{path.Join(q.name, r.name), mftRef(r.ref)})
+			}
+			d.Entries = append(d.Entries, indexEntry{Name: r.name, Type: typ})
+		}
+		sort.Slice(d.Entries, func(i, j int) bool { return d.Entries[i].Name < d.Entries[j].Name })
+		dirs[q.name] = d
+	}
+	if !strings.HasSuffix(root, `\`) && !strings.HasSuffix(root, "/") {
+		root += `\`
+	}
+	return &Index{root: root, dirs: dirs}
+}
@@ -0,0 +1,17 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build !windows
+// +build !windows
+
+package glob
+
+import "errors"
+
+// readMFT is only supported on Windows.
+func readMFT(volume string) (root string, rootRef uint64, records []mftRecord, err error) {
+	return "", 0, nil, errors.New("glob: reading the master file table is only supported on Windows")
+}
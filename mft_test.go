@@ -0,0 +1,85 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMFTIndex(t *testing.T) {
+	const seq = 3 << 48 // a sequence number, which references carry
+	records := []mftRecord{
+		{ref: 0, parent: 5, name: "$MFT"},
+		{ref: 11, parent: 5, name: "$Extend", attrs: fileAttributeDirectory},
+		{ref: 25, parent: 11, name: "$UsnJrnl"},
+		{ref: seq | 40, parent: 5, name: "src", attrs: fileAttributeDirectory},
+		{ref: 41, parent: seq | 40, name: "main.go"},
+		{ref: 42, parent: seq | 40, name: "pkg", attrs: fileAttributeDirectory},
+		{ref: 43, parent: 42, name: "pkg.go"},
+		{ref: 44, parent: 5, name: "link", attrs: fileAttributeDirectory | fileAttributeReparsePoint},
+		{ref: 45, parent: 99, name: "orphan.go"},
+	}
+	ix := mftIndex("C:", seq|5, records)
+	if got, want := ix.Root(), `C:\`; got != want {
+		t.Errorf("Root() = %q, want %q", got, want)
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "*", want: []string{"link", "src"}},
+		{pattern: "**/*.go", want: []string{"src/main.go", "src/pkg/pkg.go"}},
+		{pattern: "**", want: []string{"link", "src", "src/main.go", "src/pkg", "src/pkg/pkg.go"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		ix.mu.RLock()
+		for dir, d := range ix.dirs {
+			for _, e := range d.Entries {
+				p := e.Name
+				if dir != "." {
+					p = dir + "/" + e.Name
+				}
+				if matchPath(filepath.FromSlash(tt.pattern), filepath.FromSlash(p), &options{globstar: true}) {
+					got = append(got, p)
+				}
+			}
+		}
+		ix.mu.RUnlock()
+		if diff := cmp.Diff(tt.want, got, sortStringSlices); diff != "" {
+			t.Errorf("Bad paths matching %q in the index, -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		if _, err := ReadMFT("C:"); err == nil {
+			t.Errorf("ReadMFT succeeded on %s, want error", runtime.GOOS)
+		}
+	}
+}
+
+func TestReadMFT(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skipf("skipping test of the master file table on %s", runtime.GOOS)
+	}
+	ix, err := ReadMFT("C:")
+	if err != nil {
+		t.Skipf("skipping test without access to the master file table: %s", err)
+	}
+	matches, err := ix.Glob(context.Background(), "Windows/System32/kernel32.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("Glob of kernel32.dll in the master file table matched %q", matches)
+	}
+}
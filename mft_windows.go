@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fsctlEnumUsnData = 0x000900b3 // FSCTL_ENUM_USN_DATA
+
+	errorInvalidFunction syscall.Errno = 1 // ERROR_INVALID_FUNCTION
+)
+
+// mftEnumDataV0 mirrors MFT_ENUM_DATA_V0.
+type mftEnumDataV0 struct {
+	StartFileReferenceNumber uint64
+	LowUsn                   int64
+	HighUsn                  int64
+}
+
+// usnRecordV2 mirrors USN_RECORD_V2, up to its file name.
+type usnRecordV2 struct {
+	RecordLength              uint32
+	MajorVersion              uint16
+	MinorVersion              uint16
+	FileReferenceNumber       uint64
+	ParentFileReferenceNumber uint64
+	Usn                       int64
+	TimeStamp                 int64
+	Reason                    uint32
+	SourceInfo                uint32
+	SecurityId                uint32
+	FileAttributes            uint32
+	FileNameLength            uint16
+	FileNameOffset            uint16
+}
+
+// readMFT enumerates the files of the volume, such as "C:", with
+// FSCTL_ENUM_USN_DATA, and returns the path and file reference number of its
+// root directory.
+func readMFT(volume string) (root string, rootRef uint64, records []mftRecord, err error) {
+	volume = strings.TrimRight(volume, `\/`)
+	root = volume + `\`
+	fail := func(err error) (string, uint64, []mftRecord, error) {
+		return "", 0, nil, fmt.Errorf("glob: reading the master file table of %s: %w", volume, err)
+	}
+
+	p, err := syscall.UTF16PtrFromString(root)
+	if err != nil {
+		return fail(err)
+	}
+	h, err := syscall.CreateFile(p, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fail(err)
+	}
+	var info syscall.ByHandleFileInformation
+	err = syscall.GetFileInformationByHandle(h, &info)
+	syscall.CloseHandle(h)
+	if err != nil {
+		return fail(err)
+	}
+	rootRef = uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow)
+
+	// Opening the volume itself takes administrator rights.
+	p, err = syscall.UTF16PtrFromString(`\\.\` + volume)
+	if err != nil {
+		return fail(err)
+	}
+	h, err = syscall.CreateFile(p, syscall.GENERIC_READ, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE, nil, syscall.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return fail(err)
+	}
+	defer syscall.CloseHandle(h)
+
+	med := mftEnumDataV0{HighUsn: math.MaxInt64}
+	buf := make([]byte, 1<<16)
+	for {
+		var n uint32
+		err := syscall.DeviceIoControl(h, fsctlEnumUsnData, (*byte)(unsafe.Pointer(&med)), uint32(unsafe.Sizeof(med)), &buf[0], uint32(len(buf)), &n, nil)
+		if err == errorHandleEOF {
+			return root, rootRef, records, nil
+		}
+		if err == errorInvalidFunction {
+			return fail(fmt.Errorf("not an NTFS volume: %w", err))
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if n < 8 {
+			return root, rootRef, records, nil
+		}
+		// The output is the reference number to continue from, followed
+		// by records.
+		med.StartFileReferenceNumber = *(*uint64)(unsafe.Pointer(&buf[0]))
+		for off := uint32(8); off+uint32(unsafe.Sizeof(usnRecordV2{})) <= n; {
+			r := (*usnRecordV2)(unsafe.Pointer(&buf[off]))
+			if r.RecordLength == 0 {
+				break
+			}
+			if r.MajorVersion == 2 {
+				name := unsafe.Slice((*uint16)(unsafe.Pointer(&buf[off+uint32(r.FileNameOffset)])), r.FileNameLength/2)
+				records = append(records, mftRecord{
+					ref:    r.FileReferenceNumber,
+					parent: r.ParentFileReferenceNumber,
+					name:   syscall.UTF16ToString(name),
+					attrs:  r.FileAttributes,
+				})
+			}
+			off += r.RecordLength
+		}
+	}
+}
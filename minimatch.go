@@ -0,0 +1,73 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"runtime"
+	"strings"
+)
+
+// WithMinimatch reads patterns the way node's minimatch, and so node-glob,
+// eslint and prettier, do, so that a pattern shared with JavaScript tooling
+// matches the same files:
+//
+//   - "**" and brace expressions are expanded, as with WithGlobstar and
+//     WithBraceExpansion, and a path matched by several alternatives is
+//     yielded once, while a file reached both through a symbolic link and
+//     through its real path is yielded under each path;
+//   - a wildcard doesn't match a name beginning with a dot unless the pattern
+//     element does too, as in `.*` or `.eslintrc.*`, and "**" doesn't match
+//     or descend into such names at all;
+//   - "[!a-z]" is a negated class, as "[^a-z]" is, and a class may hold POSIX
+//     character classes, as with WithPOSIXClasses.
+//
+// Negated patterns starting with "!", extended globs such as "+(a|b)", and
+// the options of minimatch are not supported; a leading "!" is an ordinary
+// character.
+func WithMinimatch() Option {
+	return func(o *options) {
+		o.minimatch = true
+		o.globstar = true
+		o.braces = true
+		o.posixClasses = true
+	}
+}
+
+// minimatchClasses rewrites the "[!" that starts a negated class in minimatch
+// to the "[^" of filepath.Match.
+func minimatchClasses(pattern string) string {
+	if !strings.Contains(pattern, "[!") {
+		return pattern
+	}
+	escapes := runtime.GOOS != "windows"
+	b := []byte(pattern)
+	inClass := false
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '\\' && escapes:
+			i++
+		case inClass:
+			inClass = b[i] != ']'
+		case b[i] == '[':
+			inClass = true
+			if i+1 < len(b) && b[i+1] == '!' {
+				b[i+1] = '^'
+			}
+		}
+	}
+	return string(b)
+}
+
+// hiddenFrom reports whether minimatch keeps the entry named name from being
+// matched by the pattern element, because the name begins with a dot and the
+// element doesn't.
+func (o *options) hiddenFrom(name, pattern string) bool {
+	if !o.minimatch || !strings.HasPrefix(name, ".") {
+		return false
+	}
+	return !strings.HasPrefix(pattern, ".") && !(runtime.GOOS != "windows" && strings.HasPrefix(pattern, `\.`))
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobMinimatch(t *testing.T) {
+	fsys := fstest.MapFS{
+		".eslintrc.json":   {},
+		"README.md":        {},
+		"src/.hidden/a.js": {},
+		"src/b.js":         {},
+		"src/c.ts":         {},
+		"src/lib/.d.js":    {},
+		"src/lib/e.js":     {},
+	}
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"*", []string{"README.md", "src"}},
+		{".*", []string{".eslintrc.json"}},
+		{".eslintrc.*", []string{".eslintrc.json"}},
+		{"src/**/*.js", []string{"src/b.js", "src/lib/e.js"}},
+		{"src/.hidden/*.js", []string{"src/.hidden/a.js"}},
+		{"src/**/.*", []string{"src/.hidden", "src/lib/.d.js"}},
+		{"src/*.{js,ts}", []string{"src/b.js", "src/c.ts"}},
+		{"src/[!b]*", []string{"src/c.ts", "src/lib"}},
+		// Matched by both alternatives, but yielded once.
+		{"src/{**/,}e.js", []string{"src/lib/e.js"}},
+	} {
+		got, err := Glob(context.Background(), tt.pattern, WithFS(fsys), WithMinimatch())
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobMinimatchSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("skipping symlink test on Windows")
+	}
+	tmpDir, err := ioutil.TempDir("", "TestGlobMinimatchSymlinks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := os.Mkdir(filepath.Join(tmpDir, "real"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "real", "x"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(tmpDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// As with minimatch, a file is yielded under each path it has.
+	got, err := Glob(context.Background(), "*/x", WithRoot(tmpDir), WithMinimatch())
+	if err != nil {
+		t.Fatalf("Glob(%q) returned unexpected error: %v", "*/x", err)
+	}
+	want := []string{filepath.Join("link", "x"), filepath.Join("real", "x")}
+	if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", "*/x", diff)
+	}
+}
+
+func TestMinimatchClasses(t *testing.T) {
+	for _, tt := range []struct{ pattern, want string }{
+		{"[!a]", "[^a]"},
+		{"a!b", "a!b"},
+		{"[a!]", "[a!]"},
+		{"[a][!b]", "[a][^b]"},
+		{"[^!]", "[^!]"},
+	} {
+		if got := minimatchClasses(tt.pattern); got != tt.want {
+			t.Errorf("minimatchClasses(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
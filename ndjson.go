@@ -0,0 +1,103 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// ndjsonRecord is a line written by NDJSONMatches.
+type ndjsonRecord struct {
+	Path  string     `json:"path,omitempty"`
+	Type  string     `json:"type,omitempty"`
+	Size  *int64     `json:"size,omitempty"`
+	MTime *time.Time `json:"mtime,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// NDJSONMatches writes the remaining matches of r to w as newline-delimited
+// JSON, one object per line, in the order the matches are produced:
+//
+//	{"path":"logs/a.log","type":"file","size":1024,"mtime":"2024-01-02T03:04:05Z"}
+//
+// The type is one of "file", "dir", "symlink", "fifo", "socket", "device",
+// "chardevice" and "other", and describes the match itself rather than the
+// target of a symbolic link. The metadata comes from the directory entry the
+// match was found in, so on Windows, where listings include it, no further
+// system call is made; elsewhere, it costs at most one lstat per match. A
+// match whose metadata can't be read, because it was deleted in the meantime
+// for example, is written with its path and an "error". If the Stream fails,
+// a last object with only an "error" is written, and the error is returned.
+//
+// Each line is written with a single call to w.Write; wrap w in a
+// bufio.Writer to batch them.
+func NDJSONMatches(ctx context.Context, r *Result, w io.Writer) error {
+	defer r.Close()
+	enc := json.NewEncoder(w)
+	for {
+		m, err := r.next(ctx)
+		if err != nil {
+			if werr := enc.Encode(ndjsonRecord{Error: err.Error()}); werr != nil {
+				return werr
+			}
+			return err
+		}
+		if m.path == "" {
+			return nil
+		}
+		if err := enc.Encode(r.record(m)); err != nil {
+			return err
+		}
+	}
+}
+
+// record returns the record of the match m.
+func (r *Result) record(m found) ndjsonRecord {
+	rec := ndjsonRecord{Path: m.path}
+	fi, err := r.info(m)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	size, mtime := fi.Size(), fi.ModTime()
+	rec.Type, rec.Size, rec.MTime = fileType(fi.Mode()), &size, &mtime
+	return rec
+}
+
+// info returns the metadata of the match m, without following a symbolic
+// link, from its directory entry if it has one.
+func (r *Result) info(m found) (fs.FileInfo, error) {
+	if m.d != nil {
+		return m.d.Info()
+	}
+	return r.w.fsys.Lstat(m.path)
+}
+
+// fileType returns the name of the type of files with mode.
+func fileType(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode.IsDir():
+		return "dir"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "chardevice"
+	case mode&fs.ModeDevice != 0:
+		return "device"
+	}
+	return "other"
+}
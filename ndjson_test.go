@@ -0,0 +1,109 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNDJSONMatches(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestNDJSONMatches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Mkdir(filepath.Join(tmpDir, "dir"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "file"), []byte("12345"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"dir", "file"} {
+		if err := os.Chtimes(filepath.Join(tmpDir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]string{"dir": "dir", "file": "file"}
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("file", filepath.Join(tmpDir, "link")); err != nil {
+			t.Fatal(err)
+		}
+		want["link"] = "symlink"
+	}
+
+	type record struct {
+		Path  string     `json:"path"`
+		Type  string     `json:"type"`
+		Size  *int64     `json:"size"`
+		MTime *time.Time `json:"mtime"`
+		Error string     `json:"error"`
+	}
+	decode := func(b []byte) []record {
+		t.Helper()
+		var records []record
+		s := bufio.NewScanner(bytes.NewReader(b))
+		for s.Scan() {
+			var rec record
+			if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+				t.Fatalf("bad line %q: %s", s.Text(), err)
+			}
+			records = append(records, rec)
+		}
+		return records
+	}
+
+	for _, pattern := range []string{"*", "file"} {
+		var b bytes.Buffer
+		r := Stream(filepath.Join(Escape(tmpDir), pattern))
+		if err := NDJSONMatches(context.Background(), &r, &b); err != nil {
+			t.Fatalf("NDJSONMatches(%q) error: %s", pattern, err)
+		}
+		records := decode(b.Bytes())
+		if pattern == "file" && len(records) != 1 || pattern == "*" && len(records) != len(want) {
+			t.Errorf("NDJSONMatches(%q) wrote %d records:\n%s", pattern, len(records), b.String())
+		}
+		for _, rec := range records {
+			name := filepath.Base(rec.Path)
+			if rec.Type != want[name] {
+				t.Errorf("Record of %s has type %q, want %q", name, rec.Type, want[name])
+			}
+			if rec.Size == nil || rec.MTime == nil || rec.Error != "" {
+				t.Errorf("Record of %s lacks metadata: %+v", name, rec)
+				continue
+			}
+			if name == "file" && *rec.Size != 5 {
+				t.Errorf("Record of file has size %d, want 5", *rec.Size)
+			}
+			if name != "link" && !rec.MTime.Equal(mtime) {
+				t.Errorf("Record of %s has mtime %v, want %v", name, rec.MTime, mtime)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	pattern := filepath.Join(Escape(tmpDir), "[") + "/*"
+	r := Stream(pattern)
+	if err := NDJSONMatches(context.Background(), &r, &b); err == nil {
+		t.Errorf("NDJSONMatches with a bad pattern succeeded")
+	}
+	if diff := cmp.Diff([]record{{Error: Validate(pattern).Error()}}, decode(b.Bytes())); diff != "" {
+		t.Errorf("Bad records for a bad pattern, -want +got: %v", diff)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+//go:generate go run gen_norm.go
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type combiningClassRange struct {
+	lo, hi rune
+	class  uint8
+}
+
+// combiningClass returns the canonical combining class of r.
+func combiningClass(r rune) uint8 {
+	i := sort.Search(len(combiningClasses), func(i int) bool { return combiningClasses[i].hi >= r })
+	if i < len(combiningClasses) && combiningClasses[i].lo <= r {
+		return combiningClasses[i].class
+	}
+	return 0
+}
+
+// Hangul syllables are composed and decomposed algorithmically; see the
+// Unicode Standard, section 3.12.
+const (
+	hangulBase   = 0xac00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11a7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulCount  = hangulLCount * hangulNCount
+)
+
+var (
+	compositionsOnce sync.Once
+	compositions     map[[2]rune]rune
+)
+
+// primaryComposite returns the composition of the pair a, b, if any.
+func primaryComposite(a, b rune) (rune, bool) {
+	if a >= hangulLBase && a < hangulLBase+hangulLCount && b >= hangulVBase && b < hangulVBase+hangulVCount {
+		return hangulBase + ((a-hangulLBase)*hangulVCount+b-hangulVBase)*hangulTCount, true
+	}
+	if a >= hangulBase && a < hangulBase+hangulCount && (a-hangulBase)%hangulTCount == 0 && b > hangulTBase && b < hangulTBase+hangulTCount {
+		return a + b - hangulTBase, true
+	}
+
+	compositionsOnce.Do(func() {
+		compositions = make(map[[2]rune]rune)
+		for r, d := range canonicalDecompositions {
+			if d[1] != 0 && !compositionExclusions[r] && combiningClass(d[0]) == 0 {
+				compositions[d] = r
+			}
+		}
+	})
+	r, ok := compositions[[2]rune{a, b}]
+	return r, ok
+}
+
+// decompose appends the full canonical decomposition of r to buf.
+func decompose(buf []rune, r rune) []rune {
+	if r >= hangulBase && r < hangulBase+hangulCount {
+		s := r - hangulBase
+		buf = append(buf, hangulLBase+s/hangulNCount, hangulVBase+s%hangulNCount/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			buf = append(buf, hangulTBase+t)
+		}
+		return buf
+	}
+	d, ok := canonicalDecompositions[r]
+	if !ok {
+		return append(buf, r)
+	}
+	buf = decompose(buf, d[0])
+	if d[1] != 0 {
+		buf = decompose(buf, d[1])
+	}
+	return buf
+}
+
+// nfc returns s in Unicode Normalization Form C, so that canonically
+// equivalent strings, such as a name stored decomposed by HFS+ and the same
+// name typed precomposed, compare equal. Invalid UTF-8 is left as it is.
+func nfc(s string) string {
+	i := 0
+	for i < len(s) && s[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(s) {
+		return s
+	}
+
+	// Decompose.
+	var rs []rune
+	for _, r := range s {
+		rs = decompose(rs, r)
+	}
+
+	// Put each run of combining marks in canonical order.
+	for i := 0; i < len(rs); {
+		if combiningClass(rs[i]) == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(rs) && combiningClass(rs[j]) != 0 {
+			j++
+		}
+		run := rs[i:j]
+		sort.SliceStable(run, func(a, b int) bool { return combiningClass(run[a]) < combiningClass(run[b]) })
+		i = j
+	}
+
+	// Compose each character with the last starter before it, unless a
+	// character in between blocks it.
+	out := rs[:0]
+	starter := -1
+	var lastClass uint8
+	for _, r := range rs {
+		class := combiningClass(r)
+		if starter >= 0 && (len(out) == starter+1 || (lastClass != 0 && lastClass < class)) {
+			if c, ok := primaryComposite(out[starter], r); ok {
+				out[starter] = c
+				continue
+			}
+		}
+		if class == 0 {
+			starter = len(out)
+		}
+		lastClass = class
+		out = append(out, r)
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range out {
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestNFC(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"plain ascii", "plain ascii"},
+		{"caf\u00e9", "caf\u00e9"},
+		{"cafe\u0301", "caf\u00e9"},
+		// Marks are reordered by combining class before composing.
+		{"a\u0301\u0323", "\u1ea1\u0301"},
+		{"a\u0323\u0301", "\u1ea1\u0301"},
+		// A mark of the same class blocks composition with a later one.
+		{"a\u0308\u0301", "\u00e4\u0301"},
+		// Singletons and excluded characters are never composed to.
+		{"\u2126", "\u03a9"},
+		{"\u0915\u093c", "\u0915\u093c"},
+		// Hangul syllables.
+		{"\u1100\u1161\u11a8", "\uac01"},
+		{"\uac00\u11a8", "\uac01"},
+		// Japanese dakuten.
+		{"\u304b\u3099", "\u304c"},
+		{"bad\xffutf8", "bad\ufffdutf8"},
+	} {
+		if got := nfc(tt.in); got != tt.want {
+			t.Errorf("nfc(%+q) = %+q, want %+q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGlobUnicodeNormalization(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestGlobUnicodeNormalization")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Stored decomposed, as HFS+ does.
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "cafe\u0301.txt"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pattern := range []string{
+		filepath.Join(Escape(tmpDir), "caf\u00e9*"),
+		filepath.Join(Escape(tmpDir), "caf?.txt"),
+		// A literal element after a wildcard one.
+		filepath.Join(Escape(filepath.Dir(tmpDir)), "*TestGlobUnicodeNormalization*", "caf\u00e9.txt"),
+	} {
+		matches, err := Glob(context.Background(), pattern, WithUnicodeNormalization())
+		if err != nil {
+			t.Errorf("Glob(%+q) error: %s", pattern, err)
+			continue
+		}
+		found := false
+		for _, m := range matches {
+			if filepath.Dir(m) == tmpDir && nfc(filepath.Base(m)) == "caf\u00e9.txt" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Glob(%+q) with WithUnicodeNormalization = %+q, want the decomposed file", pattern, matches)
+		}
+	}
+
+	if runtime.GOOS == "linux" {
+		// Linux filesystems store names as they are given, so without the
+		// option the composed pattern doesn't match.
+		matches, err := Glob(context.Background(), filepath.Join(Escape(tmpDir), "caf\u00e9*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) != 0 {
+			t.Errorf("Glob without WithUnicodeNormalization = %+q, want no matches", matches)
+		}
+	}
+}
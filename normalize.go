@@ -0,0 +1,141 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import "sort"
+
+// Normalize returns the canonical form of pattern, read as Parse reads it
+// with opts, so that patterns that match the same paths in the same way can
+// be told apart from those that don't, as keys of a cache for example:
+//
+//	glob.Normalize("src/./**/**/[a]*.go", glob.WithGlobstar()) // "src/**/a*.go"
+//
+// It drops "." elements, collapses consecutive "**" elements and runs of
+// "*", puts "?" before "*" in a run of the two, writes a character class of a
+// single character as that character, sorts and merges the ranges of other
+// classes, and escapes only what needs escaping, using the operating
+// system's separator. ".." elements are kept, as what they refer to depends
+// on symbolic links, and so is a trailing separator, which makes the pattern
+// match only directories.
+//
+// Stream yields the match of a pattern without wildcards as the pattern is
+// written, so while the normalized pattern matches the same path, it may spell
+// it differently: "a/./b" yields "a/./b", and its normal form "a/b" yields
+// "a/b". Normalize returns a *PatternError if pattern is malformed.
+func Normalize(pattern string, opts ...Option) (string, error) {
+	p, err := Parse(pattern, opts...)
+	if err != nil {
+		return "", err
+	}
+	p.normalize()
+	return p.String(), nil
+}
+
+// normalize rewrites p in the canonical form of Normalize.
+func (p *Pattern) normalize() {
+	var segs []Segment
+	dots := false
+	for _, s := range p.Segments {
+		_, literal := s.Literal()
+		orig := s
+		s.normalize()
+		if text, ok := s.Literal(); ok && (text == "." || text == "..") && !literal {
+			// A directory listing has no "." or ".." entries for "[.]"
+			// to match, while "." names the directory itself.
+			s = orig
+		} else if ok && text == "." {
+			dots = true
+			continue
+		}
+		if n := len(segs); n > 0 && s.globstar() && segs[n-1].globstar() {
+			// As collapseGlobstars does.
+			if prev := &segs[n-1].Nodes[0]; prev.Depth != 0 {
+				prev.Depth += s.Nodes[0].Depth
+				if s.Nodes[0].Depth == 0 {
+					prev.Depth = 0
+				}
+			}
+			continue
+		}
+		segs = append(segs, s)
+	}
+	if len(segs) == 0 && dots && !p.Rooted {
+		// "." itself.
+		segs = []Segment{{Nodes: []Node{{Kind: LiteralNode, Text: "."}}}}
+	}
+	p.Segments = segs
+}
+
+// globstar reports whether s is a "**" or "**N" element.
+func (s Segment) globstar() bool {
+	return len(s.Nodes) == 1 && s.Nodes[0].Kind == GlobstarNode
+}
+
+// normalize rewrites the nodes of s in the canonical form of Normalize.
+func (s *Segment) normalize() {
+	var nodes []Node
+	// stars and anyChars count the wildcards of the run being read.
+	stars, anyChars := 0, 0
+	flush := func() {
+		for ; anyChars > 0; anyChars-- {
+			nodes = append(nodes, Node{Kind: AnyCharNode})
+		}
+		if stars > 0 {
+			nodes = append(nodes, Node{Kind: StarNode})
+		}
+		stars = 0
+	}
+	for _, n := range s.Nodes {
+		switch n.Kind {
+		case StarNode:
+			stars++
+			continue
+		case AnyCharNode:
+			anyChars++
+			continue
+		case ClassNode:
+			if r := mergeRanges(n.Ranges); len(r) > 0 {
+				n.Ranges = r
+			}
+			if r := n.Ranges; !n.Negated && len(r) == 1 && r[0].Lo == r[0].Hi {
+				n = Node{Kind: LiteralNode, Text: string(r[0].Lo)}
+			}
+		}
+		flush()
+		if last := len(nodes) - 1; n.Kind == LiteralNode && last >= 0 && nodes[last].Kind == LiteralNode {
+			nodes[last].Text += n.Text
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	flush()
+	s.Nodes = nodes
+}
+
+// mergeRanges returns ranges sorted, with overlapping and adjacent ranges
+// merged.
+func mergeRanges(ranges []Range) []Range {
+	var valid []Range
+	for _, r := range ranges {
+		// filepath.Match matches nothing with a backwards range.
+		if r.Lo <= r.Hi {
+			valid = append(valid, r)
+		}
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i].Lo < valid[j].Lo })
+	var merged []Range
+	for _, r := range valid {
+		if last := len(merged) - 1; last >= 0 && r.Lo <= merged[last].Hi+1 {
+			if r.Hi > merged[last].Hi {
+				merged[last].Hi = r.Hi
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, want string
+		globstar      bool
+	}{
+		{pattern: "a/./b", want: "a/b"},
+		{pattern: "./*.go", want: "*.go"},
+		{pattern: "./.", want: "."},
+		{pattern: "/./a", want: "/a"},
+		{pattern: "a//b/", want: "a/b/"},
+		{pattern: "*/./", want: "*/"},
+		{pattern: "a/../b", want: "a/../b"},
+		{pattern: "src/**/**/*.go", want: "src/**/*.go", globstar: true},
+		{pattern: "src/**2/./**1/*.go", want: "src/**3/*.go", globstar: true},
+		{pattern: "**1/**", want: "**", globstar: true},
+		{pattern: "**/**", want: "*/*"},
+		{pattern: "[a]", want: "a"},
+		{pattern: "x[a]y[b]*", want: "xayb*"},
+		{pattern: "[^a]", want: "[^a]"},
+		{pattern: "[cba]", want: "[a-c]"},
+		{pattern: "[a-cb-fx]", want: "[a-fx]"},
+		{pattern: "[z-a]", want: "[z-a]"},
+		{pattern: "a***b", want: "a*b"},
+		{pattern: "*?*?", want: "??*"},
+		// Listings have no "." entries.
+		{pattern: "a/[.]", want: "a/[.]"},
+	} {
+		var opts []Option
+		if tt.globstar {
+			opts = append(opts, WithGlobstar())
+		}
+		got, err := Normalize(tt.pattern, opts...)
+		if err != nil {
+			t.Errorf("Normalize(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if want := filepath.FromSlash(tt.want); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.pattern, got, want)
+		}
+	}
+
+	if _, err := Normalize("[a"); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("Normalize(%q) returned error %v, want %v", "[a", err, filepath.ErrBadPattern)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectLister lists the objects of a bucket in an object store, such as
+// Amazon S3 or Google Cloud Storage, whose keys are flat strings that "/"
+// divides into directories only by convention.
+//
+// An adapter for a particular store is a few lines wrapping its listing call,
+// which keeps the SDKs of object stores out of this package's dependencies.
+// For S3, using the AWS SDK for Go v2:
+//
+//	type s3Lister struct {
+//		client *s3.Client
+//		bucket string
+//	}
+//
+//	func (l s3Lister) ListObjects(ctx context.Context, prefix, delimiter, token string) (*glob.ObjectPage, error) {
+//		in := &s3.ListObjectsV2Input{Bucket: &l.bucket, Prefix: &prefix, Delimiter: &delimiter}
+//		if token != "" {
+//			in.ContinuationToken = &token
+//		}
+//		out, err := l.client.ListObjectsV2(ctx, in)
+//		if err != nil {
+//			return nil, err
+//		}
+//		page := &glob.ObjectPage{NextToken: aws.ToString(out.NextContinuationToken)}
+//		for _, o := range out.Contents {
+//			page.Objects = append(page.Objects, glob.Object{Key: *o.Key, Size: aws.ToInt64(o.Size), ModTime: aws.ToTime(o.LastModified)})
+//		}
+//		for _, p := range out.CommonPrefixes {
+//			page.Prefixes = append(page.Prefixes, *p.Prefix)
+//		}
+//		return page, nil
+//	}
+//
+// and for Google Cloud Storage, using cloud.google.com/go/storage:
+//
+//	type gcsLister struct {
+//		bucket *storage.BucketHandle
+//	}
+//
+//	func (l gcsLister) ListObjects(ctx context.Context, prefix, delimiter, token string) (*glob.ObjectPage, error) {
+//		it := l.bucket.Objects(ctx, &storage.Query{Prefix: prefix, Delimiter: delimiter})
+//		var attrs []*storage.ObjectAttrs
+//		next, err := iterator.NewPager(it, 1000, token).NextPage(&attrs)
+//		if err != nil {
+//			return nil, err
+//		}
+//		page := &glob.ObjectPage{NextToken: next}
+//		for _, a := range attrs {
+//			if a.Prefix != "" {
+//				page.Prefixes = append(page.Prefixes, a.Prefix)
+//			} else {
+//				page.Objects = append(page.Objects, glob.Object{Key: a.Name, Size: a.Size, ModTime: a.Updated})
+//			}
+//		}
+//		return page, nil
+//	}
+type ObjectLister interface {
+	// ListObjects returns a page of the objects whose keys begin with
+	// prefix, in lexical order of their keys. Keys that contain the
+	// delimiter after the prefix are not listed; instead, each distinct
+	// part of such keys up to and including the first delimiter after the
+	// prefix is listed once as a common prefix. token is empty for the
+	// first page and the NextToken of the previous page otherwise.
+	ListObjects(ctx context.Context, prefix, delimiter, token string) (*ObjectPage, error)
+}
+
+// ObjectPage is a page of the results of ObjectLister.ListObjects.
+type ObjectPage struct {
+	Objects  []Object
+	Prefixes []string
+	// NextToken is empty if this is the last page.
+	NextToken string
+}
+
+// Object describes an object in an object store.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// objectFS is the tree of directories of a bucket, for WithObjectLister.
+// Listing a directory queries the objects and common prefixes beginning with
+// its name and a slash, using a slash as the delimiter, a page at a time.
+type objectFS struct {
+	ctx context.Context
+	l   ObjectLister
+	// dirs remembers the directories seen in listings, so that stat'ing
+	// them, as glob does before reading them, doesn't cost a query.
+	dirs sync.Map
+}
+
+func newObjectFS(ctx context.Context, l ObjectLister) *objectFS {
+	return &objectFS{ctx: ctx, l: l}
+}
+
+// objectKey returns the key of name, as a directory if dir is set.
+func objectKey(name string, dir bool) string {
+	name = filepath.ToSlash(name)
+	if name == "." {
+		return ""
+	}
+	if dir {
+		return name + "/"
+	}
+	return name
+}
+
+func (o *objectFS) Open(name string) (fs.File, error) {
+	return o.openPrefix(name, "")
+}
+
+func (o *objectFS) openPrefix(name, prefix string) (fs.File, error) {
+	fi, err := o.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("reading objects is not supported")}
+	}
+	return &objectDir{o: o, info: fi, dir: objectKey(name, true), prefix: prefix}, nil
+}
+
+func (o *objectFS) Stat(name string) (fs.FileInfo, error) {
+	key := objectKey(name, false)
+	if key == "" {
+		return objectInfo{name: ".", dir: true}, nil
+	}
+	if !fs.ValidPath(key) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	if _, ok := o.dirs.Load(key); ok {
+		return objectInfo{name: path.Base(key), dir: true}, nil
+	}
+	// The key itself sorts before every other key it is a prefix of.
+	page, err := o.l.ListObjects(o.ctx, key, "/", "")
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if len(page.Objects) > 0 && page.Objects[0].Key == key {
+		return newObjectInfo(path.Base(key), page.Objects[0]), nil
+	}
+	for _, p := range page.Prefixes {
+		if p == key+"/" {
+			o.dirs.Store(key, true)
+			return objectInfo{name: path.Base(key), dir: true}, nil
+		}
+	}
+	if page.NextToken != "" {
+		// The directory may be listed after other keys with the same
+		// prefix; query it directly.
+		page, err := o.l.ListObjects(o.ctx, key+"/", "/", "")
+		if err != nil {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+		}
+		if len(page.Objects) > 0 || len(page.Prefixes) > 0 {
+			o.dirs.Store(key, true)
+			return objectInfo{name: path.Base(key), dir: true}, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// Lstat is Stat: object stores have no symbolic links.
+func (o *objectFS) Lstat(name string) (fs.FileInfo, error) {
+	return o.Stat(name)
+}
+
+func (o *objectFS) native(name string) (string, bool) {
+	return "", false
+}
+
+// objectDir is a directory of an objectFS being read.
+type objectDir struct {
+	o      *objectFS
+	info   fs.FileInfo
+	dir    string // the key prefix of the directory, ending in a slash
+	prefix string // the prefix of the names to list
+
+	entries []fs.DirEntry // read but not yet returned
+	token   string
+	done    bool
+}
+
+func (d *objectDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *objectDir) Read([]byte) (int, error)   { return 0, io.EOF }
+func (d *objectDir) Close() error               { return nil }
+
+func (d *objectDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	for !d.done && (n <= 0 || len(d.entries) < n) {
+		if err := d.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	if n > 0 && len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n <= 0 || n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// fetch reads the next page of the directory's listing.
+func (d *objectDir) fetch() error {
+	page, err := d.o.l.ListObjects(d.o.ctx, d.dir+d.prefix, "/", d.token)
+	if err != nil {
+		return &fs.PathError{Op: "readdir", Path: d.dir, Err: err}
+	}
+	for _, obj := range page.Objects {
+		// An empty name is a placeholder object for the directory itself.
+		if name := strings.TrimPrefix(obj.Key, d.dir); name != "" && !strings.Contains(name, "/") {
+			d.entries = append(d.entries, fs.FileInfoToDirEntry(newObjectInfo(name, obj)))
+		}
+	}
+	for _, p := range page.Prefixes {
+		key := strings.TrimSuffix(p, "/")
+		if name := strings.TrimPrefix(key, d.dir); name != "" && !strings.Contains(name, "/") {
+			d.o.dirs.Store(key, true)
+			d.entries = append(d.entries, fs.FileInfoToDirEntry(objectInfo{name: name, dir: true}))
+		}
+	}
+	d.token = page.NextToken
+	d.done = d.token == ""
+	return nil
+}
+
+// objectInfo describes an object, or a directory of objects.
+type objectInfo struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+}
+
+func newObjectInfo(name string, obj Object) objectInfo {
+	return objectInfo{name: name, size: obj.Size, modTime: obj.ModTime}
+}
+
+func (fi objectInfo) Name() string       { return fi.name }
+func (fi objectInfo) Size() int64        { return fi.size }
+func (fi objectInfo) ModTime() time.Time { return fi.modTime }
+func (fi objectInfo) IsDir() bool        { return fi.dir }
+func (fi objectInfo) Sys() any           { return nil }
+
+func (fi objectInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
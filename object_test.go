@@ -0,0 +1,157 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// memLister is an ObjectLister of keys held in memory, with pages of
+// pageSize objects and prefixes.
+type memLister struct {
+	keys     []string
+	pageSize int
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (l *memLister) ListObjects(ctx context.Context, prefix, delimiter, token string) (*ObjectPage, error) {
+	l.mu.Lock()
+	l.queries = append(l.queries, prefix)
+	l.mu.Unlock()
+
+	start := 0
+	if token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+	var page ObjectPage
+	for i := start; i < len(l.keys); i++ {
+		k := l.keys[i]
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if len(page.Objects)+len(page.Prefixes) == l.pageSize {
+			page.NextToken = strconv.Itoa(i)
+			break
+		}
+		if j := strings.Index(k[len(prefix):], delimiter); delimiter != "" && j >= 0 {
+			// Like S3 and GCS, list a common prefix once, even across pages.
+			p := k[:len(prefix)+j+1]
+			page.Prefixes = append(page.Prefixes, p)
+			for i+1 < len(l.keys) && strings.HasPrefix(l.keys[i+1], p) {
+				i++
+			}
+			continue
+		}
+		page.Objects = append(page.Objects, Object{Key: k, Size: int64(len(k))})
+	}
+	return &page, nil
+}
+
+func TestGlobObjectLister(t *testing.T) {
+	keys := []string{
+		"README",
+		"logs/",
+		"logs/2023-12/31/a.json.gz",
+		"logs/2024-01/01/a.json.gz",
+		"logs/2024-01/01/b.txt",
+		"logs/2024-01/02/c.json.gz",
+		"logs/2024-01/c.json.gz",
+		"logs/2024-02/d.json.gz",
+		"logs/2024-02x",
+		"logs/2024-03/",
+		"other/2024-01/a.json.gz",
+	}
+	sort.Strings(keys)
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "*", want: []string{"README", "logs", "other"}},
+		{pattern: "logs/2024-*", want: []string{"logs/2024-01", "logs/2024-02", "logs/2024-02x", "logs/2024-03"}},
+		{pattern: "logs/2024-0?/*.json.gz", want: []string{"logs/2024-01/c.json.gz", "logs/2024-02/d.json.gz"}},
+		{
+			pattern: "logs/2024-*/**/*.json.gz",
+			opts:    []Option{WithGlobstar()},
+			want:    []string{"logs/2024-01/01/a.json.gz", "logs/2024-01/02/c.json.gz", "logs/2024-01/c.json.gz", "logs/2024-02/d.json.gz"},
+		},
+		{pattern: "logs/2024-01/01/a.json.gz", want: []string{"logs/2024-01/01/a.json.gz"}},
+		{pattern: "logs/2024-03/*", want: []string{}},
+		{pattern: "logs/2024-01/*/b.txt", opts: []Option{WithConcurrency(4)}, want: []string{"logs/2024-01/01/b.txt"}},
+		{pattern: "logs/*/*/*", opts: []Option{WithMinSize(25)}, want: []string{"logs/2023-12/31/a.json.gz", "logs/2024-01/01/a.json.gz", "logs/2024-01/02/c.json.gz"}},
+		{pattern: "nonexistent/*", want: []string{}},
+	}
+	for _, tt := range tests {
+		for _, pageSize := range []int{1, 2, 1000} {
+			l := &memLister{keys: keys, pageSize: pageSize}
+			matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithObjectLister(l))...)
+			if err != nil {
+				t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+				continue
+			}
+			if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+				t.Errorf("Bad results from Glob(%q) with pages of %d, -want +got: %v", tt.pattern, pageSize, diff)
+			}
+			// Only "*" needs to list the whole bucket: other patterns only
+			// query within their literal prefix.
+			for _, q := range l.queries {
+				if tt.pattern != "*" && !strings.HasPrefix(tt.pattern, q) && !strings.HasPrefix(q, literalPrefix(tt.pattern)) {
+					t.Errorf("Glob(%q) queried prefix %q", tt.pattern, q)
+				}
+			}
+		}
+	}
+}
+
+// TestGlobObjectListerLikeFS checks that a bucket holding the files of
+// testdata as objects gives the same matches as testdata itself.
+func TestGlobObjectListerLikeFS(t *testing.T) {
+	var keys []string
+	err := fs.WalkDir(testdataFS, "testdata", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			keys = append(keys, p)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+
+	for _, pattern := range []string{
+		"testdata/*",
+		"testdata/*/*",
+		"testdata/[ab]/?",
+		"testdata/a/c/**",
+		"testdata/**/a",
+		"**/match",
+	} {
+		want, err := Glob(context.Background(), pattern, WithGlobstar(), WithFS(testdataFS))
+		if err != nil {
+			t.Fatalf("Glob(%q) with WithFS error: %s", pattern, err)
+		}
+		matches, err := Glob(context.Background(), pattern, WithGlobstar(), WithObjectLister(&memLister{keys: keys, pageSize: 3}))
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithObjectLister, -WithFS +got: %v", pattern, diff)
+		}
+	}
+}
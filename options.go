@@ -0,0 +1,658 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io/fs"
+	"math"
+	"path/filepath"
+	"time"
+)
+
+// Option configures behavior of Glob and Stream that goes beyond what
+// filepath.Glob offers. Without any options, Glob and Stream produce the same
+// matches as filepath.Glob.
+//
+// Options are applied in the order they are given, so of two that set the
+// same thing, such as WithRateLimit and WithLimiter, the last one wins. New
+// behavior comes as new Options, leaving the signatures of Glob and Stream,
+// and what existing calls do, unchanged.
+type Option func(*options)
+
+type options struct {
+	dataStreams    bool
+	gitignore      bool
+	commonIgnores  bool
+	globstar       bool
+	inlineFlags    bool
+	posixClasses   bool
+	followSymlinks bool
+	reparse        ReparsePolicy
+	maxLinkDepth   int
+	oneFileSystem  bool
+	maxVisitedDirs int
+	timeout        time.Duration
+	deadline       time.Time
+	yieldEvery     int
+	inodeOrder     bool
+	sorted         bool
+	tilde          bool
+	env            bool
+	concurrency    int
+	forwardSlashes bool
+	separator      byte
+	separatorSet   bool
+	absolute       bool
+	root           string
+	confined       bool
+	confinedRoot   string // the dir of WithConfinedRoot
+	within         string // the dir of a later WithRoot, within confinedRoot
+	fs             fs.FS
+	lister         ObjectLister
+	remote         RemoteFS
+	shares         bool
+	shortNames     bool
+	normalize      bool
+	dedup          bool
+	linkDedup      bool
+	statSpecial    bool
+	skipBroken     bool
+	nonEmptyFiles  bool
+	sizeRange      bool
+	minSize        int64
+	maxSize        int64
+	modifiedAfter  time.Time
+	filters        []func(string, fs.DirEntry) bool
+	pollInterval   time.Duration
+	debug          func(msg string, args ...any)
+	tracer         Tracer
+	slowDir        time.Duration
+	metrics        func(Stats)
+	progress       func(ProgressInfo)
+	dirCache       *DirCache
+	resultCache    *ResultCache
+	rateLimit      float64
+	limiter        Limiter
+	spill          bool
+	spillDir       string
+	spillMemory    int
+	resume         *Checkpoint
+	braces         bool
+	minimatch      bool
+	filterRules    []FilterRule
+	basename       bool
+}
+
+// WithAlternateDataStreams makes a pattern whose final element contains a colon,
+// such as `C:\logs\*.txt:*`, match NTFS alternate data streams. The part
+// before the colon is matched against files as usual, and the part after it is
+// matched against the names of each matching file's named data streams. Matches
+// are reported in the `file.txt:stream` form accepted by the Windows API.
+//
+// The unnamed default stream is never reported. On other platforms, where a
+// colon is an ordinary filename character, this option has no effect.
+func WithAlternateDataStreams() Option {
+	return func(o *options) {
+		o.dataStreams = true
+	}
+}
+
+// WithGitignore skips files and directories excluded by .gitignore files, the
+// way git and ripgrep do. Only entries matched by a wildcard are ever
+// skipped: a path element spelled out literally in the pattern is used even if
+// it is ignored. Excluded directories are not descended into.
+//
+// The .gitignore file of each directory that is read applies, as do those of
+// its parents up to the root of the git repository containing it (the
+// directory containing ".git"), with deeper files taking precedence. Other
+// sources of exclusions, such as .git/info/exclude and core.excludesFile, are
+// not consulted.
+func WithGitignore() Option {
+	return func(o *options) {
+		o.gitignore = true
+	}
+}
+
+// WithCommonIgnores skips directories that are rarely what a pattern is meant
+// to find but are often large enough to dominate traversal time: version
+// control metadata (.git, .hg, .svn), dependency trees (node_modules, vendor),
+// and build output and caches (target, __pycache__). As for WithGitignore,
+// only directories matched by a wildcard are skipped, so `vendor/**` still
+// works as expected.
+func WithCommonIgnores() Option {
+	return func(o *options) {
+		o.commonIgnores = true
+	}
+}
+
+// WithGlobstar makes a path element consisting solely of "**" match any number
+// of nested directories, including none, the way it does in bash with the
+// globstar option set. For example, `src/**/*.go` matches `src/main.go` and
+// `src/cmd/tool/main.go`. A pattern ending in "**" matches every file and
+// directory beneath, and including, the directory before it; "**" alone
+// matches everything beneath the current directory.
+//
+// An element "**N", where N is a number from 1 to 9999, does the same for at
+// most N levels of directories: `src/**2/*.go` matches `src/main.go`,
+// `src/cmd/main.go` and `src/cmd/tool/main.go`, but nothing deeper, and
+// directories below that depth are not read at all.
+//
+// Symbolic links to directories are matched but not descended into unless
+// WithFollowSymlinks is also given. A pattern with more than one
+// non-consecutive "**" element can match the same path more than once.
+//
+// Without this option, "**" is equivalent to "*", as it is for filepath.Glob.
+func WithGlobstar() Option {
+	return func(o *options) {
+		o.globstar = true
+	}
+}
+
+// WithBasenameMatch makes a pattern without separators, such as "*.log",
+// match the names of files and directories at any depth beneath the current
+// directory, or the root of WithRoot, as `find -name` does: it is read as
+// "**/*.log". Patterns with a separator are matched as usual. It implies
+// WithGlobstar.
+func WithBasenameMatch() Option {
+	return func(o *options) {
+		o.basename = true
+		o.globstar = true
+	}
+}
+
+// WithFollowSymlinks makes "**" descend into symbolic links to directories. A
+// link to a directory that is already being traversed is never followed, so
+// cycles do not cause infinite traversal; use WithMaxLinkDepth and
+// WithMaxVisitedDirs to bound traversal of trees that are merely very large
+// when viewed through their links.
+func WithFollowSymlinks() Option {
+	return func(o *options) {
+		o.followSymlinks = true
+	}
+}
+
+// WithMaxLinkDepth stops the Stream with a *LimitError if "**" would have to
+// follow more than n symbolic links, one inside the other, to descend into a
+// directory. It only has an effect together with WithFollowSymlinks. A value of
+// zero means no limit.
+func WithMaxLinkDepth(n int) Option {
+	return func(o *options) {
+		o.maxLinkDepth = n
+	}
+}
+
+// WithOneFileSystem stops "**" from descending into directories on a different
+// filesystem from the directory it starts in, like find's -xdev: a mount point
+// can still match, but its contents are not traversed. On Windows,
+// filesystems are told apart by volume serial number. This option has no
+// effect on platforms where file identities aren't available.
+func WithOneFileSystem() Option {
+	return func(o *options) {
+		o.oneFileSystem = true
+	}
+}
+
+// WithMaxVisitedDirs stops the Stream with a *LimitError once it has read the
+// contents of n directories. A value of zero means no limit.
+func WithMaxVisitedDirs(n int) Option {
+	return func(o *options) {
+		o.maxVisitedDirs = n
+	}
+}
+
+// WithTimeout stops the Stream once it has run for d, after which Next returns
+// context.DeadlineExceeded instead of further matches, for an interactive
+// search that shows the best results it can within a budget:
+//
+//	r := glob.Stream(query, glob.WithGlobstar(), glob.WithTimeout(200*time.Millisecond))
+//
+// The matches received before then are all the Stream finds; Glob returns
+// the error rather than them. A Stream that finds every match in time
+// doesn't fail. A d other than a positive duration means no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithDeadline is like WithTimeout, but stops the Stream at t. A zero t means
+// no deadline. If both are given, the Stream stops at whichever comes first.
+func WithDeadline(t time.Time) Option {
+	return func(o *options) {
+		o.deadline = t
+	}
+}
+
+// stopAt returns the time at which the Stream is to stop, as set by
+// WithTimeout and WithDeadline, if any.
+func (o *options) stopAt() (time.Time, bool) {
+	t := o.deadline
+	if o.timeout > 0 {
+		if at := time.Now().Add(o.timeout); t.IsZero() || at.Before(t) {
+			t = at
+		}
+	}
+	return t, !t.IsZero()
+}
+
+// WithYieldEvery makes the Stream's background goroutines call runtime.Gosched
+// after every n directory entries they examine. The Go scheduler preempts long
+// running goroutines by itself, but on a busy process a Stream scanning a huge
+// directory in which few entries match can still hold on to a processor for
+// most of its time slice; yielding regularly trades a little throughput for
+// lower latency elsewhere. A value of zero, the default, never yields.
+func WithYieldEvery(n int) Option {
+	return func(o *options) {
+		o.yieldEvery = n
+	}
+}
+
+// WithInodeOrder makes the Stream read each directory in full and process its
+// entries in order of inode number. On filesystems backed by spinning disks,
+// where inode order approximates the on-disk order of the metadata that
+// filters and "**" (see WithGlobstar) need to examine, this turns random reads
+// into mostly sequential ones. The cost is that memory use grows with the size
+// of the largest directory read.
+//
+// This option is only supported on Linux; elsewhere it has no effect.
+func WithInodeOrder() Option {
+	return func(o *options) {
+		o.inodeOrder = true
+	}
+}
+
+// WithTildeExpansion makes a leading "~" path element of the pattern stand for
+// the current user's home directory, and a leading "~name" element for the
+// home directory of the user called name, as they do in shells. Any
+// characters in the home directory that have a special meaning in patterns
+// are matched literally. If the home directory cannot be determined, for
+// example because there is no such user, the Stream fails with an error
+// wrapping the reason.
+func WithTildeExpansion() Option {
+	return func(o *options) {
+		o.tilde = true
+	}
+}
+
+// WithEnvExpansion replaces references to environment variables in the
+// pattern, written $NAME or ${NAME}, with their values before matching. Write
+// "$$" for a literal dollar sign; a dollar sign that is not followed by a name
+// or a brace is also taken literally. The values are matched literally, even
+// if they contain characters that have a special meaning in patterns.
+//
+// Referring to a variable that is not set is an error, rather than expanding
+// to nothing as in shells: silently turning `$RELEASE_DIR/**` into `/**` is
+// rarely what was intended.
+//
+// If WithTildeExpansion is also given, tilde expansion happens first.
+func WithEnvExpansion() Option {
+	return func(o *options) {
+		o.env = true
+	}
+}
+
+// WithConcurrency lets the Stream read up to n directories at the same time,
+// using up to n-1 goroutines in addition to its own. This hides the latency of
+// each directory read on network filesystems and cold caches, where traversal
+// is otherwise spent almost entirely waiting. The default of 1 reads one
+// directory at a time.
+//
+// The standard library has no asynchronous way to read a directory, and
+// io_uring offers no getdents operation, so overlapping directory reads needs
+// goroutines (and hence threads blocked in system calls). Matches are
+// typically produced in a different order when n is greater than 1.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithForwardSlashes makes matches use forward slashes as path separators. On
+// Windows, where patterns may use either separator, matches otherwise use
+// backslashes (filepath.Separator) throughout. On other platforms, this option
+// has no effect.
+func WithForwardSlashes() Option {
+	return func(o *options) {
+		o.forwardSlashes = true
+	}
+}
+
+// WithSeparator makes Result.WriteTo end each match with sep rather than a
+// newline. WithSeparator(0) writes matches separated by NUL bytes, as
+// xargs -0 and other tools that handle any file name expect.
+func WithSeparator(sep byte) Option {
+	return func(o *options) {
+		o.separator = sep
+		o.separatorSet = true
+	}
+}
+
+// WithAbsolutePaths makes matches absolute paths even if the pattern is
+// relative, as if the pattern had been passed through filepath.Abs. The
+// working directory is read once, when the Stream starts, and the tree is
+// traversed by absolute path, so matches stay consistent if the working
+// directory changes while the Stream runs or before they are used.
+func WithAbsolutePaths() Option {
+	return func(o *options) {
+		o.absolute = true
+	}
+}
+
+// WithRoot interprets relative patterns relative to dir, rather than to the
+// working directory, and makes their matches relative to dir too, as if the
+// working directory were dir but without changing it. Matches of a pattern
+// that is not relative, such as "/etc/*", are unaffected. Together with
+// WithAbsolutePaths, matches are absolute paths within dir.
+//
+// After WithConfinedRoot, dir is relative to the confined root, and traversal
+// stays confined to that root: a dir that is absolute, or that leads out of
+// the root through ".." elements or symbolic links, makes the Stream fail.
+func WithRoot(dir string) Option {
+	return func(o *options) {
+		if o.confined {
+			o.within = dir
+			o.root = filepath.Join(o.confinedRoot, dir)
+			return
+		}
+		o.root = dir
+	}
+}
+
+// WithConfinedRoot is like WithRoot, but also guarantees that nothing outside
+// of dir is ever read: traversal, including through symbolic links and ".."
+// elements, is confined to dir as by os.OpenRoot, so "../*" matches nothing
+// and a link pointing out of dir is treated as broken. This makes it safe to
+// evaluate untrusted patterns against untrusted trees. Patterns must be
+// relative; a pattern such as "/etc/*" makes the Stream fail.
+//
+// WithConfinedRoot requires Go 1.24 or later; with earlier versions, the
+// Stream fails. Options that need files' identities, such as
+// WithOneFileSystem, look them up outside of the root by path, but only ever
+// read metadata that way.
+func WithConfinedRoot(dir string) Option {
+	return func(o *options) {
+		o.root = dir
+		o.confined = true
+		o.confinedRoot = dir
+		o.within = ""
+		o.fs = nil
+		o.lister = nil
+		o.remote = nil
+	}
+}
+
+// WithSubRoot is like WithRoot, but dir is relative to the root set by an
+// earlier WithRoot or WithConfinedRoot, if any, instead of replacing it:
+// WithRoot("/srv") then WithSubRoot("logs") interprets patterns relative to
+// "/srv/logs". Within a confined root, traversal stays confined to it as
+// with WithRoot.
+func WithSubRoot(dir string) Option {
+	return func(o *options) {
+		if o.confined {
+			o.within = filepath.Join(o.within, dir)
+			o.root = filepath.Join(o.confinedRoot, o.within)
+			return
+		}
+		o.root = filepath.Join(o.root, dir)
+	}
+}
+
+// WithFS matches patterns against fsys instead of the operating system's
+// filesystem, so that patterns such as "templates/**/*.tmpl" (see WithGlobstar)
+// can select files from an embed.FS, which fs.Glob can't do, or from a
+// *zip.Reader, without extracting the archive. As for fs.Glob,
+// patterns are relative and slash-separated, and so are matches, on every
+// platform; a pattern that doesn't name a valid path for fsys, such as "/etc"
+// or "./a", matches nothing.
+//
+// Filesystem abstractions that convert to an fs.FS work the same way: an
+// afero.Fs, including in-memory and copy-on-write overlay ones, is globbed with
+// WithFS(afero.NewIOFS(fsys)).
+//
+// Symbolic links are only recognized if fsys has a method
+// Lstat(name string) (fs.FileInfo, error), as os.DirFS does from Go 1.25.
+// Options that only make sense for the operating system's filesystem, such as
+// WithAbsolutePaths, WithOneFileSystem and WithAlternateDataStreams, have no
+// effect. A later WithConfinedRoot, WithObjectLister or WithRemoteFS replaces
+// fsys.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fs = fsys
+		o.confined = false
+		o.lister = nil
+		o.remote = nil
+	}
+}
+
+// WithObjectLister matches patterns against the keys of a bucket in an object
+// store listed by l, as if each "/" in a key separated directories. As with
+// WithFS, patterns and matches are relative and slash-separated: with a bucket
+// of logs, "logs/2024-*/**/*.json.gz" (see WithGlobstar) matches
+// "logs/2024-01/02/a.json.gz". Directories have no entries of their own, but
+// match like those of a filesystem.
+//
+// Each directory is listed a page at a time, with the literal part of the
+// pattern, such as "logs/2024-", as the prefix of the query, so a pattern only
+// costs queries for the directories it could match in. WithConcurrency lists
+// several directories at once. Sizes and modification times come from the
+// listings, so filtering matches by them costs nothing extra.
+func WithObjectLister(l ObjectLister) Option {
+	return func(o *options) {
+		o.lister = l
+		o.fs = nil
+		o.confined = false
+		o.remote = nil
+	}
+}
+
+// WithRemoteFS matches patterns against the filesystem of a remote host, as
+// seen through c, such as an *sftp.Client of github.com/pkg/sftp, or against
+// a billy.Filesystem, which matches as the operating system's filesystem
+// would, symbolic links included. Patterns and matches are slash-separated on
+// every platform, and absolute patterns, such as "/var/log/*/*.log", are
+// absolute on the remote host.
+//
+// Each directory costs a single round trip, which also returns the metadata
+// of its entries, so filtering matches by size or modification time costs
+// nothing extra. Without WithConcurrency, directories are read one after
+// another and the walk takes a round trip per directory; with
+// WithConcurrency(n), up to n directories are read at once, so the latency
+// of the connection is overlapped rather than added up. Options that read
+// files, such as WithGitignore, or that only make sense for the operating
+// system's filesystem, such as WithAbsolutePaths, have no effect.
+func WithRemoteFS(c RemoteFS) Option {
+	return func(o *options) {
+		o.remote = c
+		o.fs = nil
+		o.lister = nil
+		o.confined = false
+	}
+}
+
+// WithShareEnumeration makes wildcards in the share name of a UNC pattern, as
+// in `\\server\*\logs\*.log`, match the disk shares that the server
+// lists. Administrative shares, such as C$, are not listed and can only be
+// named literally. Without this option, the share name is always taken
+// literally, like a drive letter, since a server's shares are not in any
+// directory.
+//
+// This option is only supported on Windows; elsewhere it has no effect.
+func WithShareEnumeration() Option {
+	return func(o *options) {
+		o.shares = true
+	}
+}
+
+// WithShortNames makes wildcard pattern elements match the DOS 8.3 short names
+// that Windows gives some files as well as their long names, for legacy
+// configurations that still spell paths the old way: `C:\PROGRA~?\*` then
+// matches the contents of `C:\Program Files`. Matches are reported by their
+// long names. An element without wildcards, such as `PROGRA~1`, is looked up
+// as it is, which finds a file by its short name without this option.
+//
+// Short names only exist on volumes that generate them, which NTFS can be
+// configured not to do and ReFS never does, and are only read from the
+// operating system's filesystem; elsewhere, and on other platforms, this
+// option has no effect.
+func WithShortNames() Option {
+	return func(o *options) {
+		o.shortNames = true
+	}
+}
+
+// WithUnicodeNormalization makes pattern elements match names that are
+// canonically equivalent in Unicode, even if they are stored in a different
+// normalization form: `café*` then matches a file whose name HFS+ stores
+// decomposed, as "cafe" followed by a combining acute accent. Names and
+// pattern elements are compared in Normalization Form C; matches are
+// reported as the names are stored.
+//
+// Patterns without wildcards are looked up as they are, which filesystems on
+// macOS already do insensitively to normalization.
+func WithUnicodeNormalization() Option {
+	return func(o *options) {
+		o.normalize = true
+	}
+}
+
+// WithSkipBrokenSymlinks drops matches that are symbolic links whose targets
+// don't exist (or can't be reached, as in a loop of links). By default, as with
+// filepath.Glob, a symbolic link matches by its own name whether or not its
+// target exists.
+func WithSkipBrokenSymlinks() Option {
+	return func(o *options) {
+		o.skipBroken = true
+	}
+}
+
+// WithStatSpecialFiles lets options that filter matches by metadata, such as
+// WithNonEmptyFiles, stat FIFOs, sockets and devices. By default these are
+// recognized from their directory entries and dropped without being stat'ed,
+// since some FUSE and network filesystems hang when they are; Stats counts
+// them. Neither way are they ever opened.
+func WithStatSpecialFiles() Option {
+	return func(o *options) {
+		o.statSpecial = true
+	}
+}
+
+// WithDeduplication drops matches that refer to the same directory entry as
+// an earlier match, such as a file reached through a symbolic link to its
+// directory and through its real path, or named in two casings on a
+// case-insensitive filesystem. Matches are compared by their absolute paths,
+// with symbolic links resolved, folded to lower case where the filesystem
+// holding them is found to ignore case. Memory use grows with the number of
+// matches.
+func WithDeduplication() Option {
+	return func(o *options) {
+		o.dedup = true
+	}
+}
+
+// WithHardlinkDeduplication drops matches that refer to the same file as an
+// earlier match, as identified by its device and inode number (its volume
+// serial number and file index on Windows), so that a file with several
+// matching hard links is reported once, under the first name found. Symbolic
+// links are followed, so a link is also dropped if its target was matched.
+// Each match is stat'ed, and memory use grows with the number of matches.
+// Matches whose identity can't be determined, such as those deleted since they
+// were found, are kept.
+func WithHardlinkDeduplication() Option {
+	return func(o *options) {
+		o.linkDedup = true
+	}
+}
+
+// WithNonEmptyFiles restricts matches to regular files that are not empty,
+// skipping directories, zero-byte files and anything else. A symbolic link
+// matches if it points to such a file. The type of each entry comes from its
+// directory listing, so only candidate files are stat'ed. Data streams matched
+// through WithAlternateDataStreams are not affected.
+func WithNonEmptyFiles() Option {
+	return func(o *options) {
+		o.nonEmptyFiles = true
+	}
+}
+
+// WithMinSize restricts matches to regular files of at least n bytes, in the
+// same way as WithNonEmptyFiles, which is equivalent to WithMinSize(1).
+func WithMinSize(n int64) Option {
+	return func(o *options) {
+		if !o.sizeRange {
+			o.maxSize = math.MaxInt64
+		}
+		o.sizeRange = true
+		o.minSize = n
+	}
+}
+
+// WithMaxSize restricts matches to regular files of at most n bytes, in the
+// same way as WithNonEmptyFiles. It may be combined with WithMinSize.
+func WithMaxSize(n int64) Option {
+	return func(o *options) {
+		if !o.sizeRange {
+			o.minSize = 0
+		}
+		o.sizeRange = true
+		o.maxSize = n
+	}
+}
+
+// WithModifiedAfter restricts matches to those modified after t, which allows
+// incremental tools to find the files matching a pattern that changed since
+// their last run. Every kind of match is compared, directories included; a
+// symbolic link matches if its target was modified after t. Modification times
+// come from the directory listing where the platform provides them, as on
+// Windows, and otherwise each candidate is stat'ed once. A zero t disables
+// the option.
+func WithModifiedAfter(t time.Time) Option {
+	return func(o *options) {
+		o.modifiedAfter = t
+	}
+}
+
+// WithFilter restricts matches to those for which keep returns true. keep is
+// passed the path of each match, using the operating system's separator, and
+// its directory entry, which describes the match itself rather than the target
+// of a symbolic link. Several filters may be given, and a match must satisfy
+// all of them, as well as the options that filter by type or metadata, which
+// are applied first. Only matches are filtered: a directory that keep rejects
+// is still descended into by "**".
+//
+// keep is called from the goroutines traversing the tree, so it holds up the
+// traversal while it runs and, with WithConcurrency, may be called
+// concurrently.
+func WithFilter(keep func(path string, d fs.DirEntry) bool) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, keep)
+	}
+}
+
+// WithSortedOrder guarantees that matches are produced in the order in which
+// filepath.Glob returns them: the names in each directory are taken in lexical
+// byte order, and the matches within a directory come before those in the
+// next. "**" (see WithGlobstar) visits a tree depth first, matching each
+// directory before its contents.
+//
+// The order is part of the API: it does not vary between platforms or
+// releases. Getting it means reading each directory in full before its
+// entries are processed, and reading one directory at a time, so this option
+// overrides WithInodeOrder and WithConcurrency.
+func WithSortedOrder() Option {
+	return func(o *options) {
+		o.sorted = true
+	}
+}
+
+// WithPollInterval sets how often Watch re-evaluates its pattern. It defaults
+// to one second, as does a d other than a positive duration. It has no effect
+// on Glob and Stream.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = d
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// WithPOSIXClasses lets a character class hold POSIX character classes, as
+// "[[:alpha:]_]" does, as in shells and fnmatch: each matches the characters
+// it has in the C locale, so only ASCII characters, and an unknown name such
+// as "[:word:]" makes the pattern malformed. Without it, "[[:alpha:]]" is
+// read as filepath.Match reads it, as a class of "[:alph" followed by "]".
+func WithPOSIXClasses() Option {
+	return func(o *options) {
+		o.posixClasses = true
+	}
+}
+
+// posixClasses holds the characters of the POSIX character classes that may
+// appear in a character class, as in "[[:alpha:]_]", as they are in the C
+// locale: only ASCII characters belong to them. "/", which belongs to punct,
+// is left out, as no name has it.
+var posixClasses = map[string][]Range{
+	"alnum":  {{'0', '9'}, {'A', 'Z'}, {'a', 'z'}},
+	"alpha":  {{'A', 'Z'}, {'a', 'z'}},
+	"blank":  {{'\t', '\t'}, {' ', ' '}},
+	"cntrl":  {{0, 0x1f}, {0x7f, 0x7f}},
+	"digit":  {{'0', '9'}},
+	"graph":  {{'!', '~'}},
+	"lower":  {{'a', 'z'}},
+	"print":  {{' ', '~'}},
+	"punct":  {{'!', '.'}, {':', '@'}, {'[', '`'}, {'{', '~'}},
+	"space":  {{'\t', '\r'}, {' ', ' '}},
+	"upper":  {{'A', 'Z'}},
+	"xdigit": {{'0', '9'}, {'A', 'F'}, {'a', 'f'}},
+}
+
+// posixClassAt reads the POSIX character class name, such as "[:alpha:]", at
+// pattern[i:] within a character class ending before end, returning its name
+// and the offset just past it. ok is false if there is none there, in which
+// case the "[" is an ordinary character of the class.
+func posixClassAt(pattern string, i, end int) (name string, next int, ok bool) {
+	if !strings.HasPrefix(pattern[i:end], "[:") {
+		return "", 0, false
+	}
+	n := strings.Index(pattern[i+2:end], ":]")
+	if n < 0 {
+		return "", 0, false
+	}
+	name = pattern[i+2 : i+2+n]
+	if strings.IndexFunc(name, func(r rune) bool { return r < 0x80 && os.IsPathSeparator(uint8(r)) }) >= 0 {
+		// A character class cannot span a path separator.
+		return "", 0, false
+	}
+	return name, i + 2 + n + 2, true
+}
+
+// expandSyntax rewrites the constructs of the valid pattern that
+// filepath.Match doesn't have, the POSIX character classes of
+// WithPOSIXClasses and the case-insensitive elements of WithInlineFlags, in
+// terms of those it does.
+func expandSyntax(pattern string, o *options) (string, error) {
+	if o.posixClasses {
+		var err error
+		if pattern, err = expandPOSIXClasses(pattern); err != nil {
+			return "", err
+		}
+	}
+	if o.inlineFlags {
+		pattern = expandCaseFolds(pattern)
+	}
+	return pattern, nil
+}
+
+// expandPOSIXClasses rewrites the POSIX character class names in the
+// character classes of the valid pattern as ranges, in the syntax of
+// filepath.Match, which has none: "[[:digit:]_]" becomes "[0-9_]". It returns
+// a *PatternError for an unknown name.
+func expandPOSIXClasses(pattern string) (string, error) {
+	if !strings.Contains(pattern, "[:") {
+		return pattern, nil
+	}
+	escapes := runtime.GOOS != "windows"
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		switch {
+		case c == '\\' && escapes && i+1 < len(pattern):
+			b.WriteString(pattern[i : i+2])
+			i += 2
+			continue
+		case !inClass:
+			if c == '[' {
+				inClass = true
+				b.WriteByte(c)
+				i++
+				if i < len(pattern) && pattern[i] == '^' {
+					b.WriteByte('^')
+					i++
+				}
+				continue
+			}
+		case c == ']':
+			inClass = false
+		case c == '[':
+			if name, next, ok := posixClassAt(pattern, i, len(pattern)); ok {
+				ranges, known := posixClasses[name]
+				if !known {
+					return "", &PatternError{Pattern: pattern, Offset: i, Construct: pattern[i:next], Msg: "unknown character class name"}
+				}
+				writeRanges(&b, ranges)
+				i = next
+				continue
+			}
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String(), nil
+}
+
+// writeRanges writes the ranges of a POSIX character class in the syntax of
+// filepath.Match, in which none of their bounds needs escaping or is a
+// separator.
+func writeRanges(b *strings.Builder, ranges []Range) {
+	for _, r := range ranges {
+		b.WriteRune(r.Lo)
+		if r.Hi != r.Lo {
+			b.WriteByte('-')
+			b.WriteRune(r.Hi)
+		}
+	}
+}
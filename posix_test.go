@@ -0,0 +1,129 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestGlobPOSIXClasses(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a1":   {},
+		"B2":   {},
+		"c_":   {},
+		"d 4":  {},
+		"é5":   {},
+		"f.go": {},
+	}
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"[[:alpha:]][[:digit:]]", []string{"B2", "a1"}},
+		{"[[:lower:]]?", []string{"a1", "c_"}},
+		{"[[:upper:][:punct:]]*", []string{"B2"}},
+		{"?[[:punct:]]*", []string{"c_", "f.go"}},
+		{"*[[:space:]]*", []string{"d 4"}},
+		{"[^[:alpha:]]*", []string{"é5"}},
+		{"[[:xdigit:]][![:alnum:]]*", []string{"c_", "d 4", "f.go"}},
+	} {
+		got, err := Glob(context.Background(), tt.pattern, WithFS(fsys), WithPOSIXClasses(), WithMinimatch())
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	var pe *PatternError
+	if _, err := Glob(context.Background(), "[[:word:]]", WithFS(fsys), WithPOSIXClasses()); !errors.As(err, &pe) {
+		t.Errorf("Glob(%q) returned error %v, want a *PatternError", "[[:word:]]", err)
+	}
+}
+
+func TestGlobPOSIXClassesOff(t *testing.T) {
+	// Without WithPOSIXClasses, patterns mean what they do to
+	// filepath.Match.
+	fsys := fstest.MapFS{
+		"a]":  {},
+		"p]":  {},
+		"a":   {},
+		"d]z": {},
+		"1":   {},
+	}
+	for _, pattern := range []string{"[[:alpha:]]", "[[:word:]]", "[[:digit:]-z]", "[[:digit:]"} {
+		var want []string
+		for name := range fsys {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				want = append(want, name)
+			}
+		}
+		got, err := Glob(context.Background(), pattern, WithFS(fsys))
+		if _, matchErr := filepath.Match(pattern, ""); matchErr != nil {
+			if err == nil {
+				t.Errorf("Glob(%q) succeeded, want the error of filepath.Match, %v", pattern, matchErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, got, sortStringSlices, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", pattern, diff)
+		}
+	}
+	if err := Validate("[[:word:]]"); err != nil {
+		t.Errorf("Validate(%q) returned unexpected error: %v", "[[:word:]]", err)
+	}
+}
+
+func TestExpandPOSIXClasses(t *testing.T) {
+	for _, tt := range []struct{ pattern, want string }{
+		{"[[:digit:]_]", "[0-9_]"},
+		{"[^[:blank:]]", "[^\t ]"},
+		{"[:digit:]", "[:digit:]"},
+		{"a[[:punct:]]", "a[!-.:-@[-`{-~]"},
+		{"[a][[:upper:]]/[[:lower:]]", "[a][A-Z]/[a-z]"},
+	} {
+		got, err := expandPOSIXClasses(tt.pattern)
+		if err != nil {
+			t.Errorf("expandPOSIXClasses(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandPOSIXClasses(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestParsePOSIXClasses(t *testing.T) {
+	p, err := Parse("[_[:digit:]]", WithPOSIXClasses())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Node{{Kind: ClassNode, Ranges: []Range{{'_', '_'}, {'0', '9'}}}}
+	if diff := cmp.Diff(want, p.Segments[0].Nodes); diff != "" {
+		t.Errorf("Bad nodes from Parse(%q), -want +got: %v", "[_[:digit:]]", diff)
+	}
+	if got, want := p.String(), "[_0-9]"; got != want {
+		t.Errorf("Parse(%q).String() = %q, want %q", "[_[:digit:]]", got, want)
+	}
+
+	if !(FilterRule{Pattern: "*.[[:digit:]]"}).matches("a/b.1", false) {
+		t.Errorf("FilterRule %q doesn't match %q", "*.[[:digit:]]", "a/b.1")
+	}
+}
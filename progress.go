@@ -0,0 +1,88 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is how often the function set by WithProgress is called.
+const progressInterval = 100 * time.Millisecond
+
+// ProgressInfo is a snapshot of the progress of a Stream, for WithProgress.
+type ProgressInfo struct {
+	// Dirs is the number of directories read so far.
+	Dirs int64
+	// Entries is the number of directory entries examined so far.
+	Entries int64
+	// Matches is the number of matches received from the Stream so far.
+	Matches int64
+	// Pending is the number of directories being read, whose remaining
+	// entries are yet to be examined: the depth of the traversal, summed
+	// over its goroutines with WithConcurrency.
+	Pending int64
+	// Elapsed is the time since the Stream was created.
+	Elapsed time.Duration
+}
+
+// WithProgress makes the Stream call f every 100ms while it runs, and once
+// more when it ends, so that a long-running command can show a live progress
+// line. f is called from a goroutine of the Stream, never concurrently with
+// itself, and must return quickly.
+func WithProgress(f func(ProgressInfo)) Option {
+	return func(o *options) {
+		o.progress = f
+	}
+}
+
+// reportProgress starts calling the function set by WithProgress, and returns
+// a function that stops it after a last call.
+func (g *Result) reportProgress() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(progressInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				g.w.opts.progress(g.progress())
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		g.w.opts.progress(g.progress())
+	}
+}
+
+func (g *Result) progress() ProgressInfo {
+	return ProgressInfo{
+		Dirs:    atomic.LoadInt64(&g.w.visited),
+		Entries: atomic.LoadInt64(&g.w.examined),
+		Matches: atomic.LoadInt64(&g.w.matched),
+		Pending: atomic.LoadInt64(&g.w.pending),
+		Elapsed: time.Since(g.w.began),
+	}
+}
+
+// pendingDirReader counts a directory as pending while it is read, for
+// WithProgress.
+type pendingDirReader struct {
+	dirReader
+	w *walker
+}
+
+func (r *pendingDirReader) Close() error {
+	atomic.AddInt64(&r.w.pending, -1)
+	return r.dirReader.Close()
+}
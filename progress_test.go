@@ -0,0 +1,55 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestGlobProgress(t *testing.T) {
+	r := &slowRemote{
+		fsys: fstest.MapFS{
+			"a/b/c/x": {},
+			"a/b/y":   {},
+			"d/e/z":   {},
+		},
+		latency: 40 * time.Millisecond,
+	}
+	var calls []ProgressInfo
+	matches, err := Glob(context.Background(), "**", WithRemoteFS(r), WithGlobstar(), WithProgress(func(p ProgressInfo) {
+		calls = append(calls, p)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The root and a, a/b, a/b/c, d and d/e are read, at 40ms each.
+	if len(calls) < 2 {
+		t.Fatalf("Progress called %d times over 240ms, want a call every 100ms and one at the end", len(calls))
+	}
+	pending := false
+	for i, p := range calls {
+		if p.Pending > 0 {
+			pending = true
+		}
+		if i > 0 && (p.Dirs < calls[i-1].Dirs || p.Elapsed < calls[i-1].Elapsed) {
+			t.Errorf("Progress went backwards from %+v to %+v", calls[i-1], p)
+		}
+	}
+	if !pending {
+		t.Errorf("No call to progress saw a directory being read: %+v", calls)
+	}
+	last := calls[len(calls)-1]
+	if last.Dirs != 6 || last.Pending != 0 {
+		t.Errorf("Last progress = %+v, want 6 directories read and none pending", last)
+	}
+	if last.Matches > int64(len(matches)) {
+		t.Errorf("Last progress = %+v, want at most the %d matches", last, len(matches))
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// A Limiter limits the rate of a Stream's filesystem operations, as set by
+// WithLimiter. Wait blocks until the next operation may go ahead, or returns
+// an error if it can't before ctx is done. A *rate.Limiter from
+// golang.org/x/time/rate is a Limiter.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimit limits the Stream to opsPerSecond filesystem operations a
+// second, spaced out evenly, so that globbing a huge tree in the background
+// doesn't starve other work of the disk it shares. Opening a directory to
+// read it is an operation, as is stat'ing a file, whether by path or through
+// its directory entry; what a DirCache (see WithDirCache) already holds isn't
+// read again, and isn't limited. The limit applies to each Stream on its own;
+// Streams that share a budget should share a Limiter, with WithLimiter.
+//
+// A limit other than a positive number turns limiting off.
+func WithRateLimit(opsPerSecond float64) Option {
+	return func(o *options) {
+		o.limiter, o.rateLimit = nil, 0
+		if opsPerSecond > 0 {
+			o.rateLimit = opsPerSecond
+		}
+	}
+}
+
+// WithLimiter is like WithRateLimit, but waits on l before each filesystem
+// operation, allowing for bursts or sharing a budget as l does:
+//
+//	lim := rate.NewLimiter(500, 50)
+//	matches, err := glob.Glob(ctx, "/srv/**/*.log", glob.WithGlobstar(), glob.WithLimiter(lim))
+//
+// If Wait returns an error, the operation fails with it. A nil l turns
+// limiting off.
+func WithLimiter(l Limiter) Option {
+	return func(o *options) {
+		o.limiter, o.rateLimit = l, 0
+	}
+}
+
+// useLimiter makes the walker wait on the Limiter set by the options before
+// each filesystem operation. Like useDirCache, it is called once the
+// filesystem is otherwise set up, and before useDirCache, so that cached
+// reads aren't limited.
+func (w *walker) useLimiter() {
+	l := w.opts.limiter
+	if w.opts.rateLimit > 0 {
+		l = &intervalLimiter{interval: time.Duration(float64(time.Second) / w.opts.rateLimit)}
+	}
+	if l != nil {
+		w.fsys = limitedFS{w.fsys, l, w.ctx}
+	}
+}
+
+// intervalLimiter is the Limiter of WithRateLimit, which lets one operation go
+// ahead every interval.
+type intervalLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // when the next operation may go ahead
+}
+
+func (l *intervalLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// limitedFS is a filesystem whose operations wait on a Limiter.
+type limitedFS struct {
+	filesystem
+	l   Limiter
+	ctx context.Context
+}
+
+func (f limitedFS) Open(name string) (fs.File, error) {
+	if err := f.l.Wait(f.ctx); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	file, err := f.filesystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := file.(fs.ReadDirFile); ok {
+		return &limitedDir{ReadDirFile: d, fsys: f}, nil
+	}
+	return file, nil
+}
+
+func (f limitedFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.l.Wait(f.ctx); err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return f.filesystem.Stat(name)
+}
+
+func (f limitedFS) Lstat(name string) (fs.FileInfo, error) {
+	if err := f.l.Wait(f.ctx); err != nil {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	return f.filesystem.Lstat(name)
+}
+
+// limitedDir is a directory opened by a limitedFS, whose entries' Info
+// methods wait on its Limiter.
+type limitedDir struct {
+	fs.ReadDirFile
+	fsys limitedFS
+}
+
+func (d *limitedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := d.ReadDirFile.ReadDir(n)
+	limited := make([]fs.DirEntry, len(entries))
+	for i, e := range entries {
+		limited[i] = limitedEntry{e, d.fsys}
+	}
+	return limited, err
+}
+
+// limitedEntry is an entry of a limitedDir.
+type limitedEntry struct {
+	fs.DirEntry
+	fsys limitedFS
+}
+
+func (e limitedEntry) Info() (fs.FileInfo, error) {
+	if err := e.fsys.l.Wait(e.fsys.ctx); err != nil {
+		return nil, &fs.PathError{Op: "lstat", Path: e.Name(), Err: err}
+	}
+	return e.DirEntry.Info()
+}
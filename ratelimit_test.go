@@ -0,0 +1,100 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// countingLimiter is a Limiter that never waits, counting the operations it
+// lets go ahead, or failing them once it has let max go ahead.
+type countingLimiter struct {
+	mu  sync.Mutex
+	n   int
+	max int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.max > 0 && l.n == l.max {
+		return errors.New("over budget")
+	}
+	l.n++
+	return nil
+}
+
+func rateLimitFS() (fstest.MapFS, []string) {
+	fsys := fstest.MapFS{}
+	var want []string
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("dir%d/file", i)
+		fsys[name] = &fstest.MapFile{}
+		want = append(want, name)
+	}
+	return fsys, want
+}
+
+func TestGlobLimiter(t *testing.T) {
+	fsys, want := rateLimitFS()
+	l := &countingLimiter{}
+	matches, err := Glob(context.Background(), "*/*", WithFS(fsys), WithLimiter(l), WithMinSize(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", "*/*", diff)
+	}
+	// The root and each subdirectory are stat'ed and read, and each match
+	// stat'ed for WithMinSize.
+	if want := 2 + 2*5 + 5; l.n != want {
+		t.Errorf("Glob(%q) waited on the Limiter %d times, want %d", "*/*", l.n, want)
+	}
+
+	// Operations the Limiter refuses fail.
+	l = &countingLimiter{max: 1}
+	if _, err := Glob(context.Background(), "*/*", WithFS(fsys), WithLimiter(l)); err == nil {
+		t.Errorf("Glob(%q) over a Limiter's budget succeeded, want an error", "*/*")
+	}
+}
+
+func TestGlobRateLimit(t *testing.T) {
+	fsys, want := rateLimitFS()
+	start := time.Now()
+	matches, err := Glob(context.Background(), "*/*", WithFS(fsys), WithRateLimit(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", "*/*", diff)
+	}
+	// The 12 operations go ahead 10ms apart.
+	if took, min := time.Since(start), 110*time.Millisecond; took < min {
+		t.Errorf("Glob(%q) at 100 operations a second took %v, want at least %v", "*/*", took, min)
+	}
+}
+
+func TestGlobRateLimitCancel(t *testing.T) {
+	fsys, _ := rateLimitFS()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := Glob(ctx, "*/*", WithFS(fsys), WithRateLimit(0.1)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Glob(%q) = %v, want %v", "*/*", err, context.DeadlineExceeded)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("Glob(%q) took %v to notice its context was done", "*/*", took)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// RemoteFS is the part of a client of a remote filesystem that globbing needs.
+// The *sftp.Client of github.com/pkg/sftp implements it, as does every
+// billy.Filesystem of github.com/go-git/go-billy, such as a go-git worktree's
+// or memfs; NewWebDAVFS and NewHTTPIndexFS return ones for web servers. Names
+// are slash-separated paths on the remote host, relative to its working
+// directory unless they begin with a slash.
+type RemoteFS interface {
+	// ReadDir returns the entries of the directory name, in any order.
+	ReadDir(name string) ([]fs.FileInfo, error)
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// remoteFS is a RemoteFS, for WithRemoteFS.
+type remoteFS struct {
+	c RemoteFS
+}
+
+func (r remoteFS) Open(name string) (fs.File, error) {
+	return &remoteDir{c: r.c, name: filepath.ToSlash(name)}, nil
+}
+
+func (r remoteFS) Stat(name string) (fs.FileInfo, error) {
+	return r.c.Stat(filepath.ToSlash(name))
+}
+
+func (r remoteFS) Lstat(name string) (fs.FileInfo, error) {
+	return r.c.Lstat(filepath.ToSlash(name))
+}
+
+func (r remoteFS) native(name string) (string, bool) {
+	return "", false
+}
+
+// remoteDir is a directory of a remoteFS being read. The directory is read in
+// full, in a single round trip, by the first call to ReadDir.
+type remoteDir struct {
+	c       RemoteFS
+	name    string
+	entries []fs.DirEntry // read but not yet returned
+	read    bool
+}
+
+func (d *remoteDir) Stat() (fs.FileInfo, error) { return d.c.Stat(d.name) }
+func (d *remoteDir) Close() error               { return nil }
+
+func (d *remoteDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("reading remote files is not supported")}
+}
+
+func (d *remoteDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		infos, err := d.c.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.read = true
+		d.entries = make([]fs.DirEntry, len(infos))
+		for i, fi := range infos {
+			d.entries[i] = fs.FileInfoToDirEntry(fi)
+		}
+	}
+	if n > 0 && len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n <= 0 || n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// remoteInfo describes a file or directory of one of the RemoteFSs of this
+// package.
+type remoteInfo struct {
+	name    string
+	dir     bool
+	mode    fs.FileMode // the type of a file that is not a directory
+	size    int64
+	modTime time.Time
+}
+
+func (fi remoteInfo) Name() string       { return fi.name }
+func (fi remoteInfo) Size() int64        { return fi.size }
+func (fi remoteInfo) ModTime() time.Time { return fi.modTime }
+func (fi remoteInfo) IsDir() bool        { return fi.dir }
+func (fi remoteInfo) Sys() any           { return nil }
+
+func (fi remoteInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return fi.mode | 0444
+}
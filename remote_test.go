@@ -0,0 +1,215 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// slowRemote is a RemoteFS of a tree held in memory, rooted at "/", whose
+// directory reads take latency. It records how many reads overlapped.
+type slowRemote struct {
+	fsys    fstest.MapFS
+	latency time.Duration
+
+	mu              sync.Mutex
+	reads, max, all int
+}
+
+func (r *slowRemote) path(name string) string {
+	if name = strings.TrimPrefix(name, "/"); name == "" {
+		return "."
+	}
+	return name
+}
+
+func (r *slowRemote) ReadDir(name string) ([]fs.FileInfo, error) {
+	r.mu.Lock()
+	r.reads++
+	r.all++
+	if r.reads > r.max {
+		r.max = r.reads
+	}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.reads--
+		r.mu.Unlock()
+	}()
+	time.Sleep(r.latency)
+
+	entries, err := r.fsys.ReadDir(r.path(name))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, len(entries))
+	for i, e := range entries {
+		if infos[i], err = e.Info(); err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+func (r *slowRemote) Stat(name string) (fs.FileInfo, error) {
+	return r.fsys.Stat(r.path(name))
+}
+
+func (r *slowRemote) Lstat(name string) (fs.FileInfo, error) {
+	return r.Stat(name)
+}
+
+// deniedRemote is a RemoteFS of a tree in which stat'ing denied fails.
+type deniedRemote struct {
+	slowRemote
+	denied string
+}
+
+func (r *deniedRemote) Stat(name string) (fs.FileInfo, error) {
+	if name == r.denied {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrPermission}
+	}
+	return r.slowRemote.Stat(name)
+}
+
+func TestGlobRemoteFS(t *testing.T) {
+	tree := fstest.MapFS{
+		"var/log/app/a.log":        {Data: []byte("a")},
+		"var/log/app/b.log":        {},
+		"var/log/app/b.txt":        {},
+		"var/log/db/c.log":         {Data: []byte("c")},
+		"var/log/db/old/d.log":     {},
+		"var/log/web/e.log":        {Data: []byte("e")},
+		"var/log/web/access/f.log": {},
+		"home/user/notes.txt":      {},
+	}
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "/var/log/*/*.log", want: []string{"/var/log/app/a.log", "/var/log/app/b.log", "/var/log/db/c.log", "/var/log/web/e.log"}},
+		{pattern: "/var/log/*/*.log", opts: []Option{WithNonEmptyFiles()}, want: []string{"/var/log/app/a.log", "/var/log/db/c.log", "/var/log/web/e.log"}},
+		{pattern: "/var/log/**/*.log", opts: []Option{WithGlobstar()}, want: []string{"/var/log/app/a.log", "/var/log/app/b.log", "/var/log/db/c.log", "/var/log/db/old/d.log", "/var/log/web/access/f.log", "/var/log/web/e.log"}},
+		{pattern: "home/*/*", want: []string{"home/user/notes.txt"}},
+		{pattern: "/nonexistent/*", want: []string{}},
+	}
+	for _, tt := range tests {
+		r := &slowRemote{fsys: tree}
+		matches, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithRemoteFS(r))...)
+		if err != nil {
+			t.Errorf("Glob(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobRemoteFSPipelined(t *testing.T) {
+	tree := fstest.MapFS{}
+	for _, host := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		tree["logs/"+host+"/messages.log"] = &fstest.MapFile{}
+	}
+	r := &slowRemote{fsys: tree, latency: 20 * time.Millisecond}
+	matches, err := Glob(context.Background(), "logs/*/*.log", WithRemoteFS(r), WithConcurrency(8))
+	if err != nil {
+		t.Fatalf("Glob error: %s", err)
+	}
+	if len(matches) != 8 {
+		t.Errorf("Glob returned %d matches, want 8: %v", len(matches), matches)
+	}
+	if r.max < 2 {
+		t.Errorf("Glob read at most %d of %d directories at once with WithConcurrency(8), want more than 1", r.max, r.all)
+	}
+}
+
+// osRemote is a RemoteFS of the operating system's filesystem, like a billy
+// osfs.
+type osRemote struct{}
+
+func (osRemote) ReadDir(name string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, len(entries))
+	for i, e := range entries {
+		if infos[i], err = e.Info(); err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+func (osRemote) Stat(name string) (fs.FileInfo, error)  { return os.Stat(name) }
+func (osRemote) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+
+func TestGlobRemoteFSLikeOS(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("skipping symlink test on Windows")
+	}
+	tmpDir, err := ioutil.TempDir("", "TestGlobRemoteFSLikeOS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"a/file", "a/b/file", "c/file"} {
+		p := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(name), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{"a/link": "file", "a/broken": "missing", "c/dir": "../a"} {
+		if err := os.Symlink(target, filepath.Join(tmpDir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		opts    []Option
+	}{
+		{pattern: "*/*"},
+		{pattern: "*/*", opts: []Option{WithSkipBrokenSymlinks()}},
+		{pattern: "*/*/file"},
+		{pattern: "**/file", opts: []Option{WithGlobstar()}},
+		{pattern: "**/file", opts: []Option{WithGlobstar(), WithFollowSymlinks()}},
+		{pattern: "**", opts: []Option{WithGlobstar(), WithNonEmptyFiles()}},
+	} {
+		pattern := filepath.Join(Escape(tmpDir), tt.pattern)
+		want, err := Glob(context.Background(), pattern, tt.opts...)
+		if err != nil {
+			t.Fatalf("Glob(%q) error: %s", tt.pattern, err)
+		}
+		matches, err := Glob(context.Background(), pattern, append(tt.opts, WithRemoteFS(osRemote{}))...)
+		if err != nil {
+			t.Errorf("Glob(%q) with WithRemoteFS error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q) with WithRemoteFS, -OS +got: %v", tt.pattern, diff)
+		}
+	}
+}
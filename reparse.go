@@ -0,0 +1,68 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"io/fs"
+	"os"
+)
+
+// A ReparsePolicy says how "**" treats the junctions and mount points of
+// Windows volumes, and the other reparse points that stand in for
+// directories, as given to WithReparsePoints.
+type ReparsePolicy int
+
+const (
+	// ReparseLeaf, the default, matches a reparse point as a file, without
+	// descending into it.
+	ReparseLeaf ReparsePolicy = iota
+	// ReparseSkip neither matches a reparse point nor descends into it.
+	ReparseSkip
+	// ReparseDescend matches a reparse point as the directory it leads to,
+	// and descends into it, unless that directory is already being
+	// traversed.
+	ReparseDescend
+)
+
+// WithReparsePoints sets how "**" treats the reparse points that Windows
+// resolves to directories other than symbolic links: the junctions that
+// `mklink /J` creates, the mount points of volumes mounted in a folder, and
+// the like. os lists them as irregular files rather than directories or
+// symbolic links, so by default "**" matches them without descending into
+// them, as ReparseLeaf does.
+//
+// With ReparseDescend, they are descended into as WithFollowSymlinks
+// descends into symbolic links: a reparse point that leads back to a
+// directory being traversed is never descended into, so a junction pointing
+// at one of its parents doesn't cause infinite traversal, and reparse points
+// count towards WithMaxLinkDepth as symbolic links do. Symbolic links are
+// still only followed with WithFollowSymlinks.
+//
+// Other wildcards, such as the "*" of `C:\Users\*\Documents`, go through
+// reparse points as Windows resolves them, whatever the policy. Elsewhere than
+// on Windows, where no directory is listed as an irregular file, this option
+// has no effect.
+func WithReparsePoints(p ReparsePolicy) Option {
+	return func(o *options) {
+		o.reparse = p
+	}
+}
+
+// reparseDir returns what the entry e at path p leads to if it is a reparse
+// point standing in for a directory, with the policy of WithReparsePoints
+// other than ReparseLeaf, under which nothing is resolved.
+func (w *walker) reparseDir(p string, e fs.DirEntry) (os.FileInfo, bool) {
+	if w.opts.reparse == ReparseLeaf || e.Type()&fs.ModeIrregular == 0 {
+		return nil, false
+	}
+	fi, err := w.fsys.Stat(p)
+	if err != nil {
+		w.skipped(p, err)
+		return nil, false
+	}
+	return fi, fi.IsDir()
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// junctionFS is a MapFS in which the irregular files named by links stand in
+// for the directories they map to, as os lists the junctions of Windows.
+type junctionFS struct {
+	fstest.MapFS
+	links map[string]string
+}
+
+func (j junctionFS) resolve(name string) string {
+	for link, target := range j.links {
+		if name == link || strings.HasPrefix(name, link+"/") {
+			return path.Join(target, strings.TrimPrefix(name, link))
+		}
+	}
+	return name
+}
+
+func (j junctionFS) Open(name string) (fs.File, error) {
+	return j.MapFS.Open(j.resolve(name))
+}
+
+func (j junctionFS) Stat(name string) (fs.FileInfo, error) {
+	return j.MapFS.Stat(j.resolve(name))
+}
+
+func TestGlobstarReparsePoints(t *testing.T) {
+	fsys := junctionFS{
+		MapFS: fstest.MapFS{
+			"real/x.txt":   {},
+			"top/a.txt":    {},
+			"top/junction": {Mode: fs.ModeIrregular},
+		},
+		links: map[string]string{"top/junction": "real"},
+	}
+	for _, tt := range []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		// By default, a junction is matched as a file.
+		{pattern: "top/**", want: []string{"top", "top/a.txt", "top/junction"}},
+		{pattern: "top/**", opts: []Option{WithReparsePoints(ReparseLeaf)}, want: []string{"top", "top/a.txt", "top/junction"}},
+		{pattern: "top/**", opts: []Option{WithReparsePoints(ReparseSkip)}, want: []string{"top", "top/a.txt"}},
+		{pattern: "top/**", opts: []Option{WithReparsePoints(ReparseDescend)}, want: []string{"top", "top/a.txt", "top/junction", "top/junction/x.txt"}},
+		{pattern: "top/**/*.txt", want: []string{"top/a.txt"}},
+		{pattern: "top/**/*.txt", opts: []Option{WithReparsePoints(ReparseDescend)}, want: []string{"top/a.txt", "top/junction/x.txt"}},
+		// Other wildcards go through junctions whatever the policy.
+		{pattern: "top/*/x.txt", opts: []Option{WithReparsePoints(ReparseSkip)}, want: []string{"top/junction/x.txt"}},
+	} {
+		got, err := Glob(context.Background(), tt.pattern, append(tt.opts, WithFS(fsys), WithGlobstar())...)
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		var want []string
+		for _, p := range tt.want {
+			want = append(want, filepath.FromSlash(p))
+		}
+		if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+}
+
+func TestGlobstarJunctionCycle(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skipf("skipping windows specific test")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestGlobstarJunctionCycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := os.MkdirAll(filepath.Join(tmpDir, "a", "b"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("cmd", "/c", "mklink", "/J", filepath.Join(tmpDir, "a", "b", "up"), filepath.Join(tmpDir, "a")).CombinedOutput(); err != nil {
+		t.Skipf("mklink /J failed: %v: %s", err, out)
+	}
+
+	got, err := Glob(context.Background(), "**", WithRoot(tmpDir), WithGlobstar(), WithReparsePoints(ReparseDescend))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The junction leads back to an ancestor, so it isn't descended into.
+	want := []string{"a", `a\b`, `a\b\up`}
+	if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", "**", diff)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A ResultCache remembers the matches of the Streams it is given to with
+// WithResultCache, so that a Stream for a pattern already evaluated against
+// the same root yields the same matches again without reading anything, for
+// servers that answer the same queries over and over:
+//
+//	c := glob.NewResultCache(time.Minute)
+//	gb := glob.NewGlobber(glob.WithGlobstar(), glob.WithResultCache(c))
+//	...
+//	matches, err := gb.Glob(ctx, req.Pattern)
+//
+// Results are remembered for the time to live given to NewResultCache, or
+// until they are invalidated with Invalidate or Reset; call Invalidate when a
+// directory is known to have changed, after a deployment for example. Only
+// Streams that run to the end without an error are remembered.
+//
+// Results are known by the pattern and the root it is relative to, which is
+// the directory of WithRoot or WithConfinedRoot if any, and the working
+// directory otherwise. The pattern is taken as options such as
+// WithEnvExpansion expand it when the Stream starts, and with its "." and
+// empty elements removed, so "./a/*" and "a/*" share their results while
+// "$DIR/*" doesn't once DIR changes. Like a DirCache, a ResultCache must only be used by
+// Streams that read the same filesystem; its Streams must also have the same
+// options, which it can't tell apart. It is safe for concurrent use.
+type ResultCache struct {
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[resultKey]*resultEntry
+}
+
+// resultKey identifies the results of a ResultCache.
+type resultKey struct {
+	root    string // absolute
+	pattern string
+}
+
+// resultEntry is the results of a pattern in a ResultCache.
+type resultEntry struct {
+	matches []string
+	dir     string // the absolute directory the Stream started from
+	expires time.Time
+}
+
+// NewResultCache returns an empty ResultCache whose results expire after ttl,
+// or never if ttl isn't positive.
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{ttl: ttl, entries: make(map[resultKey]*resultEntry)}
+}
+
+// WithResultCache makes the Stream answer its pattern from c, if c has its
+// matches, and remember them in c otherwise. A Stream answered from c reads
+// nothing, so its Stats count no directories or entries; its matches have no
+// directory entries, so NDJSONMatches stats them. A nil c turns caching off.
+func WithResultCache(c *ResultCache) Option {
+	return func(o *options) {
+		o.resultCache = c
+	}
+}
+
+// Invalidate forgets the results of every pattern that might have read dir:
+// those of patterns whose directory before their first wildcard is dir, or
+// is above or below it. A relative dir is relative to the working directory.
+func (c *ResultCache) Invalidate(dir string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		c.Reset()
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if within(e.dir, dir) || within(dir, e.dir) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Reset forgets every result.
+func (c *ResultCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[resultKey]*resultEntry)
+}
+
+// within reports whether the path p is dir or below it.
+func within(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// lookup returns the matches remembered for k, if any.
+func (c *ResultCache) lookup(k resultKey) ([]string, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[k]
+	c.mu.RUnlock()
+	if !ok || c.ttl > 0 && time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.matches, true
+}
+
+// store remembers the matches for k of a Stream that started from dir.
+func (c *ResultCache) store(k resultKey, dir string, matches []string) {
+	e := &resultEntry{matches: matches, dir: dir}
+	if c.ttl > 0 {
+		e.expires = time.Now().Add(c.ttl)
+	}
+	c.mu.Lock()
+	c.entries[k] = e
+	c.mu.Unlock()
+}
+
+// resultPattern returns the form of pattern that a ResultCache knows its
+// results by: pattern as expanded by the options, and cleaned as by
+// cleanPattern, keeping any trailing separator. Patterns with brace
+// expressions are not cleaned, as an element of one may not be one of the
+// patterns it expands to.
+func (w *walker) resultPattern(pattern string) (string, error) {
+	p, err := w.expand(pattern)
+	if err != nil {
+		return "", err
+	}
+	if w.opts.braces && strings.ContainsAny(p, "{}") {
+		return p, nil
+	}
+	p, dirOnly := splitDirOnly(p)
+	p = cleanPattern(p)
+	if dirOnly {
+		p += string(filepath.Separator)
+	}
+	return p, nil
+}
+
+// useResultCache returns a function like start that yields the matches of its
+// pattern from the ResultCache of WithResultCache, or has start find them and
+// remembers them.
+func (w *walker) useResultCache(start func(string, chan<- found) error) func(string, chan<- found) error {
+	c := w.opts.resultCache
+	return func(pattern string, results chan<- found) error {
+		root, err := filepath.Abs(w.opts.root)
+		if err != nil {
+			return start(pattern, results)
+		}
+		p, err := w.resultPattern(pattern)
+		if err != nil {
+			return start(pattern, results)
+		}
+		k := resultKey{root: root, pattern: p}
+		if matches, ok := c.lookup(k); ok {
+			for _, m := range matches {
+				select {
+				case results <- found{path: m}:
+				case <-w.cancel:
+					return nil
+				}
+			}
+			return nil
+		}
+
+		dir, _ := splitScope(filepath.FromSlash(pattern))
+		if !rooted(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		var matches []string
+		err = w.rewrite(start, func(p string) (string, bool) {
+			matches = append(matches, p)
+			return p, true
+		})(pattern, results)
+		select {
+		case <-w.cancel:
+			// The Stream didn't run to the end.
+		default:
+			// A resumed Stream doesn't find every match.
+			if err == nil && w.opts.resume == nil {
+				c.store(k, dir, matches)
+			}
+		}
+		return err
+	}
+}
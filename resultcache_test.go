@@ -0,0 +1,152 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobResultCache(t *testing.T) {
+	r := &slowRemote{fsys: fstest.MapFS{
+		"src/a/a.go":  {},
+		"src/b/b.go":  {},
+		"docs/README": {},
+	}}
+	c := NewResultCache(0)
+	gb := NewGlobber(WithRemoteFS(r), WithResultCache(c))
+	want := map[string][]string{
+		"src/*/*.go": {"src/a/a.go", "src/b/b.go"},
+		"docs/*":     {"docs/README"},
+	}
+	reads := func() int {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.all
+	}
+	glob := func(pattern string, wantReads int) {
+		t.Helper()
+		before := reads()
+		matches, err := gb.Glob(context.Background(), pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want[pattern], matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", pattern, diff)
+		}
+		if got := reads() - before; got != wantReads {
+			t.Errorf("Glob(%q) read %d directories, want %d", pattern, got, wantReads)
+		}
+	}
+	glob("src/*/*.go", 3)
+	glob("docs/*", 1)
+	glob("src/*/*.go", 0)
+	glob("docs/*", 0)
+
+	// Invalidating a directory forgets the patterns that read it, and
+	// only those.
+	c.Invalidate("src/a")
+	glob("src/*/*.go", 3)
+	glob("docs/*", 0)
+	c.Invalidate(".")
+	glob("src/*/*.go", 3)
+	glob("docs/*", 1)
+	c.Reset()
+	glob("docs/*", 1)
+
+	// A Stream closed before its end isn't remembered.
+	c.Reset()
+	s := gb.Stream("src/*/*.go")
+	if m, err := s.Next(); err != nil || m == "" {
+		t.Fatalf("Next() = %q, %v, want a match", m, err)
+	}
+	s.Close()
+	for atomic.LoadInt32(&s.w.ended) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	glob("src/*/*.go", 3)
+}
+
+func TestGlobResultCacheExpandedPattern(t *testing.T) {
+	r := &slowRemote{fsys: fstest.MapFS{
+		"src/a/a.go":  {},
+		"docs/README": {},
+	}}
+	gb := NewGlobber(WithRemoteFS(r), WithResultCache(NewResultCache(0)), WithEnvExpansion())
+	glob := func(pattern string, want []string, wantReads int) {
+		t.Helper()
+		r.mu.Lock()
+		before := r.all
+		r.mu.Unlock()
+		matches, err := gb.Glob(context.Background(), pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", pattern, diff)
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if got := r.all - before; got != wantReads {
+			t.Errorf("Glob(%q) read %d directories, want %d", pattern, got, wantReads)
+		}
+	}
+
+	// Spellings of the same pattern share their results.
+	glob("src/*/*.go", []string{"src/a/a.go"}, 2)
+	glob("./src/*/*.go", []string{"src/a/a.go"}, 0)
+	glob("src//*/./*.go", []string{"src/a/a.go"}, 0)
+
+	// A pattern is known by what its variables expand to.
+	t.Setenv("GLOB_TEST_DIR", "src/a")
+	glob("$GLOB_TEST_DIR/*", []string{"src/a/a.go"}, 1)
+	t.Setenv("GLOB_TEST_DIR", "docs")
+	glob("$GLOB_TEST_DIR/*", []string{"docs/README"}, 1)
+}
+
+func TestGlobResultCacheTTL(t *testing.T) {
+	r := &slowRemote{fsys: fstest.MapFS{"a": {}}}
+	gb := NewGlobber(WithRemoteFS(r), WithResultCache(NewResultCache(10*time.Millisecond)))
+	gb.Glob(context.Background(), "*")
+	gb.Glob(context.Background(), "*")
+	if r.all != 1 {
+		t.Errorf("Glob read %d directories before its results expired, want 1", r.all)
+	}
+	time.Sleep(20 * time.Millisecond)
+	gb.Glob(context.Background(), "*")
+	if r.all != 2 {
+		t.Errorf("Glob read %d directories after its results expired, want 2", r.all)
+	}
+}
+
+func TestGlobResultCacheConcurrent(t *testing.T) {
+	r := &slowRemote{fsys: fstest.MapFS{"a/b": {}, "a/c": {}}}
+	c := NewResultCache(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				matches, err := Glob(context.Background(), "a/*", WithRemoteFS(r), WithResultCache(c))
+				if err != nil || len(matches) != 2 {
+					t.Errorf("Glob(%q) = %q, %v, want 2 matches", "a/*", matches, err)
+				}
+				if j%5 == 0 {
+					c.Invalidate("a")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
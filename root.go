@@ -0,0 +1,72 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// anchor splits a relative pattern into the directory it is relative to, as
+// set by WithRoot and WithAbsolutePaths, and the rest of the pattern. It
+// returns a base of "." for a pattern that is used as it is. rel reports
+// whether matches are to be made relative to base again.
+func (w *walker) anchor(pattern string) (base, rest string, rel bool, err error) {
+	if w.opts.confined {
+		// The tree is read relative to the root already.
+		return ".", pattern, false, nil
+	}
+	if w.opts.root != "" && !rooted(pattern) {
+		if _, ok := w.fsys.(osFS); !ok || !w.opts.absolute {
+			return filepath.Clean(w.opts.root), pattern, true, nil
+		}
+		base, err := filepath.Abs(w.opts.root)
+		return base, pattern, false, err
+	}
+	if _, ok := w.fsys.(osFS); ok && w.opts.absolute {
+		base, rest, err := absolutePattern(pattern)
+		if base == "" {
+			base = "."
+		}
+		return base, rest, false, err
+	}
+	return ".", pattern, false, nil
+}
+
+// rooted reports whether pattern is anchored by a volume name or a leading
+// separator, rather than relative to the working directory. On Windows, a
+// drive-relative pattern such as `C:*.txt` is rooted.
+func rooted(pattern string) bool {
+	return filepath.VolumeName(pattern) != "" || (len(pattern) > 0 && os.IsPathSeparator(pattern[0]))
+}
+
+// absolutePattern splits a relative pattern into the absolute directory it is
+// relative to and the rest of the pattern, for WithAbsolutePaths. It returns an
+// empty base for a pattern that is already absolute.
+func absolutePattern(pattern string) (base, rest string, err error) {
+	if filepath.IsAbs(pattern) {
+		return "", pattern, nil
+	}
+	if runtime.GOOS == "windows" {
+		if vol := filepath.VolumeName(pattern); vol != "" {
+			// A drive-relative pattern, such as `C:*.txt`, is relative to
+			// the current directory of its drive.
+			base, err := filepath.Abs(vol)
+			return base, pattern[len(vol):], err
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", err
+	}
+	if len(pattern) > 0 && os.IsPathSeparator(pattern[0]) {
+		// A pattern such as `\*.txt` is rooted on the current drive.
+		return filepath.VolumeName(cwd), pattern, nil
+	}
+	return cwd, pattern, nil
+}
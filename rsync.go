@@ -0,0 +1,247 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// A FilterRule is an include or exclude rule of an rsync filter list, as
+// given to WithFilterRules.
+//
+// Its Pattern is read as rsync reads it, with forward slashes as separators:
+//
+//   - a pattern with no slash but a trailing one, such as "*.o", is matched
+//     against the name of an entry; one with a slash or "**", such as
+//     "build/*.o", against the trailing elements of its path, relative to the
+//     directory the traversal starts from; and one starting with a slash, such
+//     as "/build", against the whole of that path;
+//   - a trailing slash, as in "cache/", makes the rule apply to directories
+//     only;
+//   - "*" matches within a path element, "**" across elements, and a trailing
+//     "/***", as in "src/***", matches a directory and everything in it;
+//   - "?", "[a-z]", "[!a-z]" and "[[:alpha:]]" match single characters, and a
+//     backslash escapes the character after it.
+//
+// A rule with a malformed pattern matches nothing.
+type FilterRule struct {
+	// Exclude is set for an exclude rule ("- pattern"), and clear for an
+	// include rule ("+ pattern").
+	Exclude bool
+	Pattern string
+}
+
+// WithFilterRules decides which entries a traversal keeps with an ordered list
+// of include and exclude rules, the way rsync's --filter does: the first rule
+// that matches an entry decides, and an entry no rule matches is kept. An
+// excluded directory is not descended into, so nothing within it matches even
+// if a later rule includes it, and the usual way to keep only some files is to
+// include the directories on the way to them:
+//
+//	rules, err := glob.ReadFilterRules(strings.NewReader(`
+//	+ */
+//	+ *.go
+//	- *
+//	`))
+//	...
+//	r := glob.Stream("src/**", glob.WithGlobstar(), glob.WithFilterRules(rules...))
+//
+// Paths are matched relative to the directory before the first wildcard of
+// the pattern, "src" above, which a rule starting with a slash is anchored to.
+// As with WithGitignore, only entries matched by a wildcard are subject to the
+// rules: a path element spelled out literally in the pattern is always used.
+func WithFilterRules(rules ...FilterRule) Option {
+	return func(o *options) {
+		o.filterRules = rules
+	}
+}
+
+// ReadFilterRules reads a list of filter rules in the form of rsync's
+// --filter-from files: one rule per line, such as "- *.o" or "+ /src/***",
+// with "include" and "exclude" accepted for "+" and "-". Blank lines and
+// lines starting with "#" or ";" are ignored, and a "!" line clears the rules
+// read so far. Rule modifiers, merge files and the other kinds of rsync rule
+// are not supported, and are reported as errors.
+func ReadFilterRules(r io.Reader) ([]FilterRule, error) {
+	var rules []FilterRule
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSuffix(s.Text(), "\r")
+		if strings.TrimSpace(text) == "" || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+		if text == "!" {
+			rules = nil
+			continue
+		}
+		kind, pattern, ok := strings.Cut(text, " ")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("glob: reading filter rules: line %d: bad rule %q", line, text)
+		}
+		switch kind {
+		case "+", "include":
+			rules = append(rules, FilterRule{Pattern: pattern})
+		case "-", "exclude":
+			rules = append(rules, FilterRule{Exclude: true, Pattern: pattern})
+		default:
+			return nil, fmt.Errorf("glob: reading filter rules: line %d: unsupported rule %q", line, text)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("glob: reading filter rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ruleExcluded reports whether the rules of WithFilterRules exclude the entry
+// name of directory dir, which is a directory if isDir is set.
+func (w *walker) ruleExcluded(dir, name string, isDir bool) bool {
+	rel, err := filepath.Rel(w.filterRoot, filepath.Join(dir, name))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		// The rules only apply within the root.
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, r := range w.opts.filterRules {
+		if r.matches(rel, isDir) {
+			return r.Exclude
+		}
+	}
+	return false
+}
+
+// matches reports whether r matches the entry with the slash-separated path
+// rel, relative to the root of the rules, which is a directory if isDir is
+// set.
+func (r FilterRule) matches(rel string, isDir bool) bool {
+	p := r.Pattern
+	if strings.HasSuffix(p, "/***") {
+		p = strings.TrimSuffix(p, "/***")
+		return (isDir && FilterRule{Pattern: p}.matches(rel, true)) || FilterRule{Pattern: p + "/**"}.matches(rel, isDir)
+	}
+	if strings.HasSuffix(p, "/") {
+		if !isDir {
+			return false
+		}
+		p = strings.TrimRight(p, "/")
+	}
+	switch {
+	case strings.HasPrefix(p, "/"):
+		return wildmatch(p[1:], rel)
+	case !strings.Contains(p, "/") && !strings.Contains(p, "**"):
+		return wildmatch(p, path.Base(rel))
+	}
+	// The pattern may match any trailing elements of the path.
+	for {
+		if wildmatch(p, rel) {
+			return true
+		}
+		i := strings.IndexByte(rel, '/')
+		if i < 0 {
+			return false
+		}
+		rel = rel[i+1:]
+	}
+}
+
+// wildmatch reports whether the slash-separated path text matches the rsync
+// pattern p, in which "*" matches within a path element and "**" across them.
+func wildmatch(p, text string) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			anything := strings.HasPrefix(p, "**")
+			p = strings.TrimLeft(p, "*")
+			for i := 0; i <= len(text); i++ {
+				if wildmatch(p, text[i:]) {
+					return true
+				}
+				if i < len(text) && text[i] == '/' && !anything {
+					return false
+				}
+			}
+			return false
+		case '?', '[':
+			if len(text) == 0 || text[0] == '/' {
+				return false
+			}
+			c, n := utf8.DecodeRuneInString(text)
+			if p[0] == '[' {
+				class, end, ok := rsyncClass(p)
+				if !ok {
+					return false
+				}
+				if matched, err := path.Match(class, string(c)); err != nil || !matched {
+					return false
+				}
+				p = p[end:]
+			} else {
+				p = p[1:]
+			}
+			text = text[n:]
+		case '\\':
+			if len(p) < 2 || !strings.HasPrefix(text, p[1:2]) {
+				return false
+			}
+			p, text = p[2:], text[1:]
+		default:
+			if len(text) == 0 || text[0] != p[0] {
+				return false
+			}
+			p, text = p[1:], text[1:]
+		}
+	}
+	return len(text) == 0
+}
+
+// rsyncClass returns the character class at the start of the rsync pattern
+// p, in the syntax of path.Match, and the offset just past it.
+func rsyncClass(p string) (class string, end int, ok bool) {
+	var b strings.Builder
+	b.WriteByte('[')
+	i := 1
+	if i < len(p) && (p[i] == '!' || p[i] == '^') {
+		b.WriteByte('^')
+		i++
+	}
+	// A "]" right after the opening bracket is a character of the class.
+	if i < len(p) && p[i] == ']' {
+		b.WriteString(`\]`)
+		i++
+	}
+	for ; i < len(p); i++ {
+		if name, next, ok := posixClassAt(p, i, len(p)); ok {
+			ranges, known := posixClasses[name]
+			if !known {
+				return "", 0, false
+			}
+			writeRanges(&b, ranges)
+			i = next - 1
+			continue
+		}
+		switch p[i] {
+		case ']':
+			b.WriteByte(']')
+			return b.String(), i + 1, true
+		case '\\':
+			if i+1 == len(p) {
+				return "", 0, false
+			}
+			b.WriteString(p[i : i+2])
+			i++
+		default:
+			b.WriteByte(p[i])
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobFilterRules(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/a.go":           {},
+		"src/a.o":            {},
+		"src/build/b.go":     {},
+		"src/cache/c.go":     {},
+		"src/lib/cache":      {},
+		"src/lib/d.go":       {},
+		"src/lib/tmp/e.go":   {},
+		"src/docs/f.md":      {},
+		"src/docs/img/g.png": {},
+	}
+	for _, tt := range []struct {
+		name  string
+		rules string
+		want  []string
+	}{
+		{
+			name: "FirstMatchWins",
+			rules: `
++ a.o
+- *.o
+`,
+			want: []string{"src/a.go", "src/a.o", "src/build", "src/build/b.go", "src/cache", "src/cache/c.go", "src/docs", "src/docs/f.md", "src/docs/img", "src/docs/img/g.png", "src/lib", "src/lib/cache", "src/lib/d.go", "src/lib/tmp", "src/lib/tmp/e.go"},
+		},
+		{
+			// Directories on the way to the files have to be included.
+			name: "OnlyGoFiles",
+			rules: `
+# Go sources only.
++ */
++ *.go
+- *
+`,
+			want: []string{"src/a.go", "src/build", "src/build/b.go", "src/cache", "src/cache/c.go", "src/docs", "src/docs/img", "src/lib", "src/lib/d.go", "src/lib/tmp", "src/lib/tmp/e.go"},
+		},
+		{
+			// "cache/" leaves the file src/lib/cache alone, and "/build"
+			// only applies at the root.
+			name: "DirectoryOnlyAndAnchored",
+			rules: `
+- cache/
+- /build
+- /lib/tmp
+- tmp
+`,
+			want: []string{"src/a.go", "src/a.o", "src/docs", "src/docs/f.md", "src/docs/img", "src/docs/img/g.png", "src/lib", "src/lib/cache", "src/lib/d.go"},
+		},
+		{
+			name: "TripleStar",
+			rules: `
++ /docs/***
+- *
+`,
+			want: []string{"src/docs", "src/docs/f.md", "src/docs/img", "src/docs/img/g.png"},
+		},
+		{
+			name: "DoubleStar",
+			rules: `
+- docs/**.png
+- lib/*.go
+`,
+			want: []string{"src/a.go", "src/a.o", "src/build", "src/build/b.go", "src/cache", "src/cache/c.go", "src/docs", "src/docs/f.md", "src/docs/img", "src/lib", "src/lib/cache", "src/lib/tmp", "src/lib/tmp/e.go"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ReadFilterRules(strings.NewReader(tt.rules))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Glob(context.Background(), "src/**", WithFS(fsys), WithGlobstar(), WithFilterRules(rules...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// "src" itself is named literally.
+			want := append([]string{"src"}, tt.want...)
+			if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+				t.Errorf("Bad results from Glob(%q), -want +got: %v", "src/**", diff)
+			}
+		})
+	}
+}
+
+func TestFilterRuleMatches(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, rel string
+		isDir, want  bool
+	}{
+		{"*.o", "a/b.o", false, true},
+		{"*.o", "a.o/b", false, false},
+		{"a/*.o", "x/a/b.o", false, true},
+		{"a/*.o", "xa/b.o", false, false},
+		{"/a/*.o", "x/a/b.o", false, false},
+		{"/a/*.o", "a/b.o", false, true},
+		{"a/**", "x/a/b/c", false, true},
+		{"a/**", "a", true, false},
+		{"a/***", "a", true, true},
+		{"a/***", "a", false, false},
+		{"a/***", "a/b", false, true},
+		{"d/", "x/d", false, false},
+		{"d/", "x/d", true, true},
+		{"[!a]", "b", false, true},
+		{"[!a]", "a", false, false},
+		{"[]]", "]", false, true},
+		{`\*`, "*", false, true},
+		{`\*`, "a", false, false},
+		{"a?c", "abc", false, true},
+		{"a?c", "a/c", false, false},
+		{"[a", "[a", false, false},
+	} {
+		if got := (FilterRule{Pattern: tt.pattern}).matches(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("FilterRule{Pattern: %q}.matches(%q, %v) = %v, want %v", tt.pattern, tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestReadFilterRules(t *testing.T) {
+	got, err := ReadFilterRules(strings.NewReader("- a\n; comment\n!\ninclude b\r\n\nexclude c/\n- d e\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FilterRule{{Pattern: "b"}, {Exclude: true, Pattern: "c/"}, {Exclude: true, Pattern: "d e"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Bad rules from ReadFilterRules, -want +got: %v", diff)
+	}
+
+	for _, text := range []string{"-", "P a", ": .rules", "-/ a"} {
+		if _, err := ReadFilterRules(strings.NewReader(text)); err == nil {
+			t.Errorf("ReadFilterRules(%q) succeeded, want error", text)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scopeSamples is the number of directories EstimateScope reads at each level
+// of the tree, and scopeLevels the number of levels below the root it reads
+// at most, which bounds it in trees with loops through symbolic links.
+const (
+	scopeSamples = 16
+	scopeLevels  = 64
+)
+
+// Scope describes the part of the filesystem a Stream for a pattern would
+// read, as estimated by EstimateScope.
+type Scope struct {
+	// Root is the directory the Stream starts reading from: the part of the
+	// pattern before its wildcards, made absolute or relative to a root as
+	// the options say, with the separators matches would have.
+	Root string
+	// Recursive reports whether the pattern descends into every directory
+	// below some point, with a "**" (see WithGlobstar).
+	Recursive bool
+	// Dirs is the estimated number of directories the Stream would read.
+	Dirs int64
+	// Exact reports whether Dirs is exact rather than estimated, because
+	// every directory was read to count it.
+	Exact bool
+}
+
+// EstimateScope reports where a Stream for pattern with opts would start
+// reading, whether it would descend recursively, and roughly how many
+// directories it would read, without running it. A pattern with no wildcards
+// reads no directories.
+//
+// The estimate reads up to 16 directories at each level of the tree that the
+// pattern reaches and extrapolates from their subdirectories that the pattern
+// would descend into; directories that can't be read are taken to be empty.
+// It takes account of WithCommonIgnores and WithGitignore, and of
+// WithFollowSymlinks for "**", but not of limits such as WithMaxVisitedDirs.
+// In a tree whose shape varies widely, such as the whole of a root
+// filesystem, it is only good to an order of magnitude, which is enough to
+// tell "/**" from "src/**".
+//
+// EstimateScope returns a *PatternError if pattern is malformed, and
+// context.Cause(ctx) if ctx is done first.
+func EstimateScope(ctx context.Context, pattern string, opts ...Option) (Scope, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := &walker{ctx: ctx, cancel: ctx.Done(), fsys: osFS{}}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+	w.useBackend(ctx)
+	pattern, err := w.expand(pattern)
+	if err != nil {
+		return Scope{}, err
+	}
+	if err := validate(pattern, false); err != nil {
+		return Scope{}, err
+	}
+	done, err := w.prepare(pattern)
+	if err != nil {
+		return Scope{}, err
+	}
+	defer done()
+	base, pattern, _, err := w.anchor(pattern)
+	if err != nil {
+		return Scope{}, err
+	}
+	w.useLimiter()
+	w.useDirCache()
+
+	root, rest := splitScope(pattern)
+	if base != "." {
+		root = filepath.Join(base, root)
+	}
+	sc := Scope{Root: root, Exact: true}
+	if w.opts.forwardSlashes {
+		sc.Root = filepath.ToSlash(root)
+	}
+	if rest == "" {
+		return sc, nil
+	}
+	elems := strings.Split(rest, string(filepath.Separator))
+	for _, e := range elems {
+		if _, ok := globstarDepth(e); ok && w.opts.globstar {
+			sc.Recursive = true
+		}
+	}
+
+	// count is the estimated number of directories at the current level,
+	// of which frontier are read.
+	frontier := []string{root}
+	count := 1.0
+	var dirs float64
+	for i, level := 0, 0; len(frontier) > 0; level++ {
+		if level == scopeLevels {
+			sc.Exact = false
+			break
+		}
+		dirs += count
+		elem := elems[i]
+		_, star := globstarDepth(elem)
+		star = star && w.opts.globstar
+		if !star && i == len(elems)-1 {
+			// The last element is matched against the entries of the
+			// directories at this level.
+			break
+		}
+		var next []string
+		for _, dir := range frontier {
+			if ctx.Err() != nil {
+				return Scope{}, context.Cause(ctx)
+			}
+			next = append(next, w.scopeSubdirs(dir, elem, star)...)
+		}
+		count *= float64(len(next)) / float64(len(frontier))
+		frontier = next
+		if len(frontier) > scopeSamples {
+			sc.Exact = false
+			frontier = make([]string, scopeSamples)
+			for j := range frontier {
+				frontier[j] = next[j*len(next)/scopeSamples]
+			}
+		}
+		if !star {
+			// After a "**", the rest of the pattern is matched in the
+			// directories it reads already.
+			i++
+		}
+	}
+	sc.Dirs = int64(dirs + 0.5)
+	return sc, nil
+}
+
+// splitScope splits pattern into the directory named by its elements before
+// the first with a wildcard, and the rest of the pattern. rest is empty if the
+// pattern has no wildcards.
+func splitScope(pattern string) (root, rest string) {
+	meta := strings.IndexAny(pattern, `*?[`)
+	if i := strings.IndexByte(pattern, '\\'); filepath.Separator != '\\' && i >= 0 && (meta < 0 || i < meta) {
+		meta = i
+	}
+	if meta < 0 {
+		return filepath.Dir(pattern), ""
+	}
+	i := meta
+	for i > 0 && !os.IsPathSeparator(pattern[i-1]) {
+		i--
+	}
+	root = pattern[:i]
+	if vol := filepath.VolumeName(root); root == "" || root == vol {
+		// A drive-relative pattern such as `C:*` starts at the current
+		// directory of the drive.
+		return root + ".", pattern[i:]
+	}
+	return filepath.Clean(root), pattern[i:]
+}
+
+// scopeSubdirs returns the subdirectories of dir that a Stream would descend
+// into for the pattern element elem, or for every subdirectory if star is set.
+// Directories that can't be read have none, nor do entries that can't be
+// stat'ed.
+func (w *walker) scopeSubdirs(dir, elem string, star bool) []string {
+	if fi, err := w.fsys.Stat(dir); err != nil || !fi.IsDir() {
+		return nil
+	}
+	d, err := w.openDir(dir, "")
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+	var subdirs []string
+	for {
+		e, err := d.next()
+		if err != nil {
+			// io.EOF, or a directory that can't be read in full.
+			return subdirs
+		}
+		if !star {
+			if ok, _ := filepath.Match(elem, e.Name()); !ok {
+				continue
+			}
+		}
+		if hasMeta(elem) && w.excluded(dir, e) {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		if e.Type()&fs.ModeSymlink != 0 && (!star || w.opts.followSymlinks) ||
+			e.Type()&fs.ModeIrregular != 0 && (!star || w.opts.reparse == ReparseDescend) {
+			if fi, err := w.fsys.Stat(p); err == nil && fi.IsDir() {
+				subdirs = append(subdirs, p)
+			}
+			continue
+		}
+		if e.IsDir() {
+			subdirs = append(subdirs, p)
+		}
+	}
+}
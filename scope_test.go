@@ -0,0 +1,67 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEstimateScope(t *testing.T) {
+	tree := fstest.MapFS{
+		"go.mod":               {},
+		"src/a/a.go":           {},
+		"src/b/b.go":           {},
+		"src/b/internal/c.go":  {},
+		"docs/README":          {},
+		"node_modules/left/x":  {},
+		"node_modules/right/y": {},
+	}
+	// A wide tree of 4 + 4*40 directories below "wide".
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 40; j++ {
+			tree[fmt.Sprintf("wide/%d/%d/x", i, j)] = &fstest.MapFile{}
+		}
+	}
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    Scope
+	}{
+		{pattern: "go.mod", want: Scope{Root: ".", Exact: true}},
+		{pattern: "src/*.go", want: Scope{Root: "src", Dirs: 1, Exact: true}},
+		{pattern: "src/*/*.go", want: Scope{Root: "src", Dirs: 3, Exact: true}},
+		{pattern: "src/b/*", want: Scope{Root: "src/b", Dirs: 1, Exact: true}},
+		{pattern: "*/a/*", want: Scope{Root: ".", Dirs: 6, Exact: true}},
+		{pattern: "src/**", opts: []Option{WithGlobstar()}, want: Scope{Root: "src", Recursive: true, Dirs: 4, Exact: true}},
+		{pattern: "**/*.go", opts: []Option{WithGlobstar()}, want: Scope{Root: ".", Recursive: true, Dirs: 174, Exact: false}},
+		{pattern: "**/*.go", opts: []Option{WithGlobstar(), WithRoot("src")}, want: Scope{Root: "src", Recursive: true, Dirs: 4, Exact: true}},
+		{pattern: "node_modules/**", opts: []Option{WithGlobstar(), WithCommonIgnores()}, want: Scope{Root: "node_modules", Recursive: true, Dirs: 3, Exact: true}},
+		{pattern: "**", opts: []Option{WithGlobstar(), WithCommonIgnores(), WithRoot("src")}, want: Scope{Root: "src", Recursive: true, Dirs: 4, Exact: true}},
+		{pattern: "wide/*/*/x", want: Scope{Root: "wide", Dirs: 165, Exact: false}},
+	}
+	for _, tt := range tests {
+		got, err := EstimateScope(context.Background(), tt.pattern, append(tt.opts, WithFS(tree))...)
+		if err != nil {
+			t.Errorf("EstimateScope(%q) error: %s", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Bad scope from EstimateScope(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	var pe *PatternError
+	if _, err := EstimateScope(context.Background(), "src/[", WithFS(tree)); !errors.As(err, &pe) {
+		t.Errorf("EstimateScope of a malformed pattern returned %v, want a *PatternError", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"hash/maphash"
+	"math"
+	"sort"
+)
+
+// A Set records which paths matched a pattern, without keeping the order in
+// which they were found.
+type Set interface {
+	// Contains reports whether path was one of the matches. The path must be
+	// in the form in which the match was produced.
+	Contains(path string) bool
+	// Len returns the number of distinct matches added to the Set, however
+	// many times each was added.
+	Len() int
+}
+
+// SetKind selects how CollectSet represents the Set it builds.
+type SetKind struct {
+	newBuilder func() setBuilder
+}
+
+// MapSet is the SetKind of a Set backed by a map. It answers Contains fastest,
+// at the greatest cost in memory.
+func MapSet() SetKind {
+	return SetKind{func() setBuilder { return make(mapSet) }}
+}
+
+// SortedSet is the SetKind of a Set backed by a sorted slice, which takes less
+// memory than MapSet but answers Contains by binary search.
+func SortedSet() SetKind {
+	return SetKind{func() setBuilder { return &sortedSet{} }}
+}
+
+// BloomSet is the SetKind of a Set backed by a Bloom filter: Contains may
+// return true for a path that didn't match, with a probability of about
+// falsePositiveRate, but never returns false for one that did. The finished
+// Set takes about 1.44*log2(1/falsePositiveRate) bits per match (10 bits for a
+// rate of 1%), whatever the length of the paths. While it is being built, it
+// takes 8 bytes per match.
+func BloomSet(falsePositiveRate float64) SetKind {
+	return SetKind{func() setBuilder { return &bloomBuilder{rate: falsePositiveRate, seed: maphash.MakeSeed()} }}
+}
+
+// CollectSet reads the remaining matches from r into a Set of the given kind.
+// It closes r if ctx is done first, and returns context.Cause(ctx).
+func CollectSet(ctx context.Context, r *Result, kind SetKind) (Set, error) {
+	b := kind.newBuilder()
+	for {
+		m, err := r.NextWithContext(ctx)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		if m == "" {
+			return b.build(), nil
+		}
+		b.add(m)
+	}
+}
+
+// setBuilder accumulates matches for a Set.
+type setBuilder interface {
+	add(path string)
+	build() Set
+}
+
+type mapSet map[string]struct{}
+
+func (s mapSet) add(path string)           { s[path] = struct{}{} }
+func (s mapSet) build() Set                { return s }
+func (s mapSet) Contains(path string) bool { _, ok := s[path]; return ok }
+func (s mapSet) Len() int                  { return len(s) }
+
+type sortedSet struct {
+	paths []string
+}
+
+func (s *sortedSet) add(path string) { s.paths = append(s.paths, path) }
+
+func (s *sortedSet) build() Set {
+	sort.Strings(s.paths)
+	// Drop the duplicates, and the spare capacity left by append.
+	paths := s.paths[:0]
+	for i, p := range s.paths {
+		if i == 0 || p != s.paths[i-1] {
+			paths = append(paths, p)
+		}
+	}
+	s.paths = append([]string(nil), paths...)
+	return s
+}
+
+func (s *sortedSet) Contains(path string) bool {
+	i := sort.SearchStrings(s.paths, path)
+	return i < len(s.paths) && s.paths[i] == path
+}
+
+func (s *sortedSet) Len() int { return len(s.paths) }
+
+// bloomBuilder collects the hashes of the matches, since a Bloom filter can
+// only be sized once the number of matches is known.
+type bloomBuilder struct {
+	rate   float64
+	seed   maphash.Seed
+	hashes []uint64
+}
+
+func (b *bloomBuilder) add(path string) {
+	b.hashes = append(b.hashes, maphash.String(b.seed, path))
+}
+
+func (b *bloomBuilder) build() Set {
+	// A match added more than once is counted, and sized for, once.
+	sort.Slice(b.hashes, func(i, j int) bool { return b.hashes[i] < b.hashes[j] })
+	hashes := b.hashes[:0]
+	for i, h := range b.hashes {
+		if i == 0 || h != b.hashes[i-1] {
+			hashes = append(hashes, h)
+		}
+	}
+	b.hashes = hashes
+	n := float64(len(b.hashes))
+	rate := b.rate
+	if rate <= 0 || rate >= 1 {
+		rate = 0.01
+	}
+	bits := math.Ceil(-n * math.Log(rate) / (math.Ln2 * math.Ln2))
+	if bits < 64 {
+		bits = 64
+	}
+	k := int(math.Round(bits / math.Max(n, 1) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	s := &bloomSet{
+		seed: b.seed,
+		bits: make([]uint64, (int(bits)+63)/64),
+		k:    k,
+		n:    len(b.hashes),
+	}
+	for _, h := range b.hashes {
+		s.set(h)
+	}
+	b.hashes = nil
+	return s
+}
+
+type bloomSet struct {
+	seed maphash.Seed
+	bits []uint64
+	k    int
+	n    int
+}
+
+// probes calls f for each of the k bit positions of the hash h, derived from it
+// by double hashing.
+func (s *bloomSet) probes(h uint64, f func(bit uint64) bool) bool {
+	m := uint64(len(s.bits)) * 64
+	h1, h2 := h&0xffffffff, h>>32|1
+	for i := 0; i < s.k; i++ {
+		if !f((h1 + uint64(i)*h2) % m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *bloomSet) set(h uint64) {
+	s.probes(h, func(bit uint64) bool {
+		s.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+}
+
+func (s *bloomSet) Contains(path string) bool {
+	return s.probes(maphash.String(s.seed, path), func(bit uint64) bool {
+		return s.bits[bit/64]&(1<<(bit%64)) != 0
+	})
+}
+
+func (s *bloomSet) Len() int { return s.n }
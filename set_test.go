@@ -0,0 +1,96 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollectSet(t *testing.T) {
+	want := []string{"testdata/a/a", "testdata/a/b", "testdata/a/c", "testdata/b/a"}
+	for i := range want {
+		want[i] = filepath.FromSlash(want[i])
+	}
+	for name, kind := range map[string]SetKind{
+		"MapSet":    MapSet(),
+		"SortedSet": SortedSet(),
+		"BloomSet":  BloomSet(0.01),
+	} {
+		r := Stream("testdata/*/*")
+		s, err := CollectSet(context.Background(), &r, kind)
+		if err != nil {
+			t.Errorf("CollectSet with %s returned unexpected error: %v", name, err)
+			continue
+		}
+		if s.Len() != len(want) {
+			t.Errorf("CollectSet with %s: Len() = %d, want %d", name, s.Len(), len(want))
+		}
+		for _, p := range want {
+			if !s.Contains(p) {
+				t.Errorf("CollectSet with %s: Contains(%q) = false, want true", name, p)
+			}
+		}
+		if name != "BloomSet" && s.Contains(filepath.FromSlash("testdata/b/b")) {
+			t.Errorf("CollectSet with %s: Contains(%q) = true, want false", name, "testdata/b/b")
+		}
+	}
+}
+
+func TestSetLenDuplicates(t *testing.T) {
+	for name, kind := range map[string]SetKind{
+		"MapSet":    MapSet(),
+		"SortedSet": SortedSet(),
+		"BloomSet":  BloomSet(0.01),
+	} {
+		b := kind.newBuilder()
+		for _, p := range []string{"b", "a", "b", "c", "a", "b"} {
+			b.add(p)
+		}
+		s := b.build()
+		if got := s.Len(); got != 3 {
+			t.Errorf("%s with duplicate matches: Len() = %d, want 3", name, got)
+		}
+		for _, p := range []string{"a", "b", "c"} {
+			if !s.Contains(p) {
+				t.Errorf("%s with duplicate matches: Contains(%q) = false, want true", name, p)
+			}
+		}
+	}
+}
+
+func TestCollectSetError(t *testing.T) {
+	r := Stream("[")
+	if _, err := CollectSet(context.Background(), &r, MapSet()); err == nil {
+		t.Error("CollectSet with a malformed pattern returned no error")
+	}
+}
+
+func TestBloomSetFalsePositiveRate(t *testing.T) {
+	const n, rate = 10000, 0.01
+	b := BloomSet(rate).newBuilder()
+	for i := 0; i < n; i++ {
+		b.add(fmt.Sprintf("match/%d", i))
+	}
+	s := b.build()
+	for i := 0; i < n; i++ {
+		if p := fmt.Sprintf("match/%d", i); !s.Contains(p) {
+			t.Fatalf("Contains(%q) = false for an added path", p)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if s.Contains(fmt.Sprintf("other/%d", i)) {
+			falsePositives++
+		}
+	}
+	if got := float64(falsePositives) / n; got > 3*rate {
+		t.Errorf("false positive rate = %v, want about %v", got, rate)
+	}
+}
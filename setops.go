@@ -0,0 +1,125 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import "context"
+
+// Union returns a Result that yields every path matched by any of streams,
+// once, as they are found, like MergeStreams. If every stream is in path order
+// (see MergeStreams), the matches are merged in that order, which takes
+// constant memory; otherwise the paths yielded so far are remembered, to leave
+// out those found again.
+//
+// Like the Results of Intersect and Subtract, the Result of Union closes the
+// streams once it is exhausted or closed, and fails with the first error of
+// any of them. The streams mustn't be read from other than through it.
+func Union(streams ...*Result) Result {
+	return merge(streams, func(ctx context.Context, g *Result) error {
+		if inPathOrder(streams) {
+			return g.mergeSorted(ctx, streams, true)
+		}
+		seen := make(map[string]bool)
+		return g.mergeFair(ctx, streams, func(p string) bool {
+			if seen[p] {
+				return false
+			}
+			seen[p] = true
+			return true
+		})
+	})
+}
+
+// Intersect returns a Result that yields the matches of a that b matches too,
+// as they are found:
+//
+//	srcs := glob.Stream("src/**", glob.WithGlobstar(), glob.WithSortedOrder())
+//	changed := glob.Stream("src/**", glob.WithGlobstar(), glob.WithSortedOrder(), glob.WithModifiedAfter(t))
+//	r := glob.Intersect(&srcs, &changed)
+//
+// If a and b are both in path order (see MergeStreams), the two are read side
+// by side, in constant memory. Otherwise the matches of b are collected first,
+// into a set in memory, and the matches of a streamed against it; to hold
+// fewer paths, make a the larger of the two.
+func Intersect(a, b *Result) Result {
+	return merge([]*Result{a, b}, func(ctx context.Context, g *Result) error {
+		return g.join(ctx, a, b, true)
+	})
+}
+
+// Subtract returns a Result that yields the matches of a that b doesn't
+// match, as they are found, for everything matching one pattern but not
+// another:
+//
+//	all := glob.Stream("src/*/*.go", glob.WithSortedOrder())
+//	tests := glob.Stream("src/*/*_test.go", glob.WithSortedOrder())
+//	r := glob.Subtract(&all, &tests)
+//
+// Its memory use is as for Intersect.
+func Subtract(a, b *Result) Result {
+	return merge([]*Result{a, b}, func(ctx context.Context, g *Result) error {
+		return g.join(ctx, a, b, false)
+	})
+}
+
+// join sends the matches of a down the results channel that are matches of b,
+// if intersect is set, or that aren't otherwise.
+func (g *Result) join(ctx context.Context, a, b *Result, intersect bool) error {
+	send := func(m found) bool {
+		select {
+		case g.results <- m:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	if !inPathOrder([]*Result{a, b}) {
+		set, err := CollectSet(ctx, b, MapSet())
+		if err != nil {
+			return ignoreDone(ctx, err)
+		}
+		for {
+			m, err := a.next(ctx)
+			if err != nil || m.path == "" {
+				return ignoreDone(ctx, err)
+			}
+			if set.Contains(m.path) == intersect && !send(m) {
+				return nil
+			}
+		}
+	}
+
+	ma, err := a.next(ctx)
+	if err != nil {
+		return ignoreDone(ctx, err)
+	}
+	mb, err := b.next(ctx)
+	if err != nil {
+		return ignoreDone(ctx, err)
+	}
+	for ma.path != "" {
+		c := -1
+		if mb.path != "" {
+			c = comparePaths(ma.path, mb.path)
+		}
+		if c > 0 {
+			// b is behind a.
+			if mb, err = b.next(ctx); err != nil {
+				return ignoreDone(ctx, err)
+			}
+			continue
+		}
+		// b is kept at a match of a, which a may match again.
+		if (c == 0) == intersect && !send(ma) {
+			return nil
+		}
+		if ma, err = a.next(ctx); err != nil {
+			return ignoreDone(ctx, err)
+		}
+	}
+	// The rest of b, if any, can't change the result.
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSetOperations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"top.go":         {},
+		"a/main.go":      {},
+		"a/main_test.go": {},
+		"a/README":       {},
+		"a-b/x.go":       {},
+		"b/c/y.go":       {},
+		"b/c/y_test.go":  {},
+		"b/z.txt":        {},
+	}
+	tests := []struct {
+		name string
+		op   func(a, b *Result) Result
+		a, b string
+		want []string
+		// inOrder is set if the patterns are in path order, and so their
+		// results with WithSortedOrder.
+		inOrder bool
+	}{
+		{
+			name: "Union",
+			op:   func(a, b *Result) Result { return Union(a, b) },
+			a:    "**/*.go",
+			b:    "a/*",
+			want: []string{"a/README", "a/main.go", "a/main_test.go", "a-b/x.go", "b/c/y.go", "b/c/y_test.go", "top.go"},
+		},
+		{
+			name: "Intersect",
+			op:   Intersect,
+			a:    "**/*.go",
+			b:    "a/*",
+			want: []string{"a/main.go", "a/main_test.go"},
+		},
+		{
+			name: "Subtract",
+			op:   Subtract,
+			a:    "**/*.go",
+			b:    "**/*_test.go",
+			want: []string{"a/main.go", "a-b/x.go", "b/c/y.go", "top.go"},
+		},
+		{
+			name:    "IntersectInPathOrder",
+			op:      Intersect,
+			a:       "**",
+			b:       "*/*.go",
+			want:    []string{"a/main.go", "a/main_test.go", "a-b/x.go"},
+			inOrder: true,
+		},
+		{
+			name: "SubtractEverything",
+			op:   Subtract,
+			a:    "**/*_test.go",
+			b:    "**",
+		},
+		{
+			name:    "SubtractNothing",
+			op:      Subtract,
+			a:       "b/**",
+			b:       "nonexistent/*",
+			want:    []string{"b", "b/c", "b/c/y.go", "b/c/y_test.go", "b/z.txt"},
+			inOrder: true,
+		},
+	}
+	for _, tt := range tests {
+		for _, sorted := range []bool{false, true} {
+			opts := []Option{WithFS(fsys), WithGlobstar()}
+			if sorted {
+				opts = append(opts, WithSortedOrder())
+			}
+			a := Stream(tt.a, opts...)
+			b := Stream(tt.b, opts...)
+			r := tt.op(&a, &b)
+			got := readAll(t, &r)
+			if diff := cmp.Diff(tt.want, got, sortStringSlices); diff != "" {
+				t.Errorf("Bad results from %s(%q, %q), sorted %v, -want +got: %v", tt.name, tt.a, tt.b, sorted, diff)
+			}
+			if sorted && tt.inOrder {
+				// The matches come in order.
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("%s(%q, %q) out of order, -want +got: %v", tt.name, tt.a, tt.b, diff)
+				}
+			}
+		}
+	}
+}
+
+func TestSetOperationsError(t *testing.T) {
+	fsys := fstest.MapFS{"a": {}}
+	for _, sorted := range []bool{false, true} {
+		opts := []Option{WithFS(fsys)}
+		if sorted {
+			opts = append(opts, WithSortedOrder())
+		}
+		a := Stream("*", opts...)
+		b := Stream("[", opts...)
+		r := Subtract(&a, &b)
+		if _, err := r.Next(); !errors.Is(err, filepath.ErrBadPattern) {
+			t.Errorf("Subtract with a malformed pattern, sorted %v: Next() error = %v, want %v", sorted, err, filepath.ErrBadPattern)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+// shortNames returns the 8.3 short names of the entries of directory dir that
+// have one, keyed by their long names, for WithShortNames. The map is empty if
+// there are none, or they can't be read, as on a filesystem other than the
+// operating system's.
+func (w *walker) shortNames(dir string) map[string]string {
+	if p, ok := w.fsys.native(dir); ok {
+		if names, err := listShortNames(p); err == nil && names != nil {
+			return names
+		}
+	}
+	return map[string]string{}
+}
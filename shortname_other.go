@@ -0,0 +1,16 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build !windows
+// +build !windows
+
+package glob
+
+// listShortNames is only meaningful on Windows; elsewhere no file has a short
+// name.
+func listShortNames(dir string) (map[string]string, error) {
+	return nil, nil
+}
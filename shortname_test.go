@@ -0,0 +1,76 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestGlobShortNames(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skipf("skipping windows specific test")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestGlobShortNames")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	long := "Program Files Test"
+	if err := os.MkdirAll(filepath.Join(tmpDir, long, "app"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	names, err := listShortNames(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, ok := names[long]
+	if !ok {
+		t.Skipf("the volume of %s doesn't generate short names", tmpDir)
+	}
+
+	// The last character of a short name such as PROGRA~1 is a digit.
+	pattern := filepath.Join(Escape(tmpDir), short[:len(short)-1]+"[0-9]", "*")
+	for _, tt := range []struct {
+		opts []Option
+		want []string
+	}{
+		{want: []string{}},
+		{opts: []Option{WithShortNames()}, want: []string{filepath.Join(tmpDir, long, "app")}},
+	} {
+		got, err := Glob(context.Background(), pattern, tt.opts...)
+		if err != nil {
+			t.Errorf("Glob(%q) returned unexpected error: %v", pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Bad results from Glob(%q), -want +got: %v", pattern, diff)
+		}
+	}
+}
+
+func TestGlobShortNamesOtherFilesystems(t *testing.T) {
+	// Only the operating system's filesystem has short names.
+	fsys := fstest.MapFS{"Program Files/app": {}}
+	got, err := Glob(context.Background(), "PROGRA~[0-9]/*", WithFS(fsys), WithShortNames())
+	if err != nil {
+		t.Fatalf("Glob(%q) returned unexpected error: %v", "PROGRA~[0-9]/*", err)
+	}
+	if diff := cmp.Diff([]string{}, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("Bad results from Glob(%q), -want +got: %v", "PROGRA~[0-9]/*", diff)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"path/filepath"
+	"syscall"
+)
+
+// listShortNames returns the 8.3 short names of the entries of directory dir,
+// keyed by their long names. An entry whose name is already a valid short
+// name, or that was created while the volume didn't generate short names, has
+// none and is left out.
+func listShortNames(dir string) (map[string]string, error) {
+	p, err := syscall.UTF16PtrFromString(extendedLengthPath(filepath.Join(dir, "*")))
+	if err != nil {
+		return nil, err
+	}
+	var data syscall.Win32finddata
+	h, err := syscall.FindFirstFile(p, &data)
+	if err != nil {
+		if err == syscall.ERROR_FILE_NOT_FOUND {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer syscall.FindClose(h)
+
+	names := make(map[string]string)
+	for {
+		if short := syscall.UTF16ToString(data.AlternateFileName[:]); short != "" {
+			names[syscall.UTF16ToString(data.FileName[:])] = short
+		}
+		if err := syscall.FindNextFile(h, &data); err != nil {
+			if err == syscall.ERROR_NO_MORE_FILES {
+				return names, nil
+			}
+			return names, err
+		}
+	}
+}
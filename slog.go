@@ -0,0 +1,30 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build go1.21
+
+package glob
+
+import "log/slog"
+
+// WithLogger makes the Stream log to l, at debug level, what it skips and why:
+// directories and symbolic links that can't be stat'ed, for example for lack
+// of permission, symbolic links that loop, special files not stat'ed, entries
+// ignored by WithGitignore and WithCommonIgnores, and directories on other
+// filesystems with WithOneFileSystem. When it ends, the Stream logs the
+// directories and entries it read, how long it took and the error that
+// stopped it, if any.
+//
+// WithLogger requires Go 1.21 or later.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		if l == nil {
+			o.debug = nil
+			return
+		}
+		o.debug = l.Debug
+	}
+}
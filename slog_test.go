@@ -0,0 +1,67 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+//go:build go1.21
+
+package glob
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGlobLogger(t *testing.T) {
+	r := &deniedRemote{
+		slowRemote: slowRemote{fsys: fstest.MapFS{
+			"public/x":            {},
+			"secret/x":            {},
+			"node_modules/left/x": {},
+		}},
+		denied: "secret",
+	}
+	var b bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	matches, err := Glob(context.Background(), "*/x", WithRemoteFS(r), WithLogger(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("Glob matched %q, want public/x", matches)
+	}
+	matches, err = Glob(context.Background(), "**/x", WithRemoteFS(r), WithGlobstar(), WithCommonIgnores(), WithLogger(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("Glob matched %q, want public/x", matches)
+	}
+
+	log := b.String()
+	for _, want := range []string{
+		`level=DEBUG msg="glob: skipping path that can't be stat'ed" path=secret err="stat secret: permission denied"`,
+		`level=DEBUG msg="glob: skipping commonly ignored directory" path=node_modules`,
+		`level=DEBUG msg="glob: done" pattern=*/x dirs=3 entries=5`,
+		`level=DEBUG msg="glob: done" pattern=**/x`,
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("Log lacks %s:\n%s", want, log)
+		}
+	}
+
+	// Without a logger at debug level, nothing is logged.
+	b.Reset()
+	l = slog.New(slog.NewTextHandler(&b, nil))
+	if _, err := Glob(context.Background(), "*/x", WithRemoteFS(r), WithLogger(l)); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Logged at info level:\n%s", b.String())
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// snapshotVersion is the version of the format written by Snapshot.WriteTo.
+const snapshotVersion = 1
+
+// A Snapshot records the matches of a Stream at one time, and optionally their
+// sizes and modification times, so that a later run can be compared with it:
+//
+//	r := glob.Stream("dist/**", glob.WithGlobstar())
+//	snap, err := glob.TakeSnapshot(ctx, &r, true)
+//	...
+//	r = glob.Stream("dist/**", glob.WithGlobstar())
+//	changes := snap.Diff(&r)
+//	defer changes.Close()
+//	for {
+//		e, err := changes.Next()
+//		...
+//	}
+//
+// A Snapshot can be saved with WriteTo and loaded with ReadSnapshot, for
+// comparing runs of different processes. It is not modified once taken, and
+// is safe for concurrent use.
+type Snapshot struct {
+	info  bool
+	files map[string]snapshotFile
+}
+
+// snapshotFile is a match recorded in a Snapshot.
+type snapshotFile struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// snapshotFileData is the form in which a Snapshot is written.
+type snapshotFileData struct {
+	Version int
+	Info    bool
+	Files   map[string]snapshotFile
+}
+
+// TakeSnapshot reads the remaining matches of r into a Snapshot, with their
+// sizes and modification times if info is set, as for NDJSONMatches. A match
+// whose metadata can't be read, because it was deleted in the meantime for
+// example, is left out. TakeSnapshot closes r if ctx is done first, and
+// returns context.Cause(ctx).
+func TakeSnapshot(ctx context.Context, r *Result, info bool) (*Snapshot, error) {
+	s := &Snapshot{info: info, files: make(map[string]snapshotFile)}
+	for {
+		m, err := r.next(ctx)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		if m.path == "" {
+			return s, nil
+		}
+		var f snapshotFile
+		if info {
+			fi, err := r.info(m)
+			if err != nil {
+				continue
+			}
+			f = snapshotFile{Size: fi.Size(), ModTime: fi.ModTime()}
+		}
+		s.files[m.path] = f
+	}
+}
+
+// ReadSnapshot reads a Snapshot written by Snapshot.WriteTo.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var data snapshotFileData
+	if err := gob.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("glob: reading snapshot: %w", err)
+	}
+	if data.Version != snapshotVersion {
+		return nil, fmt.Errorf("glob: reading snapshot: unsupported version %d", data.Version)
+	}
+	if data.Files == nil {
+		data.Files = make(map[string]snapshotFile)
+	}
+	return &Snapshot{info: data.Info, files: data.Files}, nil
+}
+
+// WriteTo writes the Snapshot to w, for ReadSnapshot, and returns the number
+// of bytes written.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := gob.NewEncoder(cw).Encode(&snapshotFileData{Version: snapshotVersion, Info: s.info, Files: s.files})
+	return cw.n, err
+}
+
+// Len returns the number of matches in the Snapshot.
+func (s *Snapshot) Len() int {
+	return len(s.files)
+}
+
+// Paths returns the matches in the Snapshot, sorted.
+func (s *Snapshot) Paths() []string {
+	paths := make([]string, 0, len(s.files))
+	for p := range s.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Diff returns a Watcher that reports how the remaining matches of r differ
+// from the Snapshot, as r produces them: a Create event for each match that
+// isn't in the Snapshot, a Modify event for each whose size or modification
+// time has changed, if the Snapshot has them, and, once r is exhausted, a
+// Remove event for each path in the Snapshot that r didn't match. Once every
+// difference has been reported, Next returns an Event with a zero Op. The
+// Watcher closes r once it is done or closed.
+func (s *Snapshot) Diff(r *Result) Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	wt := Watcher{
+		errors: make(chan error),
+		events: make(chan Event),
+		cancel: cancel,
+	}
+	go func() {
+		defer close(wt.events)
+		defer close(wt.errors)
+		defer r.Close()
+		if err := s.diff(ctx, r, wt.events); err != nil {
+			select {
+			case wt.errors <- err:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return wt
+}
+
+// diff sends the differences between the Snapshot and the matches of r down
+// the events channel.
+func (s *Snapshot) diff(ctx context.Context, r *Result, events chan<- Event) error {
+	seen := make(map[string]bool, len(s.files))
+	for {
+		m, err := r.next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if m.path == "" {
+			break
+		}
+		if seen[m.path] {
+			continue
+		}
+		seen[m.path] = true
+		old, ok := s.files[m.path]
+		var e Event
+		switch {
+		case !ok:
+			e = Event{Op: Create, Path: m.path}
+		case s.info:
+			fi, err := r.info(m)
+			if err != nil {
+				// It was deleted since it was matched.
+				seen[m.path] = false
+				continue
+			}
+			if fi.Size() == old.Size && fi.ModTime().Equal(old.ModTime) {
+				continue
+			}
+			e = Event{Op: Modify, Path: m.path}
+		default:
+			continue
+		}
+		if send(ctx, events, e) != nil {
+			return nil
+		}
+	}
+	for _, p := range s.Paths() {
+		if !seen[p] {
+			if send(ctx, events, Event{Op: Remove, Path: p}) != nil {
+				return nil
+			}
+		}
+	}
+	return nil
+}
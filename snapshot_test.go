@@ -0,0 +1,116 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// diffEvents returns the events reported by wt, sorted.
+func diffEvents(t *testing.T, wt Watcher) []Event {
+	t.Helper()
+	defer wt.Close()
+	var events []Event
+	for {
+		e, err := wt.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Op == 0 {
+			break
+		}
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "globtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	write := func(name, data string) {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("kept", "a")
+	write("changed", "a")
+	write("removed", "a")
+
+	pattern := filepath.Join(tmpDir, "*")
+	snap := func(info bool) *Snapshot {
+		r := Stream(pattern)
+		s, err := TakeSnapshot(context.Background(), &r, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	withInfo, withoutInfo := snap(true), snap(false)
+	if withInfo.Len() != 3 {
+		t.Errorf("Snapshot of %q has %d matches, want 3", pattern, withInfo.Len())
+	}
+
+	// A Snapshot reads back as written.
+	var b bytes.Buffer
+	if _, err := withInfo.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSnapshot(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(withInfo.Paths(), read.Paths()); diff != "" {
+		t.Errorf("ReadSnapshot read different paths, -want +got: %v", diff)
+	}
+
+	write("changed", "ab")
+	write("added", "a")
+	if err := os.Remove(filepath.Join(tmpDir, "removed")); err != nil {
+		t.Fatal(err)
+	}
+	path := func(name string) string { return filepath.Join(tmpDir, name) }
+	for _, tt := range []struct {
+		name string
+		s    *Snapshot
+		want []Event
+	}{
+		{
+			name: "with info",
+			s:    read,
+			want: []Event{{Create, path("added")}, {Modify, path("changed")}, {Remove, path("removed")}},
+		},
+		{
+			name: "without info",
+			s:    withoutInfo,
+			want: []Event{{Create, path("added")}, {Remove, path("removed")}},
+		},
+	} {
+		r := Stream(pattern)
+		got := diffEvents(t, tt.s.Diff(&r))
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Bad events from Diff of a snapshot %s, -want +got: %v", tt.name, diff)
+		}
+	}
+}
+
+func TestReadSnapshotError(t *testing.T) {
+	if _, err := ReadSnapshot(bytes.NewReader([]byte("not a snapshot"))); err == nil {
+		t.Error("ReadSnapshot of garbage succeeded, want an error")
+	}
+}
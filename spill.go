@@ -0,0 +1,209 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// WithSpillToDisk makes a Stream read ahead of its consumer instead of waiting
+// for it to take each match, so that a slow consumer doesn't hold up reading
+// the filesystem. Up to inMemory matches (at least one) wait in memory; any
+// more are written to a temporary file in dir, or in os.TempDir() if dir is
+// empty, and read back in order as the consumer catches up, so that the memory
+// a huge result set takes stays bounded. The file is removed once the Stream
+// has ended and its matches have been taken, or it is closed.
+//
+// Matches read back from the file have lost their directory entries, so
+// NDJSONMatches stats them again. An error writing or reading the file
+// stops the Stream, and is returned in its place.
+//
+// Glob takes every match as soon as it is found, so this option makes no
+// difference to it: its result is a slice in memory. To keep a result set too
+// large for memory, use Stream and Result.WriteTo instead.
+func WithSpillToDisk(dir string, inMemory int) Option {
+	return func(o *options) {
+		o.spill = true
+		o.spillDir = dir
+		o.spillMemory = inMemory
+	}
+}
+
+// spill returns a function like start that takes each match from start as
+// soon as it is sent and queues it for the results channel, in memory or on
+// disk, as set by WithSpillToDisk.
+func (w *walker) spill(start func(string, chan<- found) error) func(string, chan<- found) error {
+	return func(pattern string, results chan<- found) error {
+		raw := make(chan found)
+		var err error
+		go func() {
+			err = start(pattern, raw)
+			close(raw)
+		}()
+		q := &spillQueue{dir: w.opts.spillDir, max: w.opts.spillMemory}
+		if q.max < 1 {
+			q.max = 1
+		}
+		defer q.close()
+		in := raw
+		for in != nil || q.len() > 0 {
+			var out chan<- found
+			var head found
+			if q.len() > 0 {
+				head, out = q.peek(), results
+			}
+			var qerr error
+			select {
+			case m, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				qerr = q.push(m)
+			case out <- head:
+				qerr = q.pop()
+			case <-w.cancel:
+				for range raw {
+					// Drain raw until start notices cancel.
+				}
+				return err
+			}
+			if qerr != nil {
+				w.abort()
+				for range raw {
+					// Drain raw until start notices abort.
+				}
+				return qerr
+			}
+		}
+		return err
+	}
+}
+
+// spillQueue is a queue of matches whose head is held in memory, and whose
+// tail beyond max matches is in a temporary file created on first use.
+type spillQueue struct {
+	dir string
+	max int
+
+	mem []found // the head of the queue
+
+	f      *os.File
+	w      *bufio.Writer
+	r      *bufio.Reader
+	rOff   int64 // the offset in f of the next record to be read
+	unread int   // the number of records in f not yet read
+	buf    []byte
+}
+
+// len returns the number of matches in the queue.
+func (q *spillQueue) len() int {
+	return len(q.mem) + q.unread
+}
+
+// peek returns the match at the head of the queue, which mustn't be empty.
+func (q *spillQueue) peek() found {
+	return q.mem[0]
+}
+
+// push adds m to the tail of the queue.
+func (q *spillQueue) push(m found) error {
+	if q.unread == 0 && len(q.mem) < q.max {
+		q.mem = append(q.mem, m)
+		return nil
+	}
+	if q.f == nil {
+		f, err := os.CreateTemp(q.dir, "glob-spill-*")
+		if err != nil {
+			return err
+		}
+		q.f = f
+		q.w = bufio.NewWriter(f)
+		q.r = bufio.NewReader(&spillReader{q: q})
+	}
+	q.buf = binary.AppendUvarint(q.buf[:0], uint64(len(m.path)))
+	q.buf = append(q.buf, m.path...)
+	if _, err := q.w.Write(q.buf); err != nil {
+		return err
+	}
+	q.unread++
+	return nil
+}
+
+// pop removes the match at the head of the queue, which mustn't be empty,
+// and refills memory from the file once its matches there have all been
+// taken.
+func (q *spillQueue) pop() error {
+	q.mem[0] = found{}
+	q.mem = q.mem[1:]
+	if len(q.mem) > 0 || q.unread == 0 {
+		return nil
+	}
+	if err := q.w.Flush(); err != nil {
+		return err
+	}
+	for len(q.mem) < q.max && q.unread > 0 {
+		n, err := binary.ReadUvarint(q.r)
+		if err != nil {
+			return unexpected(err)
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(q.r, b); err != nil {
+			return unexpected(err)
+		}
+		q.mem = append(q.mem, found{path: string(b)})
+		q.unread--
+	}
+	if q.unread == 0 {
+		// Start the file over, so that the disk it takes stays bounded as
+		// well as long as the consumer keeps up now and then.
+		if err := q.f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := q.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		q.rOff = 0
+		q.w.Reset(q.f)
+		q.r.Reset(&spillReader{q: q})
+	}
+	return nil
+}
+
+// close removes the queue's file, if any.
+func (q *spillQueue) close() {
+	if q.f != nil {
+		q.f.Close()
+		os.Remove(q.f.Name())
+	}
+}
+
+// spillReader reads the records of a spillQueue's file that haven't been read
+// yet.
+type spillReader struct {
+	q *spillQueue
+}
+
+func (r *spillReader) Read(p []byte) (int, error) {
+	n, err := r.q.f.ReadAt(p, r.q.rOff)
+	r.q.rOff += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// unexpected returns err, or io.ErrUnexpectedEOF in place of io.EOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
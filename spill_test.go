@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// waitForSpill waits until the number of spill files in dir is n.
+func waitForSpill(t *testing.T, dir string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		files, err := filepath.Glob(filepath.Join(dir, "glob-spill-*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Found %d spill files, want %d", len(files), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGlobSpillToDisk(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "globtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := fstest.MapFS{}
+	var want []string
+	for i := 0; i < 1000; i++ {
+		name := fmt.Sprintf("dir%d/file%d", i%10, i)
+		fsys[name] = &fstest.MapFile{}
+		want = append(want, name)
+	}
+
+	r := Stream("*/*", WithFS(fsys), WithSpillToDisk(tmpDir, 10))
+	// The Stream reads ahead of its consumer, into the file.
+	waitForSpill(t, tmpDir, 1)
+	var got []string
+	for {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == "" {
+			break
+		}
+		got = append(got, m)
+	}
+	if diff := cmp.Diff(want, got, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Stream(%q), -want +got: %v", "*/*", diff)
+	}
+	waitForSpill(t, tmpDir, 0)
+}
+
+func TestGlobSpillToDiskClose(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "globtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fsys := fstest.MapFS{}
+	for i := 0; i < 100; i++ {
+		fsys[fmt.Sprintf("file%d", i)] = &fstest.MapFile{}
+	}
+	r := Stream("*", WithFS(fsys), WithSpillToDisk(tmpDir, 1))
+	waitForSpill(t, tmpDir, 1)
+	if m, err := r.Next(); err != nil || m == "" {
+		t.Fatalf("Next() = %q, %v, want a match", m, err)
+	}
+	r.Close()
+	waitForSpill(t, tmpDir, 0)
+}
+
+func TestSpillQueueOrder(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "globtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	q := &spillQueue{dir: tmpDir, max: 3}
+	defer q.close()
+	var want, got []string
+	next := 0
+	// Interleave pushes and pops, so that the file is drained, started
+	// over and refilled.
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 2+round*3; i++ {
+			p := fmt.Sprintf("path%d", next)
+			next++
+			want = append(want, p)
+			if err := q.push(found{path: p}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 1+round*2 && q.len() > 0; i++ {
+			got = append(got, q.peek().path)
+			if err := q.pop(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for q.len() > 0 {
+		got = append(got, q.peek().path)
+		if err := q.pop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Bad order from spillQueue, -want +got: %v", diff)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"archive/tar"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// TarReader reads the entries of a tar archive whose names match a pattern.
+// The archive is read sequentially, once, so it can come from a pipe or a
+// decompressing reader; to read a .tgz file, wrap it with gzip.NewReader.
+//
+// Entries are matched as Stream would match files of those names, relative to
+// the root of the archive, but without directories having entries of their
+// own: "logs/**/*.json" (see WithGlobstar) matches "logs/2024/01/a.json"
+// whether or not the archive has entries for "logs" and its subdirectories.
+// Leading "./" and "/" are ignored in entry names. Options that filter by
+// metadata, such as WithMinSize and WithFilter, apply to each entry's header;
+// symbolic links are not followed.
+type TarReader struct {
+	tr    *tar.Reader
+	match func(name string) bool
+	opts  options
+}
+
+// NewTarReader returns a TarReader that reads the entries of tr that match
+// pattern. pattern is slash-separated, as entry names are, and is expanded as
+// Stream expands it, by options such as WithBraceExpansion; as for Stream, a
+// pattern ending in a separator matches only directories, here the entries of
+// type tar.TypeDir. It returns a *PatternError if pattern is malformed.
+func NewTarReader(tr *tar.Reader, pattern string, opts ...Option) (*TarReader, error) {
+	match, err := newPathMatcher(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := &TarReader{tr: tr, match: match}
+	for _, opt := range opts {
+		opt(&t.opts)
+	}
+	return t, nil
+}
+
+// Next advances to the next matching entry of the archive and returns its
+// header, with its name as it is in the archive. It returns io.EOF at the end
+// of the archive. Read reads the contents of the entry.
+func (t *TarReader) Next() (*tar.Header, error) {
+	for {
+		hdr, err := t.tr.Next()
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean("/" + hdr.Name)[1:]
+		if name == "" {
+			continue
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			name += "/"
+		}
+		if !t.match(name) {
+			continue
+		}
+		fi := hdr.FileInfo()
+		if t.opts.filtersByInfo() && !t.opts.keepInfo(fi) {
+			continue
+		}
+		if len(t.opts.filters) > 0 && !t.opts.accepted(strings.TrimSuffix(name, "/"), fs.FileInfoToDirEntry(fi)) {
+			continue
+		}
+		return hdr, nil
+	}
+}
+
+// Read reads from the current entry of the archive, as for tar.Reader.
+func (t *TarReader) Read(b []byte) (int, error) {
+	return t.tr.Read(b)
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTarReader(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range []struct {
+		name string
+		data string
+		typ  byte
+	}{
+		{name: "./", typ: tar.TypeDir},
+		{name: "./logs/", typ: tar.TypeDir},
+		{name: "./logs/a.json", data: "{}"},
+		{name: "./logs/empty.json"},
+		{name: "./logs/2024/01/b.json", data: "[]"},
+		{name: "./logs/2024/01/b.txt", data: "b"},
+		{name: "/etc/passwd", data: "root"},
+		{name: "link.json", typ: tar.TypeSymlink},
+	} {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typ, Size: int64(len(e.data)), Mode: 0644}
+		if e.typ == tar.TypeSymlink {
+			hdr.Linkname = "logs/a.json"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, e.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    []string
+	}{
+		{pattern: "logs/*.json", want: []string{"./logs/a.json", "./logs/empty.json"}},
+		{pattern: "logs/**/*.json", opts: []Option{WithGlobstar()}, want: []string{"./logs/a.json", "./logs/empty.json", "./logs/2024/01/b.json"}},
+		{pattern: "logs/**", opts: []Option{WithGlobstar()}, want: []string{"./logs/", "./logs/a.json", "./logs/empty.json", "./logs/2024/01/b.json", "./logs/2024/01/b.txt"}},
+		{pattern: "**/*.json", opts: []Option{WithGlobstar(), WithNonEmptyFiles()}, want: []string{"./logs/a.json", "./logs/2024/01/b.json"}},
+		{pattern: "*", want: []string{"./logs/", "link.json"}},
+		{pattern: "etc/passwd", want: []string{"/etc/passwd"}},
+		{pattern: "logs/*/*/b.*", want: []string{"./logs/2024/01/b.json", "./logs/2024/01/b.txt"}},
+		{pattern: "nonexistent/*", want: nil},
+		{pattern: "{logs,etc}/[ap]*", opts: []Option{WithBraceExpansion()}, want: []string{"./logs/a.json", "/etc/passwd"}},
+		{pattern: "*/", want: []string{"./logs/"}},
+		{pattern: "logs/*.{json,txt}", opts: []Option{WithMinimatch()}, want: []string{"./logs/a.json", "./logs/empty.json"}},
+	}
+	for _, tt := range tests {
+		gr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := NewTarReader(tar.NewReader(gr), tt.pattern, tt.opts...)
+		if err != nil {
+			t.Fatalf("NewTarReader(%q) error: %v", tt.pattern, err)
+		}
+		var got []string
+		for {
+			hdr, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Next() error for %q: %v", tt.pattern, err)
+			}
+			got = append(got, hdr.Name)
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Read() error for %q: %v", tt.pattern, err)
+			}
+			if int64(len(data)) != hdr.Size {
+				t.Errorf("read %d bytes of %s, want %d", len(data), hdr.Name, hdr.Size)
+			}
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Bad results from NewTarReader(%q), -want +got: %v", tt.pattern, diff)
+		}
+	}
+
+	if _, err := NewTarReader(tar.NewReader(&buf), "logs/[", WithGlobstar()); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("NewTarReader(%q) returned error %v, want ErrBadPattern", "logs/[", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import "time"
+
+// Tracer traces Streams, for WithTracer.
+//
+// An adapter for a tracing library is a few lines, which keeps this package
+// free of its dependencies. For OpenTelemetry, a Tracer made for each context
+// the Stream runs in:
+//
+//	type otelTracer struct {
+//		ctx    context.Context
+//		tracer trace.Tracer
+//	}
+//
+//	func (t otelTracer) StartStream(pattern string) glob.StreamSpan {
+//		ctx, span := t.tracer.Start(t.ctx, "glob.Stream", trace.WithAttributes(attribute.String("glob.pattern", pattern)))
+//		return otelSpan{ctx: ctx, tracer: t.tracer, span: span}
+//	}
+//
+//	type otelSpan struct {
+//		ctx    context.Context
+//		tracer trace.Tracer
+//		span   trace.Span
+//	}
+//
+//	func (s otelSpan) SlowDir(dir string, start time.Time, elapsed time.Duration) {
+//		_, span := s.tracer.Start(s.ctx, "glob.ReadDir", trace.WithTimestamp(start), trace.WithAttributes(attribute.String("glob.dir", dir)))
+//		span.End(trace.WithTimestamp(start.Add(elapsed)))
+//	}
+//
+//	func (s otelSpan) End(st glob.Stats, matches int64, err error) {
+//		s.span.SetAttributes(
+//			attribute.Int64("glob.dirs", st.Dirs),
+//			attribute.Int64("glob.entries", st.Entries),
+//			attribute.Int64("glob.matches", matches))
+//		if err != nil {
+//			s.span.RecordError(err)
+//			s.span.SetStatus(codes.Error, err.Error())
+//		}
+//		s.span.End()
+//	}
+type Tracer interface {
+	// StartStream is called when a Stream for pattern is created.
+	StartStream(pattern string) StreamSpan
+}
+
+// StreamSpan traces a single Stream.
+type StreamSpan interface {
+	// SlowDir is called after reading the directory dir, which started at
+	// start, took at least the threshold set by WithTracer. elapsed only
+	// counts the time spent reading, not the time spent waiting for
+	// matches to be received in between. SlowDir may be called
+	// concurrently.
+	SlowDir(dir string, start time.Time, elapsed time.Duration)
+	// End is called once, when the Stream ends, with the work it did, the
+	// number of matches it produced and the error it stopped with, if any.
+	// A Stream that is closed ends once it notices.
+	End(st Stats, matches int64, err error)
+}
+
+// WithTracer makes each Stream traced by a StreamSpan from t, from when it is
+// created until it ends. Directories that take at least slowDir to read are
+// reported to the span with SlowDir; with a slowDir of 0, every directory is.
+// With a Tracer, reading each entry costs two more calls to time.Now.
+func WithTracer(t Tracer, slowDir time.Duration) Option {
+	return func(o *options) {
+		o.tracer = t
+		o.slowDir = slowDir
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingTracer records the spans of Streams.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) StartStream(pattern string) StreamSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &recordingSpan{pattern: pattern}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+type recordingSpan struct {
+	pattern string
+
+	mu       sync.Mutex
+	slowDirs []string
+	ended    int
+	stats    Stats
+	matches  int64
+	err      error
+}
+
+func (s *recordingSpan) SlowDir(dir string, start time.Time, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slowDirs = append(s.slowDirs, filepath.ToSlash(dir))
+}
+
+func (s *recordingSpan) End(st Stats, matches int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended++
+	s.stats, s.matches, s.err = st, matches, err
+}
+
+func TestGlobTracer(t *testing.T) {
+	r := &slowRemote{fsys: fstest.MapFS{
+		"logs/a/x.log": {},
+		"logs/a/y.log": {},
+		"logs/b/z.log": {},
+	}, latency: 10 * time.Millisecond}
+	tracer := &recordingTracer{}
+	matches, err := Glob(context.Background(), "logs/*/*.log", WithRemoteFS(r), WithTracer(tracer, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Glob started %d spans, want 1", len(tracer.spans))
+	}
+	s := tracer.spans[0]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pattern != "logs/*/*.log" || s.ended != 1 || s.err != nil {
+		t.Errorf("Span of %q ended %d times with error %v, want once with no error", s.pattern, s.ended, s.err)
+	}
+	if s.matches != int64(len(matches)) || s.stats.Dirs != 3 {
+		t.Errorf("Span ended with %d matches and %d directories, want %d and 3", s.matches, s.stats.Dirs, len(matches))
+	}
+	sort.Strings(s.slowDirs)
+	if diff := cmp.Diff([]string{"logs", "logs/a", "logs/b"}, s.slowDirs); diff != "" {
+		t.Errorf("Bad slow directories, -want +got: %v", diff)
+	}
+
+	tracer = &recordingTracer{}
+	if _, err := Glob(context.Background(), "testdata/*/[", WithTracer(tracer, time.Hour)); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Fatalf("Glob with a bad pattern returned %v", err)
+	}
+	s = tracer.spans[0]
+	if !errors.Is(s.err, filepath.ErrBadPattern) || len(s.slowDirs) != 0 {
+		t.Errorf("Span of a bad pattern ended with error %v and slow directories %q, want %v and none", s.err, s.slowDirs, filepath.ErrBadPattern)
+	}
+}
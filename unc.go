@@ -0,0 +1,76 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// splitShare splits a UNC pattern of the form `\\server\share\rest`, whose
+// share element contains wildcards, into its server, the share element and
+// the rest, which is empty or starts with a separator. Extended-length and
+// device paths (`\\?\`, `\\.\`) are not split.
+func splitShare(pattern string) (server, share, rest string, ok bool) {
+	if len(pattern) < 3 || !os.IsPathSeparator(pattern[0]) || !os.IsPathSeparator(pattern[1]) || os.IsPathSeparator(pattern[2]) {
+		return "", "", "", false
+	}
+	elem := func(s string) (string, string) {
+		i := 0
+		for i < len(s) && !os.IsPathSeparator(s[i]) {
+			i++
+		}
+		return s[:i], s[i:]
+	}
+	server, rest = elem(pattern[2:])
+	if server == "?" || server == "." || hasMeta(server) || len(rest) < 2 {
+		return "", "", "", false
+	}
+	share, rest = elem(rest[1:])
+	if !hasMeta(share) {
+		return "", "", "", false
+	}
+	return server, share, rest, true
+}
+
+// streamShares sends the matches of the pattern made of the shares of server
+// that match sharePattern, followed by rest, down the results channel.
+func (w *walker) streamShares(server, sharePattern, rest string, results chan<- found) error {
+	names, err := listShares(server)
+	if err != nil {
+		return err
+	}
+	if w.opts.sorted {
+		sort.Strings(names)
+	}
+	prefix := string(filepath.Separator) + string(filepath.Separator) + server + string(filepath.Separator)
+	for _, n := range names {
+		matched, err := filepath.Match(sharePattern, n)
+		if err != nil {
+			return badPattern(sharePattern)
+		}
+		if !matched {
+			continue
+		}
+		if rest == "" || rest == string(filepath.Separator) {
+			if w.filtered(prefix+n, nil) {
+				continue
+			}
+			select {
+			case results <- found{path: prefix + n}:
+			case <-w.cancel:
+				return nil
+			}
+			continue
+		}
+		if err := w.streamFinal(prefix+Escape(n)+rest, results); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitShare(t *testing.T) {
+	for _, tt := range []struct {
+		pattern             string
+		server, share, rest string
+		ok                  bool
+	}{
+		{pattern: `\\server\*`, server: "server", share: "*", rest: "", ok: true},
+		{pattern: `\\server\sh?re\logs\*.log`, server: "server", share: "sh?re", rest: `\logs\*.log`, ok: true},
+		{pattern: `\\server\share\*`},
+		{pattern: `\\server`},
+		{pattern: `\\server\`},
+		{pattern: `\\serv*\share`},
+		{pattern: `\\?\C:\*`},
+		{pattern: `\\.\pipe\*`},
+		{pattern: `\\\x\*`},
+		{pattern: `\x\*`},
+	} {
+		pattern := strings.ReplaceAll(tt.pattern, `\`, string(filepath.Separator))
+		rest := strings.ReplaceAll(tt.rest, `\`, string(filepath.Separator))
+		server, share, gotRest, ok := splitShare(pattern)
+		if server != tt.server || share != tt.share || gotRest != rest || ok != tt.ok {
+			t.Errorf("splitShare(%#q) = %q, %q, %q, %v; want %q, %q, %q, %v", pattern, server, share, gotRest, ok, tt.server, tt.share, rest, tt.ok)
+		}
+	}
+}
+
+func TestWindowsGlobExtendedLengthPath(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skipf("skipping windows specific test")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "TestWindowsGlobExtendedLengthPath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, d := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, d, "logs"), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, d, "logs", "x.log"), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	abs, err := filepath.Abs(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := `\\?\` + abs
+	pattern := Escape(root) + `\*\logs\*.log`
+	matches, err := Glob(context.Background(), pattern)
+	if err != nil {
+		t.Fatalf("Glob(%#q) error: %s", pattern, err)
+	}
+	want := []string{root + `\a\logs\x.log`, root + `\b\logs\x.log`}
+	if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(%#q), -want +got: %v", pattern, diff)
+	}
+}
+
+func TestWindowsShareEnumeration(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skipf("skipping windows specific test")
+	}
+	shares, err := listShares("localhost")
+	if err != nil || len(shares) == 0 {
+		t.Skipf("no shares to enumerate on localhost: %v", err)
+	}
+
+	matches, err := Glob(context.Background(), `\\localhost\*`, WithShareEnumeration())
+	if err != nil {
+		t.Fatalf("Glob error: %s", err)
+	}
+	var want []string
+	for _, s := range shares {
+		want = append(want, `\\localhost\`+s)
+	}
+	if diff := cmp.Diff(want, matches, sortStringSlices); diff != "" {
+		t.Errorf("Bad results from Glob(`\\\\localhost\\*`), -want +got: %v", diff)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+var (
+	modnetapi32      = syscall.NewLazyDLL("netapi32.dll")
+	procNetShareEnum = modnetapi32.NewProc("NetShareEnum")
+)
+
+// shareInfo1 mirrors SHARE_INFO_1.
+type shareInfo1 struct {
+	netname *uint16
+	typ     uint32
+	remark  *uint16
+}
+
+const (
+	stypeMask    = 0xff       // STYPE_MASK
+	stypeDisk    = 0          // STYPE_DISKTREE
+	stypeSpecial = 0x80000000 // STYPE_SPECIAL
+
+	errorMoreData syscall.Errno = 234 // ERROR_MORE_DATA
+	maxPreferred                = 0xffffffff
+)
+
+// listShares returns the names of the disk shares of server, leaving out
+// administrative shares such as C$ and ADMIN$.
+func listShares(server string) ([]string, error) {
+	s, err := syscall.UTF16PtrFromString(`\\` + server)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	var resume uint32
+	for {
+		var buf *byte
+		var read, total uint32
+		r, _, _ := procNetShareEnum.Call(
+			uintptr(unsafe.Pointer(s)),
+			1,
+			uintptr(unsafe.Pointer(&buf)),
+			maxPreferred,
+			uintptr(unsafe.Pointer(&read)),
+			uintptr(unsafe.Pointer(&total)),
+			uintptr(unsafe.Pointer(&resume)),
+		)
+		if e := syscall.Errno(r); e != 0 && e != errorMoreData {
+			return nil, &os.PathError{Op: "NetShareEnum", Path: `\\` + server, Err: e}
+		}
+		if buf != nil {
+			shares := unsafe.Slice((*shareInfo1)(unsafe.Pointer(buf)), read)
+			for _, sh := range shares {
+				if sh.typ&stypeMask == stypeDisk && sh.typ&stypeSpecial == 0 {
+					names = append(names, utf16PtrToString(sh.netname))
+				}
+			}
+			syscall.NetApiBufferFree(buf)
+		}
+		if syscall.Errno(r) != errorMoreData {
+			return names, nil
+		}
+	}
+}
+
+// utf16PtrToString converts a NUL-terminated UTF-16 string to a string.
+func utf16PtrToString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	n := 0
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; n++ {
+		ptr = unsafe.Add(ptr, 2)
+	}
+	return string(utf16.Decode(unsafe.Slice(p, n)))
+}
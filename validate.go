@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"os"
+	"runtime"
+	"unicode/utf8"
+)
+
+// Validate reports whether pattern is well formed, without touching the file
+// system, and returns a *PatternError locating the first problem if it isn't.
+// Stream checks its pattern the same way before it reads anything, and fails
+// with the same error, whatever is on disk.
+//
+// Validate checks the syntax of filepath.Match, applied to each element of
+// the pattern in turn; a character class cannot span a path separator. Of the
+// options, only WithPOSIXClasses affects it: with it, a character class may
+// hold POSIX character classes such as "[:alpha:]", and one with an unknown
+// name is rejected.
+func Validate(pattern string, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return validate(pattern, o.posixClasses)
+}
+
+// validate is Validate, with POSIX character classes if posix is set.
+func validate(pattern string, posix bool) error {
+	escapes := runtime.GOOS != "windows"
+	bad := func(start, end int, msg string) error {
+		return &PatternError{Pattern: pattern, Offset: start, Construct: pattern[start:end], Msg: msg}
+	}
+
+	for i := 0; i < len(pattern); {
+		switch c := pattern[i]; {
+		case c == '\\' && escapes:
+			if i+1 == len(pattern) {
+				return bad(i, i+1, "trailing backslash")
+			}
+			_, n := utf8.DecodeRuneInString(pattern[i+1:])
+			i += 1 + n
+		case c == '[':
+			end, err := validateClass(pattern, i, escapes, posix, bad)
+			if err != nil {
+				return err
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return nil
+}
+
+// validateClass checks the character class starting at pattern[start], which
+// is '[', and returns the offset just past it.
+func validateClass(pattern string, start int, escapes, posix bool, bad func(start, end int, msg string) error) (int, error) {
+	// elemEnd is the end of the path element containing the class.
+	elemEnd := start
+	for elemEnd < len(pattern) && !os.IsPathSeparator(pattern[elemEnd]) {
+		elemEnd++
+	}
+	unterminated := bad(start, elemEnd, "unterminated character class")
+
+	// char reads a possibly escaped character of a range at pattern[i:], and
+	// returns the offset just past it.
+	char := func(i int) (int, error) {
+		if i == elemEnd {
+			return 0, unterminated
+		}
+		switch pattern[i] {
+		case '-', ']':
+			return 0, bad(i, i+1, "unescaped '"+pattern[i:i+1]+"' in character class")
+		}
+		c := i
+		if pattern[i] == '\\' && escapes {
+			c++
+			if c == elemEnd {
+				return 0, unterminated
+			}
+		}
+		r, n := utf8.DecodeRuneInString(pattern[c:elemEnd])
+		if r == utf8.RuneError && n == 1 {
+			return 0, bad(c, c+1, "invalid UTF-8 in character class")
+		}
+		return c + n, nil
+	}
+
+	i := start + 1
+	if i < elemEnd && pattern[i] == '^' {
+		i++
+	}
+	for nrange := 0; ; nrange++ {
+		if i == elemEnd {
+			return 0, unterminated
+		}
+		if pattern[i] == ']' {
+			if nrange == 0 {
+				return 0, bad(start, i+1, "empty character class")
+			}
+			return i + 1, nil
+		}
+		if name, next, ok := posixClassAt(pattern, i, elemEnd); ok && posix {
+			if _, known := posixClasses[name]; !known {
+				return 0, bad(i, next, "unknown character class name")
+			}
+			i = next
+			continue
+		}
+		lo := i
+		var err error
+		if i, err = char(i); err != nil {
+			return 0, err
+		}
+		if i < elemEnd && pattern[i] == '-' {
+			if i+1 < elemEnd && pattern[i+1] == ']' {
+				return 0, bad(lo, i+1, "incomplete range in character class")
+			}
+			if i, err = char(i + 1); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	posix := []Option{WithPOSIXClasses()}
+	tests := []struct {
+		pattern string
+		opts    []Option
+		want    *PatternError
+	}{
+		{pattern: ""},
+		{pattern: "*"},
+		{pattern: "a/b?/[cd]/*.go"},
+		{pattern: "[^a-z]"},
+		{pattern: "[]a]", want: &PatternError{Offset: 0, Construct: "[]", Msg: "empty character class"}},
+		{pattern: "x[^]", want: &PatternError{Offset: 1, Construct: "[^]", Msg: "empty character class"}},
+		{pattern: "ab[cd", want: &PatternError{Offset: 2, Construct: "[cd", Msg: "unterminated character class"}},
+		{pattern: "a[b/c]", want: &PatternError{Offset: 1, Construct: "[b", Msg: "unterminated character class"}},
+		{pattern: "[-a]", want: &PatternError{Offset: 1, Construct: "-", Msg: "unescaped '-' in character class"}},
+		{pattern: "[a-]", want: &PatternError{Offset: 1, Construct: "a-", Msg: "incomplete range in character class"}},
+		{pattern: "[a--]", want: &PatternError{Offset: 3, Construct: "-", Msg: "unescaped '-' in character class"}},
+		{pattern: "[a-z", want: &PatternError{Offset: 0, Construct: "[a-z", Msg: "unterminated character class"}},
+		{pattern: "ok/[\xff]", want: &PatternError{Offset: 4, Construct: "\xff", Msg: "invalid UTF-8 in character class"}},
+		{pattern: "[[:alpha:]_][[:alnum:]]*", opts: posix},
+		{pattern: "[^[:space:]]", opts: posix},
+		{pattern: "[[:]", opts: posix},
+		{pattern: "[[:word:]]", opts: posix, want: &PatternError{Offset: 1, Construct: "[:word:]", Msg: "unknown character class name"}},
+		{pattern: "[[:digit:]", opts: posix, want: &PatternError{Offset: 0, Construct: "[[:digit:]", Msg: "unterminated character class"}},
+		{pattern: "[[:digit:]-z]", opts: posix, want: &PatternError{Offset: 10, Construct: "-", Msg: "unescaped '-' in character class"}},
+		// Without WithPOSIXClasses, these are classes of filepath.Match.
+		{pattern: "[[:word:]]"},
+		{pattern: "[[:digit:]"},
+		{pattern: "[[:digit:]-z]"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, []struct {
+			pattern string
+			opts    []Option
+			want    *PatternError
+		}{
+			{pattern: `\*\[`},
+			{pattern: `[\]\-]`},
+			{pattern: `abc\`, want: &PatternError{Offset: 3, Construct: `\`, Msg: "trailing backslash"}},
+			{pattern: `[a\`, want: &PatternError{Offset: 0, Construct: `[a\`, Msg: "unterminated character class"}},
+		}...)
+	}
+
+	for _, tt := range tests {
+		err := Validate(tt.pattern, tt.opts...)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("Validate(%q) returned unexpected error: %v", tt.pattern, err)
+			}
+			continue
+		}
+		tt.want.Pattern = tt.pattern
+		var pe *PatternError
+		if !errors.As(err, &pe) {
+			t.Errorf("Validate(%q) = %v, want a *PatternError", tt.pattern, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, pe); diff != "" {
+			t.Errorf("Validate(%q) returned diff (-want +got):\n%s", tt.pattern, diff)
+		}
+		if !errors.Is(err, filepath.ErrBadPattern) {
+			t.Errorf("Validate(%q) = %v, which is not filepath.ErrBadPattern", tt.pattern, err)
+		}
+	}
+}
+
+// TestValidateAgreesWithMatch checks that Validate rejects every
+// single-element pattern that filepath.Match does. (Match doesn't always
+// check the part of a pattern after a mismatch, so it accepts some patterns
+// that Validate rejects.)
+func TestValidateAgreesWithMatch(t *testing.T) {
+	alphabet := []string{"a", "-", "^", "[", "]", `\`, "*", "?", "\xff"}
+	var patterns []string
+	var gen func(prefix string, n int)
+	gen = func(prefix string, n int) {
+		patterns = append(patterns, prefix)
+		if n == 0 {
+			return
+		}
+		for _, s := range alphabet {
+			gen(prefix+s, n-1)
+		}
+	}
+	gen("", 5)
+
+	for _, p := range patterns {
+		if Validate(p) != nil {
+			continue
+		}
+		for _, name := range []string{"", "a", "aaaa", "-^]", `\`} {
+			if _, err := filepath.Match(p, name); err != nil {
+				t.Errorf("Validate(%q) = nil, but filepath.Match(%[1]q, %q) returned %v", p, name, err)
+			}
+		}
+	}
+}
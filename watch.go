@@ -0,0 +1,193 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const defaultPollInterval = time.Second
+
+// Op describes how the matches of a watched pattern changed.
+type Op int
+
+const (
+	// Create means a path started matching the pattern.
+	Create Op = iota + 1
+	// Remove means a path stopped matching the pattern.
+	Remove
+	// Modify means the size or modification time of a matching path changed.
+	Modify
+)
+
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "Create"
+	case Remove:
+		return "Remove"
+	case Modify:
+		return "Modify"
+	}
+	return "Op(" + strconv.Itoa(int(op)) + ")"
+}
+
+// Event reports a change to the matches of a watched pattern.
+type Event struct {
+	Op   Op
+	Path string
+}
+
+// Watcher is a stream of changes to the matches of a pattern, as reported by
+// Watch, of differences between the matches of two patterns, as reported by
+// ComparePatterns, or of differences between the matches of two runs, as
+// reported by Snapshot.Diff.
+type Watcher struct {
+	errors chan error
+	events chan Event
+	cancel context.CancelFunc
+}
+
+// Watch returns a Watcher that reports changes to the set of paths matching
+// pattern until it is closed. It first reports a Create event for every path
+// that matches when watching starts.
+//
+// Watch works by re-evaluating the pattern periodically (see WithPollInterval)
+// and comparing the matches, with their sizes and modification times, to the
+// previous evaluation. This works on every filesystem, including network and
+// FUSE mounts that do not deliver change notifications, at the cost of
+// latency and of a traversal per interval. Changes that are undone within an
+// interval are not reported.
+func Watch(pattern string, opts ...Option) Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	wt := Watcher{
+		errors: make(chan error),
+		events: make(chan Event),
+		cancel: cancel,
+	}
+	o := options{pollInterval: defaultPollInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.pollInterval <= 0 {
+		o.pollInterval = defaultPollInterval
+	}
+	go func() {
+		defer close(wt.events)
+		defer close(wt.errors)
+		if err := poll(ctx, pattern, o.pollInterval, opts, wt.events); err != nil {
+			select {
+			case wt.errors <- err:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return wt
+}
+
+// Next returns the next change. It returns an Event with a zero Op once the
+// Watcher has been closed.
+func (wt *Watcher) Next() (Event, error) {
+	return wt.NextWithContext(context.Background())
+}
+
+// NextWithContext returns the next change. It returns an Event with a zero Op
+// once the Watcher has been closed.
+//
+// NextWithContext blocks until a change is observed, but respects context
+// cancelation: if ctx is done first, it returns context.Cause(ctx).
+func (wt *Watcher) NextWithContext(ctx context.Context) (Event, error) {
+	select {
+	case err := <-wt.errors:
+		wt.Close()
+		return Event{}, err
+	case e := <-wt.events:
+		return e, nil
+	case <-ctx.Done():
+		return Event{}, context.Cause(ctx)
+	}
+}
+
+// Close stops watching. You can call this any time, including concurrently
+// with Next.
+func (wt *Watcher) Close() error {
+	wt.cancel()
+	return nil
+}
+
+// fileState is what poll compares to detect modifications.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// poll evaluates pattern every interval and sends the differences between
+// consecutive evaluations down the events channel, until ctx is done.
+func poll(ctx context.Context, pattern string, interval time.Duration, opts []Option, events chan<- Event) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := make(map[string]fileState)
+	for {
+		// The matches' metadata is read as the Stream reads its
+		// filesystem, which needn't be the working directory's.
+		r := Stream(pattern, opts...)
+		snap, err := TakeSnapshot(ctx, &r, true)
+		r.Close()
+		if ctx.Err() != nil {
+			// The Watcher was closed, which isn't an error even if it
+			// interrupted the evaluation.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		cur := make(map[string]fileState, snap.Len())
+		for _, m := range snap.Paths() {
+			st := fileState{size: snap.files[m].Size, modTime: snap.files[m].ModTime}
+			cur[m] = st
+
+			old, ok := prev[m]
+			switch {
+			case !ok:
+				err = send(ctx, events, Event{Op: Create, Path: m})
+			case old.size != st.size || !old.modTime.Equal(st.modTime):
+				err = send(ctx, events, Event{Op: Modify, Path: m})
+			}
+			if err != nil {
+				return nil
+			}
+		}
+		for m := range prev {
+			if _, ok := cur[m]; !ok {
+				if send(ctx, events, Event{Op: Remove, Path: m}) != nil {
+					return nil
+				}
+			}
+		}
+		prev = cur
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// send sends e down the events channel unless ctx is done first.
+func send(ctx context.Context, events chan<- Event, e Event) error {
+	select {
+	case events <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
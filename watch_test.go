@@ -0,0 +1,186 @@
+// Copyright 2020 Google LLC
+
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or at
+// https://developers.google.com/open-source/licenses/bsd
+
+package glob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatch(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestWatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	existing := filepath.Join(tmpDir, "existing.log")
+	if err := ioutil.WriteFile(existing, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "ignored.txt"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	wt := Watch(filepath.Join(tmpDir, "*.log"), WithPollInterval(10*time.Millisecond))
+	defer wt.Close()
+
+	next := func() Event {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		e, err := wt.NextWithContext(ctx)
+		if err != nil {
+			t.Fatalf("NextWithContext() returned unexpected error: %v", err)
+		}
+		return e
+	}
+
+	if diff := cmp.Diff(Event{Create, existing}, next()); diff != "" {
+		t.Errorf("Bad initial event, -want +got: %v", diff)
+	}
+
+	created := filepath.Join(tmpDir, "created.log")
+	if err := ioutil.WriteFile(created, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Event{Create, created}, next()); diff != "" {
+		t.Errorf("Bad event after creating file, -want +got: %v", diff)
+	}
+
+	if err := ioutil.WriteFile(existing, []byte("grown"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Event{Modify, existing}, next()); diff != "" {
+		t.Errorf("Bad event after modifying file, -want +got: %v", diff)
+	}
+
+	if err := os.Remove(created); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Event{Remove, created}, next()); diff != "" {
+		t.Errorf("Bad event after removing file, -want +got: %v", diff)
+	}
+
+	if err := wt.Close(); err != nil {
+		t.Fatalf("Close() returned unexpected error: %v", err)
+	}
+	e, err := wt.Next()
+	if err != nil {
+		t.Errorf("After Close(), Next() returned unexpected error: %v", err)
+	}
+	if e.Op != 0 {
+		t.Errorf("After Close(), Next() returned event %v", e)
+	}
+}
+
+func TestWatchInvalidPattern(t *testing.T) {
+	wt := Watch("[]", WithPollInterval(10*time.Millisecond))
+	defer wt.Close()
+	if _, err := wt.Next(); err == nil {
+		t.Error("expected error for bad pattern; got none")
+	}
+}
+
+func TestWatchBadPollInterval(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestWatchBadPollInterval")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	existing := filepath.Join(tmpDir, "existing.log")
+	if err := ioutil.WriteFile(existing, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// An interval that isn't positive means the default.
+	for _, d := range []time.Duration{0, -time.Second} {
+		wt := Watch(filepath.Join(tmpDir, "*.log"), WithPollInterval(d))
+		e, err := wt.Next()
+		if err != nil {
+			t.Errorf("With WithPollInterval(%v), Next() returned unexpected error: %v", d, err)
+		}
+		if diff := cmp.Diff(Event{Create, existing}, e); diff != "" {
+			t.Errorf("Bad initial event with WithPollInterval(%v), -want +got: %v", d, diff)
+		}
+		wt.Close()
+	}
+}
+
+func TestWatchWithRoot(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestWatchWithRoot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "existing.log"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// The matches are relative to the root, not to the working directory.
+	wt := Watch("*.log", WithRoot(tmpDir), WithPollInterval(10*time.Millisecond))
+	defer wt.Close()
+
+	next := func() Event {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		e, err := wt.NextWithContext(ctx)
+		if err != nil {
+			t.Fatalf("NextWithContext() returned unexpected error: %v", err)
+		}
+		return e
+	}
+
+	if diff := cmp.Diff(Event{Create, "existing.log"}, next()); diff != "" {
+		t.Errorf("Bad initial event, -want +got: %v", diff)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "existing.log"), []byte("grown"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Event{Modify, "existing.log"}, next()); diff != "" {
+		t.Errorf("Bad event after modifying file, -want +got: %v", diff)
+	}
+}
+
+func TestWatchCloseThenNext(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestWatchCloseThenNext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for i := 0; i < 100; i++ {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, strconv.Itoa(i)+".log"), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Closing the Watcher will often interrupt its first evaluation, which
+	// mustn't be reported as an error.
+	for i := 0; i < 100; i++ {
+		wt := Watch(filepath.Join(tmpDir, "*.log"), WithPollInterval(10*time.Millisecond))
+		if err := wt.Close(); err != nil {
+			t.Fatalf("Close() returned unexpected error: %v", err)
+		}
+		for {
+			e, err := wt.Next()
+			if err != nil {
+				t.Fatalf("After Close(), Next() returned unexpected error: %v", err)
+			}
+			if e.Op == 0 {
+				break
+			}
+		}
+	}
+}